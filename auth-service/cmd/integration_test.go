@@ -97,16 +97,14 @@ func TestMain(t *testing.T) {
 	pgPort, err := postgresContainer.MappedPort(ctx, "5432")
 	require.NoError(t, err, fmt.Sprintf("failed to get container mapped port: %s", err))
 
-	// Set environment variables for the test process
-	t.Setenv("DB_HOST", pgHost)
-	t.Setenv("DB_PORT", pgPort.Port())
-	t.Setenv("DB_USER", dbUser)
-	t.Setenv("DB_PASSWORD", dbPassword)
-	t.Setenv("DB_NAME", dbName)
-
 	// Config database structure
-	database, err := db.Config()
-	require.NoError(t, err, fmt.Sprintf("failed to config database: %s", err))
+	database := db.New(db.Config{
+		Host:     pgHost,
+		Port:     pgPort.Int(),
+		User:     dbUser,
+		Password: dbPassword,
+		Dbname:   dbName,
+	})
 	// Establish database connection
 	err = database.OpenDbConnection()
 	require.NoError(t, err, fmt.Sprintf("failed to open test db connection: %s", err))
@@ -227,7 +225,7 @@ func TestMain(t *testing.T) {
 		require.NoError(t, err, fmt.Sprintf("Could not register user: %v", err))
 
 		// Get inserted user from the db
-		resultUser, err := database.SelectUserByUsername(createUserReq.Username)
+		resultUser, err := database.SelectUserByUsername(ctx, createUserReq.Username)
 		require.NoError(t, err, fmt.Sprintf("Failed to select user: %v", err))
 
 		// Compare username
@@ -380,7 +378,7 @@ func TestMain(t *testing.T) {
 		require.NoError(t, err, fmt.Sprintf("Could not update the user: %v", err))
 
 		// Get inserted user from the db
-		resultUser, err := database.SelectUserByUsername(UpdateReq.Username)
+		resultUser, err := database.SelectUserByUsername(ctx, UpdateReq.Username)
 		require.NoError(t, err, fmt.Sprintf("Failed to select user: %v", err))
 
 		// Compare username
@@ -489,13 +487,20 @@ func TestMain(t *testing.T) {
 		}
 
 		// Validate response
-		_, err = client.DeleteUser(authedCtx, &deleteUserReq)
+		deleteUserResponse, err := client.DeleteUser(authedCtx, &deleteUserReq)
 		require.NoError(t, err, fmt.Sprintf("Could not delete user: %v", err))
 
 		// Get inserted user from the db
-		_, err = database.SelectUserByID(int(deleteUserReq.Id))
+		_, err = database.SelectUserByID(ctx, int(deleteUserReq.Id))
 		require.Error(t, err, "User still exists after delete operation")
 
+		// The content of the user waits for blog-service
+		deletion, err := client.GetUserDeletion(authedCtx, &pb.GetUserDeletionRequest{Id: deleteUserResponse.GetDeletionId()})
+		require.NoError(t, err, fmt.Sprintf("Could not get user deletion: %v", err))
+		require.Equal(t, deleteUserReq.Id, deletion.GetUserId())
+		require.Equal(t, "pending", deletion.GetStatus())
+		require.Equal(t, pb.DeletionMode_DELETION_MODE_ANONYMISE, deletion.GetMode())
+
 	})
 
 }
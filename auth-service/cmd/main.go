@@ -1,40 +1,226 @@
 package main
 
 import (
+	"auth-service/internal/config"
 	"auth-service/internal/db"
+	"auth-service/internal/logging"
+	"auth-service/internal/metrics"
+	"auth-service/internal/migrate"
 	"auth-service/internal/server"
+	"auth-service/internal/tracing"
 	"context"
+	"flag"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
-func main() {
-	fmt.Println("Starting auth-service")
+const probeTimeout = 3 * time.Second
 
-	ctx := context.Background()
+/*
+Exit codes of auth-service: 1 when it cannot start (configuration, database, migrations, listener,
+also used by the migrate subcommand), 3 when the gRPC server stops on its own,
+2 when the in-flight calls are not drained within SHUTDOWN_TIMEOUT unless the server already failed.
+The -healthcheck probe keeps to the 0 or 1 Docker expects.
+*/
+const (
+	exitOK       = 0
+	exitStartup  = 1
+	exitShutdown = 2
+	exitServe    = 3
+)
 
-	// Config database
-	database, err := db.Config()
+func main() {
+	healthcheck := flag.Bool("healthcheck", false, "probe the health service of a running instance and exit")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration, secrets redacted, and exit")
+	flag.Parse()
+
+	// An invalid configuration stops the service before it touches anything
+	cfg, err := config.Load()
 	if err != nil {
 		fmt.Println(err)
-		return
+		os.Exit(exitStartup)
+	}
+
+	switch {
+	case *printConfig:
+		cfg.Print(os.Stdout)
+	case flag.Arg(0) == "migrate":
+		os.Exit(runMigrate(cfg, flag.Args()[1:]))
+	case *healthcheck:
+		os.Exit(probe(cfg))
+	default:
+		os.Exit(run(cfg))
 	}
+}
+
+func run(cfg *config.Config) int {
+	logging.Setup(cfg.LogLevel)
+	slog.Info("Starting auth-service", "config", cfg)
+
+	// Export traces, flushed once everything else is stopped
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracesExporter)
+	if err != nil {
+		slog.Error("Failed to set up tracing", "error", err)
+		return exitStartup
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+	}()
+
+	// Expose the metrics on their own listener
+	metricsServer := metrics.NewServer(cfg.MetricsAddr)
+	go func() {
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("Metrics listener failed", "error", err)
+		}
+	}()
+	defer metricsServer.Close()
+
+	// Config database
+	database := db.New(cfg.DB)
 	// Open connection
 	err = database.OpenDbConnection()
 	if err != nil {
-		fmt.Println(err)
-		return
+		slog.Error("Failed to connect to PostgreSQL", "error", err)
+		return exitStartup
 	}
 
-	// Defer closing connection
+	// Defer closing connection, it runs after the calls are drained
 	defer database.ConnPool.Close()
 
+	if err := metrics.RegisterPool(database.ConnPool); err != nil {
+		slog.Error("Failed to register PostgreSQL pool metrics", "error", err)
+		return exitStartup
+	}
+
+	// Bring the schema up to date, replicas starting together wait for each other
+	if cfg.MigrateOnStart {
+		migrator, err := migrate.New(database.ConnPool, tracing.ServiceName, db.Migrations())
+		if err != nil {
+			slog.Error("Failed to read migrations", "error", err)
+			return exitStartup
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			slog.Error("Failed to migrate PostgreSQL", "error", err)
+			return exitStartup
+		}
+	}
+
 	// Initilize database
-	err = database.Init()
+	err = database.Init(cfg.DefaultUser)
+	if err != nil {
+		slog.Error("Failed to initialize database", "error", err)
+		return exitStartup
+	}
+
+	grpcServer := server.NewGRPCServer(cfg.Server, database)
+
+	// Keep the health service in line with the database
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	healthStopped := make(chan struct{})
+	go func() {
+		defer close(healthStopped)
+		grpcServer.WatchHealth(healthCtx)
+	}()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- grpcServer.ListenAndServe(context.Background())
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	code := exitOK
+	select {
+	case sig := <-signals:
+		slog.Info("Shutting down", "signal", sig.String())
+	case err := <-serveErr:
+		slog.Error("Server failed", "error", err)
+		code = exitServe
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := grpcServer.Shutdown(ctx); err != nil {
+		slog.Error("Failed to drain gRPC calls", "error", err)
+		code = max(code, exitShutdown)
+	}
+
+	stopHealth()
+	<-healthStopped
+
+	slog.Info("auth-service stopped")
+
+	return code
+}
+
+// runMigrate runs the migrate subcommand against the configured database.
+func runMigrate(cfg *config.Config, args []string) int {
+	logging.Setup(cfg.LogLevel)
+
+	database := db.New(cfg.DB)
+	if err := database.OpenDbConnection(); err != nil {
+		slog.Error("Failed to connect to PostgreSQL", "error", err)
+		return exitStartup
+	}
+	defer database.ConnPool.Close()
+
+	migrator, err := migrate.New(database.ConnPool, tracing.ServiceName, db.Migrations())
+	if err != nil {
+		slog.Error("Failed to read migrations", "error", err)
+		return exitStartup
+	}
+
+	if err := migrator.Run(context.Background(), args, os.Stdout); err != nil {
+		slog.Error("Migration failed", "error", err)
+		return exitStartup
+	}
+
+	return exitOK
+}
+
+/*
+probe asks the instance listening locally for its health, as the container health check.
+The image has no shell or grpc_health_probe, so the binary probes itself.
+*/
+func probe(cfg *config.Config) int {
+	conn, err := grpc.NewClient(fmt.Sprintf("localhost:%d", cfg.Server.Port), grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		fmt.Println(err)
-		return
+		return 1
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	fmt.Println(resp.GetStatus())
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return 1
 	}
 
-	grpcServer := server.NewGRPCServer(database)
-	grpcServer.ListenAndServe(ctx)
+	return 0
 }
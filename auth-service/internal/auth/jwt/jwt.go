@@ -2,7 +2,6 @@ package jwt
 
 import (
 	"fmt"
-	"os"
 	"time"
 
 	"auth-service/internal/auth/models"
@@ -17,8 +16,8 @@ type CustomClaims struct {
 	Role     models.Role
 }
 
-func GenerateJWT(user models.User, ttl time.Duration) (string, error) {
-	secret := os.Getenv("JWT_SECRET")
+// GenerateJWT signs a token for user with secret, valid for ttl.
+func GenerateJWT(secret []byte, user models.User, ttl time.Duration) (string, error) {
 	claims := CustomClaims{
 		ID:       user.ID,
 		Username: user.Username,
@@ -30,18 +29,17 @@ func GenerateJWT(user models.User, ttl time.Duration) (string, error) {
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	return token.SignedString([]byte(secret))
+	return token.SignedString(secret)
 }
 
-func ValidateJWT(tokenStr string) (*jwt.Token, *CustomClaims, error) {
-	secret := os.Getenv("JWT_SECRET")
-
+// ValidateJWT checks the token was signed with secret and has not expired.
+func ValidateJWT(secret []byte, tokenStr string) (*jwt.Token, *CustomClaims, error) {
 	claims := &CustomClaims{}
 	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(secret), nil
+		return secret, nil
 	})
 
 	if err != nil {
@@ -10,6 +10,8 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var testSecret = []byte("test_secret") //nolint:gochecknoglobals // Required for testing
+
 // Checking if token ie generated correctly and is Valid with signature
 func TestGenerateAndValidate(t *testing.T) {
 	subject := models.User{
@@ -20,12 +22,12 @@ func TestGenerateAndValidate(t *testing.T) {
 		Role:     1,
 	}
 
-	token, err := GenerateJWT(subject, time.Minute)
+	token, err := GenerateJWT(testSecret, subject, time.Minute)
 	if err != nil {
 		t.Fatalf("Failed to generate token")
 	}
 
-	parse, _, err := ValidateJWT(token)
+	parse, _, err := ValidateJWT(testSecret, token)
 	if err != nil {
 		t.Fatalf("Failed to validate token")
 	}
@@ -46,12 +48,12 @@ func TestValidateExpiredToken(t *testing.T) {
 	}
 
 	// Using -time.Minutes to make an expired token
-	token, err := GenerateJWT(subject, -time.Minute)
+	token, err := GenerateJWT(testSecret, subject, -time.Minute)
 	if err != nil {
 		t.Fatalf("Failed to generate token for expiration test: %v", err)
 	}
 
-	_, _, err = ValidateJWT(token)
+	_, _, err = ValidateJWT(testSecret, token)
 	if err == nil {
 		t.Fatalf("Expected an error for expired token, but got nil")
 	}
@@ -1,16 +1,20 @@
 package models
 
+import "time"
+
 type Role int
 
 const (
 	USER Role = iota
 	ADMIN
+	MODERATOR
 )
 
 const (
-	userRoleString  = "User"
-	adminRoleString = "Admin"
-	unknownRole     = "Unknown"
+	userRoleString      = "User"
+	adminRoleString     = "Admin"
+	moderatorRoleString = "Moderator"
+	unknownRole         = "Unknown"
 )
 
 func (r Role) RoleString() string {
@@ -19,6 +23,8 @@ func (r Role) RoleString() string {
 		return userRoleString
 	case ADMIN:
 		return adminRoleString
+	case MODERATOR:
+		return moderatorRoleString
 	default:
 		return unknownRole
 	}
@@ -28,6 +34,8 @@ func StringToRole(roleStr string) Role {
 	switch roleStr {
 	case adminRoleString:
 		return ADMIN
+	case moderatorRoleString:
+		return MODERATOR
 	case userRoleString:
 		return USER
 	default:
@@ -57,3 +65,34 @@ type UserRegisterDTO struct {
 type UserLoginResponseDTO struct {
 	Token string `json:"token"`
 }
+
+// DeletionMode tells what happens to the articles and comments of a deleted user.
+type DeletionMode string
+
+const (
+	DeletionAnonymise DeletionMode = "anonymise"
+	DeletionPurge     DeletionMode = "purge"
+)
+
+// Status of a user deletion.
+const (
+	DeletionPending = "pending"
+	DeletionDone    = "done"
+)
+
+/*
+UserDeletion is the job removing the content of a deleted user from blog-service.
+Outcome counts what each step changed, CompletedAt is nil while it is pending.
+*/
+type UserDeletion struct {
+	RequestedAt time.Time
+	CompletedAt *time.Time
+	Outcome     map[string]int64
+	Username    string
+	Mode        DeletionMode
+	Status      string
+	LastError   string
+	ID          int64
+	UserID      int32
+	Attempts    int32
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"auth-service/internal/db"
+	"auth-service/internal/server"
+	"auth-service/internal/tracing"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FileVariable names the optional file holding settings, as KEY=value lines. The environment overrides it.
+const FileVariable = "CONFIG_FILE"
+
+// Config is everything auth-service reads from its environment, loaded once at startup.
+type Config struct {
+	ShutdownTimeout time.Duration
+	LogLevel        slog.Level
+	MetricsAddr     string
+	TracesExporter  string
+	// MigrateOnStart applies the pending migrations before serving, otherwise run "migrate up" first
+	MigrateOnStart bool
+
+	Server      server.Config
+	DB          db.Config
+	DefaultUser db.DefaultUser
+
+	entries []entry
+}
+
+/*
+Load reads the configuration from the environment and CONFIG_FILE, applies the defaults and validates it.
+Secrets (JWT_SECRET, SERVICE_TOKEN and the passwords) can be read from <KEY>_FILE instead.
+@returns
+error - every invalid or missing setting, the service must not start with it.
+*/
+func Load() (*Config, error) {
+	return load(os.Environ())
+}
+
+func load(environ []string) (*Config, error) {
+	l := newLoader(environ)
+
+	c := &Config{
+		ShutdownTimeout: l.duration("SHUTDOWN_TIMEOUT", 20*time.Second),
+		LogLevel:        l.level("LOG_LEVEL", slog.LevelInfo),
+		MetricsAddr:     l.string("METRICS_ADDR", ":9090"),
+		TracesExporter:  l.string("OTEL_TRACES_EXPORTER", tracing.ExporterNone),
+		MigrateOnStart:  l.bool("MIGRATE_ON_START", true),
+	}
+
+	c.Server = server.Config{
+		Port:      l.int("PORT", 9001),
+		JWTSecret: []byte(l.secret("JWT_SECRET")),
+		TokenTTL:  l.duration("JWT_TTL", 5*time.Minute),
+		// Shared with blog-service, which removes the content of deleted users
+		ServiceToken: l.secret("SERVICE_TOKEN"),
+	}
+
+	c.DB = db.Config{
+		Host:     l.string("DB_HOST", ""),
+		Port:     l.int("DB_PORT", 5432),
+		User:     l.string("DB_USER", ""),
+		Password: l.secret("DB_PASSWORD"),
+		Dbname:   l.string("DB_NAME", ""),
+	}
+
+	c.DefaultUser = db.DefaultUser{
+		Username: l.string("DEFAULT_USER_USERNAME", ""),
+		Password: l.secret("DEFAULT_USER_PASS"),
+		Email:    l.string("DEFAULT_USER_EMAIL", ""),
+	}
+
+	c.entries = l.entries
+
+	if err := errors.Join(l.err(), c.Validate()); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	return c, nil
+}
+
+// Validate checks the settings are usable, the parsing errors are reported by Load.
+func (c *Config) Validate() error {
+	var errs []error
+	require := func(key, value string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", key))
+		}
+	}
+	positive := func(key string, value int64) {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", key))
+		}
+	}
+
+	positive("SHUTDOWN_TIMEOUT", int64(c.ShutdownTimeout))
+	require("METRICS_ADDR", c.MetricsAddr)
+	if allowed := []string{tracing.ExporterOTLP, tracing.ExporterStdout, tracing.ExporterNone}; !slices.Contains(allowed, c.TracesExporter) {
+		errs = append(errs, fmt.Errorf("OTEL_TRACES_EXPORTER must be one of %s, got %q", strings.Join(allowed, ", "), c.TracesExporter))
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be between 1 and 65535, got %d", c.Server.Port))
+	}
+	// An empty secret would sign tokens anyone can forge
+	require("JWT_SECRET", string(c.Server.JWTSecret))
+	positive("JWT_TTL", int64(c.Server.TokenTTL))
+
+	require("DB_HOST", c.DB.Host)
+	require("DB_USER", c.DB.User)
+	require("DB_NAME", c.DB.Dbname)
+	positive("DB_PORT", int64(c.DB.Port))
+
+	require("DEFAULT_USER_USERNAME", c.DefaultUser.Username)
+	require("DEFAULT_USER_PASS", c.DefaultUser.Password)
+	require("DEFAULT_USER_EMAIL", c.DefaultUser.Email)
+
+	return errors.Join(errs...)
+}
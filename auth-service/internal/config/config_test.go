@@ -0,0 +1,51 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var required = []string{ //nolint:gochecknoglobals // Required for testing
+	"DB_HOST=postgres",
+	"DB_USER=auth",
+	"DB_PASSWORD=db-secret",
+	"DB_NAME=auth",
+	"DEFAULT_USER_USERNAME=admin",
+	"DEFAULT_USER_PASS=admin-secret",
+	"DEFAULT_USER_EMAIL=admin@example.com",
+}
+
+func TestEmptyJWTSecretIsRefused(t *testing.T) {
+	_, err := load(append(required, "JWT_SECRET="))
+	if err == nil || !strings.Contains(err.Error(), "JWT_SECRET is required") {
+		t.Fatalf("Expected the missing JWT secret to be reported, got %v", err)
+	}
+}
+
+func TestJWTSecretFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwt_secret")
+	if err := os.WriteFile(path, []byte("mounted-secret\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+
+	cfg, err := load(append(required, "JWT_SECRET_FILE="+path))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if string(cfg.Server.JWTSecret) != "mounted-secret" {
+		t.Errorf("JWT secret = %q, want the content of the file", cfg.Server.JWTSecret)
+	}
+	if cfg.Server.Port != 9001 {
+		t.Errorf("Port = %d, want the default 9001", cfg.Server.Port)
+	}
+
+	var out bytes.Buffer
+	cfg.Print(&out)
+	if strings.Contains(out.String(), "secret\n") || !strings.Contains(out.String(), "JWT_SECRET="+redacted) {
+		t.Errorf("Secrets were not redacted:\n%s", out.String())
+	}
+}
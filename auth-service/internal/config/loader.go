@@ -0,0 +1,233 @@
+// Kept identical in blog-service and auth-service, change both copies together.
+
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+// entry is the effective value of a setting, as printed.
+type entry struct {
+	key    string
+	value  string
+	secret bool
+}
+
+/*
+loader reads settings from the environment first, then from the optional file.
+Each read records its effective value and the errors are gathered,
+so a bad configuration is reported at once instead of one setting per start.
+*/
+type loader struct {
+	env     map[string]string
+	file    map[string]string
+	entries []entry
+	errs    []error
+}
+
+// newLoader reads from environ, KEY=value pairs as returned by os.Environ.
+func newLoader(environ []string) *loader {
+	l := &loader{env: map[string]string{}, file: map[string]string{}}
+
+	for _, pair := range environ {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			l.env[key] = value
+		}
+	}
+
+	if path := l.env[FileVariable]; path != "" {
+		file, err := readFile(path)
+		if err != nil {
+			l.errs = append(l.errs, err)
+		}
+		l.file = file
+	}
+
+	return l
+}
+
+/*
+readFile reads a file of KEY=value lines, the same keys as the environment.
+Empty lines and lines starting with # are skipped, values may be quoted.
+*/
+func readFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return map[string]string{}, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return values, fmt.Errorf("%s:%d: expected KEY=value", path, line)
+		}
+		if unquoted, err := strconv.Unquote(strings.TrimSpace(value)); err == nil {
+			value = unquoted
+		}
+		values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return values, scanner.Err()
+}
+
+/*
+lookup returns the raw value of a setting, the environment taking precedence over the file.
+Secrets may instead be kept in a file named by <KEY>_FILE, as mounted by Docker secrets.
+*/
+func (l *loader) lookup(key string, secret bool) (string, bool) {
+	for _, source := range []map[string]string{l.env, l.file} {
+		if value, ok := source[key]; ok && value != "" {
+			return value, true
+		}
+		if !secret {
+			continue
+		}
+		if path, ok := source[key+"_FILE"]; ok && path != "" {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				l.errs = append(l.errs, fmt.Errorf("failed to read %s_FILE: %w", key, err))
+				return "", false
+			}
+			return strings.TrimRight(string(content), "\r\n"), true
+		}
+	}
+
+	return "", false
+}
+
+func (l *loader) record(key, value string, secret bool) {
+	l.entries = append(l.entries, entry{key: key, value: value, secret: secret})
+}
+
+func (l *loader) invalid(key, value string) {
+	l.errs = append(l.errs, fmt.Errorf("invalid %s %q", key, value))
+}
+
+func (l *loader) string(key, fallback string) string {
+	value, ok := l.lookup(key, false)
+	if !ok {
+		value = fallback
+	}
+	l.record(key, value, false)
+
+	return value
+}
+
+func (l *loader) secret(key string) string {
+	value, _ := l.lookup(key, true)
+	l.record(key, value, true)
+
+	return value
+}
+
+func (l *loader) int(key string, fallback int) int {
+	raw, ok := l.lookup(key, false)
+	if !ok {
+		l.record(key, strconv.Itoa(fallback), false)
+		return fallback
+	}
+	l.record(key, raw, false)
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		l.invalid(key, raw)
+	}
+
+	return value
+}
+
+func (l *loader) bool(key string, fallback bool) bool {
+	raw, ok := l.lookup(key, false)
+	if !ok {
+		l.record(key, strconv.FormatBool(fallback), false)
+		return fallback
+	}
+	l.record(key, raw, false)
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		l.invalid(key, raw)
+	}
+
+	return value
+}
+
+func (l *loader) duration(key string, fallback time.Duration) time.Duration {
+	raw, ok := l.lookup(key, false)
+	if !ok {
+		l.record(key, fallback.String(), false)
+		return fallback
+	}
+	l.record(key, raw, false)
+
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		l.invalid(key, raw)
+	}
+
+	return value
+}
+
+func (l *loader) level(key string, fallback slog.Level) slog.Level {
+	raw, ok := l.lookup(key, false)
+	if !ok {
+		l.record(key, strings.ToLower(fallback.String()), false)
+		return fallback
+	}
+	l.record(key, raw, false)
+
+	var value slog.Level
+	if err := value.UnmarshalText([]byte(raw)); err != nil {
+		l.invalid(key, raw)
+	}
+
+	return value
+}
+
+func (l *loader) err() error {
+	return errors.Join(l.errs...)
+}
+
+// printable returns the value of an entry as it may be shown, secrets only tell whether they are set.
+func (e entry) printable() string {
+	if e.secret && e.value != "" {
+		return redacted
+	}
+
+	return e.value
+}
+
+// Print writes the effective configuration as KEY=value lines, secrets redacted.
+func (c *Config) Print(w io.Writer) {
+	for _, e := range c.entries {
+		fmt.Fprintf(w, "%s=%s\n", e.key, e.printable())
+	}
+}
+
+// LogValue logs the effective configuration as a group of its settings, secrets redacted.
+func (c *Config) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(c.entries))
+	for _, e := range c.entries {
+		attrs = append(attrs, slog.String(e.key, e.printable()))
+	}
+
+	return slog.GroupValue(attrs...)
+}
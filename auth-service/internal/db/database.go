@@ -3,11 +3,11 @@ package db
 import (
 	"auth-service/internal/auth/models"
 	"auth-service/internal/crypto"
+	"auth-service/internal/tracing"
 	"context"
 	"errors"
 	"fmt"
-	"os"
-	"strconv"
+	"log/slog"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -27,28 +27,35 @@ type Database struct {
 	ConnPool *pgxpool.Pool
 }
 
+// Config locates the PostgreSQL database of the service.
+type Config struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Dbname   string
+}
+
+// DefaultUser is the admin account created on the first start.
+type DefaultUser struct {
+	Username string
+	Password string
+	Email    string
+}
+
 /*
-Etablish database configuration.
-Creates a new Databse instance.
+Creates a new Database instance, the connection is opened by OpenDbConnection.
 @returns
 *Databse - pointer to a database structure.
 */
-func Config() (*Database, error) {
-	// Convert .env PORT to int
-	dbPort, err := strconv.Atoi(os.Getenv("DB_PORT"))
-	if err != nil {
-		return nil, fmt.Errorf("error converting DB_PORT: %w", err)
+func New(cfg Config) *Database {
+	return &Database{
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		User:     cfg.User,
+		Password: cfg.Password,
+		Dbname:   cfg.Dbname,
 	}
-
-	db := Database{
-		Host:     os.Getenv("DB_HOST"),
-		Port:     dbPort,
-		User:     os.Getenv("DB_USER"),
-		Password: os.Getenv("DB_PASSWORD"),
-		Dbname:   os.Getenv("DB_NAME"),
-	}
-
-	return &db, nil
 }
 
 /*
@@ -62,10 +69,14 @@ func (db *Database) OpenDbConnection() error {
 	databaseURL := fmt.Sprintf("postgresql://%s:%s@%s:%d/%s?pool_max_conns=10",
 		db.User, db.Password, db.Host, db.Port, db.Dbname)
 
-	var err error
+	config, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return fmt.Errorf("invalid database URL: %w", err)
+	}
+	config.ConnConfig.Tracer = tracing.PgxTracer{}
 
 	// Create db connection
-	db.ConnPool, err = pgxpool.New(context.Background(), databaseURL)
+	db.ConnPool, err = pgxpool.NewWithConfig(context.Background(), config)
 	if err != nil {
 		return fmt.Errorf("unable to create connection pool: %w", err)
 	}
@@ -80,11 +91,16 @@ func (db *Database) OpenDbConnection() error {
 		return fmt.Errorf("connection failed: %w", err)
 	}
 
-	fmt.Println("Successfully connected to PostGres DB!")
+	slog.Info("Successfully connected to PostgreSQL")
 
 	return nil
 }
 
+// Ping checks that a connection can be taken from the pool and used.
+func (db *Database) Ping(ctx context.Context) error {
+	return db.ConnPool.Ping(ctx)
+}
+
 func (db *Database) UserExists(username string) (bool, error) {
 	query := "SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)"
 	var exists bool
@@ -96,44 +112,37 @@ func (db *Database) UserExists(username string) (bool, error) {
 	return exists, nil
 }
 
-func (db *Database) Init() error {
-	result, err := db.ExecuteQuery(Schema)
-	if err != nil {
-		return fmt.Errorf("cannot CREATE users Table: %w", err)
-	}
-	fmt.Println("CREATE users TABLE Result:", result.String())
-
-	defaultUserUsername := os.Getenv("DEFAULT_USER_USERNAME")
-
+// Init creates the default admin account unless it already exists, the schema must be migrated first.
+func (db *Database) Init(defaultUser DefaultUser) error {
 	// Check if the default user already exists
-	exists, err := db.UserExists(defaultUserUsername)
+	exists, err := db.UserExists(defaultUser.Username)
 	if err != nil {
 		return fmt.Errorf("error checking if user exists: %w", err)
 	}
 	if exists {
-		fmt.Printf("Default user '%s' already exists. Skipping creation.\n", defaultUserUsername)
+		slog.Info("Default user already exists, skipping creation", "username", defaultUser.Username)
 		return nil
 	}
 
 	// Hash Default User Password
 	params := crypto.GetDefaultParams()
-	hashedPassword, err := crypto.HashPassword(os.Getenv("DEFAULT_USER_PASS"), &params)
+	hashedPassword, err := crypto.HashPassword(defaultUser.Password, &params)
 	if err != nil {
 		return fmt.Errorf("error in hashing password %w", err)
 	}
 
-	defaultUser := models.User{
-		Username: os.Getenv("DEFAULT_USER_USERNAME"),
+	admin := models.User{
+		Username: defaultUser.Username,
 		Password: hashedPassword,
-		Email:    os.Getenv("DEFAULT_USER_EMAIL"),
+		Email:    defaultUser.Email,
 		Role:     models.ADMIN,
 	}
 
-	result, err = db.CreateUser(&defaultUser)
+	result, err := db.CreateUser(context.Background(), &admin)
 	if err != nil {
 		return fmt.Errorf("cannot CREATE users Table: %w", err)
 	}
-	fmt.Println("CREATE default user Result:", result.String())
+	slog.Info("Created default user", "result", result.String())
 
 	return nil
 }
@@ -168,13 +177,14 @@ func (db *Database) ExecuteQuery(query string) (pgconn.CommandTag, error) {
 /*
 Function used to insert an User
 @params
+ctx - context of the call, carrying its deadline and trace.
 query - string INSERT query
 user - user structure with the new db entry
 @returns
 error - for checking the execution of the query.
 pgconn.CommandTag - to check the query result.
 */
-func (db *Database) CreateUser(user *models.User) (pgconn.CommandTag, error) {
+func (db *Database) CreateUser(ctx context.Context, user *models.User) (pgconn.CommandTag, error) {
 	// InsertQuery
 	const query = `INSERT INTO users (Username, Password, Email, Role) 
 	          VALUES($1, $2, $3, $4)`
@@ -184,9 +194,6 @@ func (db *Database) CreateUser(user *models.User) (pgconn.CommandTag, error) {
 		return pgconn.CommandTag{}, fmt.Errorf("unable to connect to database")
 	}
 
-	// Create a backgrond context ( no timeout or deadline )
-	ctx := context.Background()
-
 	// Execute query
 	commandTag, err := db.ConnPool.Exec(ctx, query, user.Username, user.Password, user.Email, user.Role.RoleString())
 	if err != nil {
@@ -199,13 +206,14 @@ func (db *Database) CreateUser(user *models.User) (pgconn.CommandTag, error) {
 /*
 Function used to update an User
 @params
+ctx - context of the call, carrying its deadline and trace.
 query - string UPDATE query
 user - user structure for the update table entry
 @returns
 error - for checking the execution of the query.
 pgconn.CommandTag - to check the query result.
 */
-func (db *Database) UpdateUser(userToUpdate *models.User) (pgconn.CommandTag, error) {
+func (db *Database) UpdateUser(ctx context.Context, userToUpdate *models.User) (pgconn.CommandTag, error) {
 	// Update query
 	const query = `UPDATE users 
               SET Username = $2, Password = $3, Email = $4, Role = $5
@@ -216,9 +224,6 @@ func (db *Database) UpdateUser(userToUpdate *models.User) (pgconn.CommandTag, er
 		return pgconn.CommandTag{}, fmt.Errorf("unable to connect to database")
 	}
 
-	// Create a backgrond context ( no timeout or deadline )
-	ctx := context.Background()
-
 	// Execute query
 	commandTag, err := db.ConnPool.Exec(ctx, query,
 		userToUpdate.ID,
@@ -235,45 +240,62 @@ func (db *Database) UpdateUser(userToUpdate *models.User) (pgconn.CommandTag, er
 }
 
 /*
-Function used to delete an User
+Function used to delete an User, with the job removing their content from blog-service
 @params
-query - string DELETE query
+ctx - context of the call, carrying its deadline and trace.
 user - user structure for the deleted table entry
+mode - what happens to the articles and comments of the user
+availableAt - when blog-service may start, once the tokens of the user expired
 @returns
+int64 - the ID of the user deletion job.
 error - for checking the execution of the query.
-pgconn.CommandTag - to check the query result.
 */
-func (db *Database) DeleteUser(userToDelete *models.User) (pgconn.CommandTag, error) {
+func (db *Database) DeleteUser(ctx context.Context, userToDelete *models.User, mode models.DeletionMode, availableAt time.Time) (int64, error) {
 	// Delete query
 	const query = `DELETE FROM users WHERE id = $1`
+	const insertQuery = `INSERT INTO user_deletions (UserID, Username, Mode, AvailableAt)
+	          VALUES ($1, $2, $3, $4) RETURNING ID`
 
 	// Check db connection
 	if db.ConnPool == nil {
-		return pgconn.CommandTag{}, fmt.Errorf("unable to connect to database")
+		return 0, fmt.Errorf("unable to connect to database")
 	}
 
-	// Create a backgrond context ( no timeout or deadline )
-	ctx := context.Background()
+	// The job is recorded with the deletion, a user is never deleted without it
+	var id int64
+	err := pgx.BeginFunc(ctx, db.ConnPool, func(tx pgx.Tx) error {
+		commandTag, err := tx.Exec(ctx, query, userToDelete.ID)
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+		if commandTag.RowsAffected() == 0 {
+			return fmt.Errorf("user not found")
+		}
 
-	// Execute query
-	commandTag, err := db.ConnPool.Exec(ctx, query, userToDelete.ID)
+		if err := tx.QueryRow(ctx, insertQuery, userToDelete.ID, userToDelete.Username, string(mode), availableAt).Scan(&id); err != nil {
+			return fmt.Errorf("failed to record user deletion: %w", err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return pgconn.CommandTag{}, fmt.Errorf("failed to execute query: %w", err)
+		return 0, err
 	}
 
-	return commandTag, nil
+	return id, nil
 }
 
 /*
 Function used to SELECT an user by ID
 @params
+ctx - context of the call, carrying its deadline and trace.
 query - string DELETE query
 id - user Id
 @returns
 user - struc with the user from the table
 error - for checking the execution of the query.
 */
-func (db *Database) SelectUserByID(id int) (*models.User, error) {
+func (db *Database) SelectUserByID(ctx context.Context, id int) (*models.User, error) {
 	// Select User by ID
 	const query = `SELECT id, username, password, email, role FROM users WHERE id = $1`
 
@@ -286,8 +308,8 @@ func (db *Database) SelectUserByID(id int) (*models.User, error) {
 	// Temporary role variable
 	roleStr := ""
 
-	// Create a backgrond context
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	// Bound the caller's context
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
 	defer cancel()
 
 	// Execute SELECT query
@@ -316,13 +338,14 @@ func (db *Database) SelectUserByID(id int) (*models.User, error) {
 /*
 Function used to SELECT an user by Username
 @params
+ctx - context of the call, carrying its deadline and trace.
 query - string DELETE query
 username - user username
 @returns
 user - struc with the user from the table
 error - for checking the execution of the query.
 */
-func (db *Database) SelectUserByUsername(username string) (*models.User, error) {
+func (db *Database) SelectUserByUsername(ctx context.Context, username string) (*models.User, error) {
 	// Select ID query
 	const query = `SELECT id, username, password, email, role FROM users WHERE username = $1`
 
@@ -335,8 +358,8 @@ func (db *Database) SelectUserByUsername(username string) (*models.User, error)
 	// Temporary role variable
 	roleStr := ""
 
-	// Create a backgrond context
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	// Bound the caller's context
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
 	defer cancel()
 
 	// Execute SELECT query
@@ -365,6 +388,7 @@ func (db *Database) SelectUserByUsername(username string) (*models.User, error)
 /*
 Function used to SELECT an user by Username and Password
 @params
+ctx - context of the call, carrying its deadline and trace.
 query - string DELETE query
 username - user username
 pass - user hashed password
@@ -372,7 +396,7 @@ pass - user hashed password
 user - struc with the user from the table
 error - for checking the execution of the query.
 */
-func (db *Database) SelectUserByUsernameAndPass(username, pass string) (*models.User, error) {
+func (db *Database) SelectUserByUsernameAndPass(ctx context.Context, username, pass string) (*models.User, error) {
 	// Select User Name and Password
 	const query = `SELECT id, username, password, email, role FROM users WHERE username = $1 AND password =$2`
 
@@ -385,8 +409,8 @@ func (db *Database) SelectUserByUsernameAndPass(username, pass string) (*models.
 	// Temporary role variable
 	roleStr := ""
 
-	// Create a backgrond context
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	// Bound the caller's context
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
 	defer cancel()
 
 	// Execute SELECT query
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"auth-service/internal/auth/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const userDeletionColumns = `ID, UserID, Username, Mode, Status, Attempts, LastError, Outcome, RequestedAt, CompletedAt`
+
+// scanUserDeletion reads a row selected with userDeletionColumns.
+func scanUserDeletion(row pgx.Row, deletion *models.UserDeletion) error {
+	var mode string
+	var outcome []byte
+	err := row.Scan(&deletion.ID, &deletion.UserID, &deletion.Username, &mode, &deletion.Status,
+		&deletion.Attempts, &deletion.LastError, &outcome, &deletion.RequestedAt, &deletion.CompletedAt)
+	if err != nil {
+		return err
+	}
+
+	deletion.Mode = models.DeletionMode(mode)
+	if err := json.Unmarshal(outcome, &deletion.Outcome); err != nil {
+		return fmt.Errorf("invalid outcome of user deletion %d: %w", deletion.ID, err)
+	}
+
+	return nil
+}
+
+/*
+Function used to SELECT a user deletion by ID
+@params
+ctx - context of the call, carrying its deadline and trace.
+id - the ID returned by DeleteUser
+@returns
+deletion - the job and its outcome so far
+error - for checking the execution of the query.
+*/
+func (db *Database) SelectUserDeletion(ctx context.Context, id int64) (*models.UserDeletion, error) {
+	const query = `SELECT ` + userDeletionColumns + ` FROM user_deletions WHERE ID = $1`
+
+	deletion := models.UserDeletion{}
+	err := scanUserDeletion(db.ConnPool.QueryRow(ctx, query, id), &deletion)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("user deletion not found")
+		}
+		return nil, fmt.Errorf("failed to get user deletion: %w", err)
+	}
+
+	return &deletion, nil
+}
+
+/*
+ClaimUserDeletions takes up to limit pending deletions that are available, oldest first.
+They are hidden from other claims for lease, a deletion that is not reported in time
+(its worker crashed) is claimed again and resumed.
+*/
+func (db *Database) ClaimUserDeletions(ctx context.Context, limit int, lease time.Duration) ([]models.UserDeletion, error) {
+	const query = `UPDATE user_deletions SET AvailableAt = NOW() + make_interval(secs => $2), Attempts = Attempts + 1
+	          WHERE ID IN (
+	              SELECT ID FROM user_deletions
+	              WHERE Status = 'pending' AND AvailableAt <= NOW()
+	              ORDER BY ID LIMIT $1
+	              FOR UPDATE SKIP LOCKED
+	          )
+	          RETURNING ` + userDeletionColumns
+
+	rows, err := db.ConnPool.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	deletions := []models.UserDeletion{}
+	for rows.Next() {
+		var deletion models.UserDeletion
+		if err := scanUserDeletion(rows, &deletion); err != nil {
+			return nil, fmt.Errorf("failed to retrieve row: %w", err)
+		}
+		deletions = append(deletions, deletion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to retrieve row: %w", err)
+	}
+
+	return deletions, nil
+}
+
+// CompleteUserDeletion marks a deletion done with the outcome of its steps, completing it again changes nothing.
+func (db *Database) CompleteUserDeletion(ctx context.Context, id int64, outcome map[string]int64) error {
+	const query = `UPDATE user_deletions SET Status = 'done', Outcome = $2, LastError = '', CompletedAt = NOW()
+	          WHERE ID = $1 AND Status = 'pending'`
+
+	data, err := json.Marshal(outcome)
+	if err != nil {
+		return fmt.Errorf("failed to encode outcome: %w", err)
+	}
+
+	if _, err := db.ConnPool.Exec(ctx, query, id, data); err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+// FailUserDeletion records why a deletion failed, with the outcome so far. It is claimed again once its lease ends.
+func (db *Database) FailUserDeletion(ctx context.Context, id int64, outcome map[string]int64, cause string) error {
+	const query = `UPDATE user_deletions SET Outcome = $2, LastError = $3
+	          WHERE ID = $1 AND Status = 'pending'`
+
+	data, err := json.Marshal(outcome)
+	if err != nil {
+		return fmt.Errorf("failed to encode outcome: %w", err)
+	}
+
+	if _, err := db.ConnPool.Exec(ctx, query, id, data, cause); err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package db
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrations returns the versioned schema migrations of auth-service, applied with the migrate package.
+func Migrations() fs.FS {
+	migrations, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		panic(err) // the directory is embedded, it always exists
+	}
+
+	return migrations
+}
@@ -1,11 +1,8 @@
-package db
-
-const Schema = `
-	CREATE TABLE IF NOT EXISTS users (
+-- Baseline of the schema created at startup before migrations existed, adopted as it is when present.
+CREATE TABLE IF NOT EXISTS users (
     ID SERIAL PRIMARY KEY,
     Username VARCHAR(50) UNIQUE NOT NULL,
     Password TEXT NOT NULL,
     Email VARCHAR(255) UNIQUE NOT NULL,
     Role VARCHAR(50) NOT NULL
 );
-`
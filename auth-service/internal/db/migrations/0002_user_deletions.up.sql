@@ -0,0 +1,17 @@
+-- Deletions of users whose content blog-service still has to anonymise or purge, recorded with the deletion.
+CREATE TABLE IF NOT EXISTS user_deletions (
+    ID BIGSERIAL PRIMARY KEY,
+    UserID INT NOT NULL,
+    Username VARCHAR(50) NOT NULL,
+    Mode VARCHAR(20) NOT NULL,
+    Status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    Attempts INT NOT NULL DEFAULT 0,
+    LastError TEXT NOT NULL DEFAULT '',
+    Outcome JSONB NOT NULL DEFAULT '{}',
+    RequestedAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- Not claimed before the tokens of the user expired, nor while a claim holds it
+    AvailableAt TIMESTAMPTZ NOT NULL,
+    CompletedAt TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS user_deletions_pending_idx ON user_deletions (AvailableAt) WHERE Status = 'pending';
@@ -0,0 +1,19 @@
+package db
+
+import (
+	"auth-service/internal/migrate"
+	"testing"
+)
+
+func TestMigrationsAreNumberedInOrder(t *testing.T) {
+	migrations, err := migrate.Parse(Migrations())
+	if err != nil {
+		t.Fatalf("Migrations of auth-service are invalid: %v", err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("Migration %s has version %d, want %d", migration.Name, migration.Version, i+1)
+		}
+	}
+}
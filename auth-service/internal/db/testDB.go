@@ -3,7 +3,9 @@ package db
 import (
 	"auth-service/internal/auth/models"
 	"auth-service/internal/crypto"
+	"context"
 	"fmt"
+	"time"
 )
 
 // testInsertUsers creates dummy users and inserts them into the database.
@@ -24,7 +26,7 @@ func testInsertUsers(database *Database) (models.User, models.User, models.User,
 	usersToInsert := []models.User{dummyUser1, dummyUser2, dummyUser3}
 
 	for i, user := range usersToInsert {
-		result, err := database.CreateUser(&user)
+		result, err := database.CreateUser(context.Background(), &user)
 		if err != nil {
 			return models.User{}, models.User{}, models.User{}, fmt.Errorf("cannot INSERT dummyUser%d: %w", i+1, err)
 		}
@@ -42,7 +44,7 @@ func testSelectUsers(database *Database, user models.User) error {
 
 	// Test GetUserByID
 	fmt.Println("--- Testing SELECT USER BY ID ---")
-	userShell, err := database.SelectUserByID(1)
+	userShell, err := database.SelectUserByID(context.Background(), 1)
 	if err != nil {
 		return fmt.Errorf("failed to SELECT dummyUser1 by ID: %w", err)
 	}
@@ -51,7 +53,7 @@ func testSelectUsers(database *Database, user models.User) error {
 
 	// Test GetUserByUsernameAndPass
 	fmt.Println("--- Testing SELECT USER BY Username & PASSWORD ---")
-	userShell, err = database.SelectUserByUsernameAndPass(user.Username, user.Password)
+	userShell, err = database.SelectUserByUsernameAndPass(context.Background(), user.Username, user.Password)
 	if err != nil {
 		return fmt.Errorf("failed to SELECT dummyUser2 by username and password: %w", err)
 	}
@@ -65,7 +67,7 @@ func testSelectUsers(database *Database, user models.User) error {
 func testUpdateUser(database *Database, userToUpdate models.User) error {
 	fmt.Println("======== TEST UPDATE USER =========")
 	// First, get the user to ensure we have the correct ID
-	userShell, err := database.SelectUserByUsername(userToUpdate.Username)
+	userShell, err := database.SelectUserByUsername(context.Background(), userToUpdate.Username)
 	if err != nil {
 		return fmt.Errorf("failed to fetch user for update: %w", err)
 	}
@@ -73,7 +75,7 @@ func testUpdateUser(database *Database, userToUpdate models.User) error {
 
 	// Now, update the user
 	userShell.Username = "User2_UPDATED"
-	result, err := database.UpdateUser(userShell)
+	result, err := database.UpdateUser(context.Background(), userShell)
 	if err != nil {
 		return fmt.Errorf("failed to UPDATE dummyUser2: %w", err)
 	}
@@ -87,18 +89,18 @@ func testUpdateUser(database *Database, userToUpdate models.User) error {
 func testDeleteUser(database *Database, userToDelete models.User) error {
 	fmt.Println("======== TEST DELETE USER =========")
 	// First, get the user to ensure we have the correct ID
-	userShell, err := database.SelectUserByUsername(userToDelete.Username)
+	userShell, err := database.SelectUserByUsername(context.Background(), userToDelete.Username)
 	if err != nil {
 		return fmt.Errorf("failed to fetch user for deletion: %w", err)
 	}
 	fmt.Println("SELECT dummyUser3 for deletion Result:", userShell)
 
 	// Now, delete the user
-	result, err := database.DeleteUser(userShell)
+	deletionID, err := database.DeleteUser(context.Background(), userShell, models.DeletionAnonymise, time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to DELETE dummyUser3: %w", err)
 	}
-	fmt.Println("DELETE dummyUser3 Result:", result.String())
+	fmt.Println("DELETE dummyUser3 Result: deletion", deletionID)
 	fmt.Println()
 
 	return nil
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+/*
+UnaryServerInterceptor continues the request ID sent by the caller, or starts a new one,
+and writes an access log line once the call is handled. It must run first in the chain
+so the other interceptors log with the request ID and rejected calls are logged too.
+*/
+func UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	requestID := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(RequestIDMetadata); len(ids) > 0 && ValidRequestID(ids[0]) {
+			requestID = ids[0]
+		}
+	}
+	if requestID == "" {
+		requestID = NewRequestID()
+	}
+
+	ctx = WithRequestID(ctx, requestID)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadata, requestID))
+
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	code := status.Code(err)
+
+	attrs := []slog.Attr{
+		slog.String("method", info.FullMethod),
+		slog.String("code", code.String()),
+		slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		attrs = append(attrs, slog.String("peer", p.Addr.String()))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+
+	slog.LogAttrs(ctx, callLevel(code), "grpc call", attrs...)
+
+	return resp, err
+}
+
+// callLevel logs failures of the service above the ones caused by the caller.
+func callLevel(code codes.Code) slog.Level {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable, codes.DeadlineExceeded:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
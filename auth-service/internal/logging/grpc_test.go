@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// call runs UnaryServerInterceptor for a call carrying md, with handler as the service method.
+func call(md metadata.MD, handler grpc.UnaryHandler) error {
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 41000}})
+	info := &grpc.UnaryServerInfo{FullMethod: "/auth.AuthService/Login"}
+
+	_, err := UnaryServerInterceptor(ctx, nil, info, handler)
+
+	return err
+}
+
+func TestInterceptorContinuesRequestID(t *testing.T) {
+	buf := captureLogs(t)
+
+	handlerID := ""
+	err := call(metadata.Pairs(RequestIDMetadata, "blog-id-1"), func(ctx context.Context, _ any) (any, error) {
+		handlerID = RequestID(ctx)
+		SetUserID(ctx, 7)
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Interceptor changed the error: %v", err)
+	}
+
+	if handlerID != "blog-id-1" {
+		t.Errorf("Handler request ID = %q, want the one sent by blog-service", handlerID)
+	}
+
+	record := lastRecord(t, buf)
+	want := map[string]any{
+		"msg":        "grpc call",
+		"level":      "INFO",
+		"method":     "/auth.AuthService/Login",
+		"code":       "Unauthenticated",
+		"error":      "invalid credentials",
+		"peer":       "10.0.0.2:41000",
+		"request_id": "blog-id-1",
+		"user_id":    float64(7),
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, want %v", key, record[key], value)
+		}
+	}
+}
+
+func TestInterceptorReplacesInvalidRequestID(t *testing.T) {
+	for _, md := range []metadata.MD{nil, metadata.Pairs(RequestIDMetadata, "bad id\nwith newline")} {
+		buf := captureLogs(t)
+
+		handlerID := ""
+		_ = call(md, func(ctx context.Context, _ any) (any, error) {
+			handlerID = RequestID(ctx)
+			return nil, nil
+		})
+
+		if !ValidRequestID(handlerID) {
+			t.Errorf("Metadata %v: handler request ID = %q, want a generated one", md, handlerID)
+		}
+		if got := lastRecord(t, buf)["request_id"]; got != handlerID {
+			t.Errorf("Metadata %v: logged request ID = %v, want %q", md, got, handlerID)
+		}
+	}
+}
+
+func TestCallLevel(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want slog.Level
+	}{
+		{codes.OK, slog.LevelInfo},
+		{codes.InvalidArgument, slog.LevelInfo},
+		{codes.Unauthenticated, slog.LevelInfo},
+		{codes.NotFound, slog.LevelInfo},
+		{codes.Internal, slog.LevelError},
+		{codes.Unknown, slog.LevelError},
+		{codes.Unavailable, slog.LevelError},
+		{codes.DeadlineExceeded, slog.LevelError},
+	}
+
+	for _, test := range tests {
+		if got := callLevel(test.code); got != test.want {
+			t.Errorf("callLevel(%v) = %v, want %v", test.code, got, test.want)
+		}
+	}
+}
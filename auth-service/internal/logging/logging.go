@@ -0,0 +1,134 @@
+// Kept identical in blog-service and auth-service, change both copies together.
+
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDMetadata is the gRPC metadata key carrying the request ID from blog-service to auth-service.
+const RequestIDMetadata = "x-request-id"
+
+const (
+	maxRequestIDLength = 128
+	redacted           = "[REDACTED]"
+)
+
+// Attribute keys containing one of these are never written out.
+var secretKeys = []string{"password", "passwd", "secret", "token", "authorization", "credential"}
+
+// Setup makes a JSON logger writing from level up the default, for slog and the log package alike.
+func Setup(level slog.Level) {
+	slog.SetDefault(slog.New(NewHandler(os.Stdout, level)))
+}
+
+/*
+NewHandler creates the JSON handler of the service.
+It adds the request ID, user ID and trace ID found in the context of each record,
+and redacts the attributes holding secrets.
+*/
+func NewHandler(w io.Writer, level slog.Leveler) slog.Handler {
+	return &contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	})}
+}
+
+func redact(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+
+	return a
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		record.AddAttrs(slog.String("request_id", info.id))
+		if userID := info.userID.Load(); userID != 0 {
+			record.AddAttrs(slog.Int64("user_id", userID))
+		}
+	}
+
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
+}
+
+type requestInfoKey struct{}
+
+// requestInfo is shared by everything handling a request, the user is only known once authenticated.
+type requestInfo struct {
+	id     string
+	userID atomic.Int64
+}
+
+// WithRequestID attaches the request ID to ctx, logs written with it carry the ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, &requestInfo{id: id})
+}
+
+// RequestID returns the ID attached by WithRequestID, empty when there is none.
+func RequestID(ctx context.Context) string {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		return info.id
+	}
+
+	return ""
+}
+
+// SetUserID records who made the request, for every log of it from now on including the access log.
+func SetUserID(ctx context.Context, userID int64) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.userID.Store(userID)
+	}
+}
+
+// NewRequestID generates a random request ID.
+func NewRequestID() string {
+	return rand.Text()
+}
+
+/*
+ValidRequestID reports whether an ID sent by a caller can be reused.
+IDs end up in logs and headers, so only short printable ones are accepted.
+*/
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		isAlnum := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlnum && !strings.ContainsRune("-_.:", c) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,94 @@
+// Kept identical in blog-service and auth-service, change both copies together.
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// captureLogs makes a JSON logger writing to the returned buffer the default for the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(NewHandler(&buf, slog.LevelDebug)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	return &buf
+}
+
+func lastRecord(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	record := map[string]any{}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &record); err != nil {
+		t.Fatalf("Log line is not JSON: %v", err)
+	}
+
+	return record
+}
+
+func TestSecretsAreRedacted(t *testing.T) {
+	buf := captureLogs(t)
+
+	slog.InfoContext(context.Background(), "login", "username", "alice", "password", "hunter2", "Authorization", "Bearer abc")
+
+	record := lastRecord(t, buf)
+	if record["username"] != "alice" {
+		t.Errorf("username = %v, it should not be redacted", record["username"])
+	}
+	if record["password"] != redacted || record["Authorization"] != redacted {
+		t.Errorf("Secrets were written out: %v", record)
+	}
+}
+
+func TestContextAttributes(t *testing.T) {
+	buf := captureLogs(t)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	ctx = WithRequestID(ctx, "request-1")
+	SetUserID(ctx, 7)
+
+	slog.InfoContext(ctx, "handled")
+
+	record := lastRecord(t, buf)
+	want := map[string]any{"request_id": "request-1", "user_id": float64(7), "trace_id": traceID.String()}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, want %v", key, record[key], value)
+		}
+	}
+	if RequestID(ctx) != "request-1" || RequestID(context.Background()) != "" {
+		t.Errorf("RequestID = %q, want the attached ID only", RequestID(ctx))
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	tests := map[string]bool{
+		"":                       false,
+		"request-id_1.2:3":       true,
+		"with space":             false,
+		strings.Repeat("a", 128): true,
+		strings.Repeat("a", 129): false,
+		"newline\ninjected=true": false,
+		"unicode-é":              false,
+		NewRequestID():           true,
+	}
+
+	for id, want := range tests {
+		if got := ValidRequestID(id); got != want {
+			t.Errorf("ValidRequestID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
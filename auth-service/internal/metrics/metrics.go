@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	namespace   = "auth"
+	readTimeout = 5 * time.Second
+)
+
+// Registry holds every metric of the service, the Go runtime and process ones included.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// LoginsFailed counts the login attempts that did not return a token, by reason.
+	LoginsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_failed_total",
+		Help:      "Login attempts that did not return a token, by reason.",
+	}, []string{"reason"})
+
+	grpcServerCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_server_calls_total",
+		Help:      "Calls handled, by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcServerDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_server_call_duration_seconds",
+		Help:      "Time taken to handle calls, by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		LoginsFailed,
+		grpcServerCalls,
+		grpcServerDuration,
+	)
+}
+
+/*
+NewServer creates the listener exposing /metrics on addr.
+It is kept apart from the gRPC port so the metrics are never reachable by clients.
+*/
+func NewServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry}))
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: readTimeout,
+	}
+}
+
+/*
+UnaryServerInterceptor counts the calls handled and how long they take.
+It runs before the authentication, so rejected calls are counted too.
+*/
+func UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+
+	resp, err := handler(ctx, req)
+
+	grpcServerDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+	grpcServerCalls.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+
+	return resp, err
+}
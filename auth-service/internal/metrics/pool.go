@@ -0,0 +1,65 @@
+// Kept identical in blog-service and auth-service, change both copies together.
+
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector reads the statistics of a pgx pool whenever the metrics are scraped.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired     *prometheus.Desc
+	idle         *prometheus.Desc
+	total        *prometheus.Desc
+	max          *prometheus.Desc
+	acquires     *prometheus.Desc
+	emptyWaits   *prometheus.Desc
+	waitDuration *prometheus.Desc
+}
+
+// RegisterPool exposes the connection statistics of the Postgres pool.
+func RegisterPool(pool *pgxpool.Pool) error {
+	return Registry.Register(newPoolCollector(pool))
+}
+
+func newPoolCollector(pool *pgxpool.Pool) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "pgxpool", name), help, nil, nil)
+	}
+
+	return &poolCollector{
+		pool:         pool,
+		acquired:     desc("acquired_connections", "Connections currently in use."),
+		idle:         desc("idle_connections", "Connections currently idle."),
+		total:        desc("total_connections", "Connections currently open."),
+		max:          desc("max_connections", "Maximum size of the pool."),
+		acquires:     desc("acquires_total", "Connections acquired from the pool."),
+		emptyWaits:   desc("empty_acquires_total", "Acquires that had to wait for a connection."),
+		waitDuration: desc("acquire_wait_seconds_total", "Time spent waiting for a connection."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquired
+	ch <- c.idle
+	ch <- c.total
+	ch <- c.max
+	ch <- c.acquires
+	ch <- c.emptyWaits
+	ch <- c.waitDuration
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyWaits, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
@@ -0,0 +1,37 @@
+// Kept identical in blog-service and auth-service, change both copies together.
+
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPoolCollector(t *testing.T) {
+	// The pool only connects once a connection is acquired, so nothing listens on the port
+	pool, err := pgxpool.New(context.Background(), "postgres://user@127.0.0.1:1/db?pool_max_conns=4")
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	collector := newPoolCollector(pool)
+	if got := testutil.CollectAndCount(collector); got != 7 {
+		t.Errorf("Collected %d metrics, want 7", got)
+	}
+
+	maxConnections := namespace + "_pgxpool_max_connections"
+	expected := `
+# HELP NAME Maximum size of the pool.
+# TYPE NAME gauge
+NAME 4
+`
+	err = testutil.CollectAndCompare(collector, strings.NewReader(strings.ReplaceAll(expected, "NAME", maxConnections)), maxConnections)
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,305 @@
+// Kept identical in blog-service and auth-service, change both copies together.
+
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+/*
+lockKey is the advisory lock held while migrating. It is the same for every service sharing the database,
+so replicas starting together, or the services creating schema_migrations, never race.
+*/
+const lockKey int64 = 0x736368656d61 // "schema"
+
+const schemaMigrations = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		Service VARCHAR(50) NOT NULL,
+		Version BIGINT NOT NULL,
+		Name VARCHAR(255) NOT NULL,
+		AppliedAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (Service, Version)
+	);
+`
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration changes the schema from the previous version to Version, Down reverts it.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status tells whether a migration has been applied, and when.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies the migrations of one service, recorded under its name in schema_migrations.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	service    string
+	migrations []Migration
+}
+
+/*
+New reads the migrations of service from fsys, pairs of files named
+<version>_<name>.up.sql and <version>_<name>.down.sql, applied in the order of their version.
+*/
+func New(pool *pgxpool.Pool, service string, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Parse(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{pool: pool, service: service, migrations: migrations}, nil
+}
+
+// Parse reads and checks the migration files found in fsys.
+func Parse(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %q, want <version>_<name>.(up|down).sql", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+
+	return migrations, nil
+}
+
+/*
+Up applies the pending migrations in order, each in its own transaction.
+@returns
+int - how many were applied.
+*/
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	count := 0
+	err := m.locked(ctx, func(conn *pgxpool.Conn, applied map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (Service, Version, Name) VALUES ($1, $2, $3)`,
+					m.service, migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			slog.InfoContext(ctx, "Applied migration", "service", m.service, "version", migration.Version, "name", migration.Name)
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+/*
+Down reverts the last steps applied migrations, newest first.
+@returns
+int - how many were reverted.
+*/
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	count := 0
+	err := m.locked(ctx, func(conn *pgxpool.Conn, applied map[int64]time.Time) error {
+		for _, migration := range slices.Backward(m.migrations) {
+			if count == steps {
+				break
+			}
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE Service = $1 AND Version = $2`, m.service, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			slog.InfoContext(ctx, "Reverted migration", "service", m.service, "version", migration.Version, "name", migration.Name)
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Status lists every known migration with when it was applied, nil when it is pending.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	statuses := make([]Status, 0, len(m.migrations))
+	err := m.locked(ctx, func(_ *pgxpool.Conn, applied map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			status := Status{Migration: migration}
+			if at, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &at
+			}
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+/*
+locked runs fn holding the advisory lock on a dedicated connection, with the versions applied so far.
+The lock belongs to the session, a crashed migrator releases it when its connection drops.
+*/
+func (m *Migrator) locked(ctx context.Context, fn func(conn *pgxpool.Conn, applied map[int64]time.Time) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, lockKey); err != nil {
+			slog.ErrorContext(ctx, "Failed to release migration lock", "error", err)
+		}
+	}()
+
+	if _, err := conn.Exec(ctx, schemaMigrations); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	rows, err := conn.Query(ctx, `SELECT Version, AppliedAt FROM schema_migrations WHERE Service = $1`, m.service)
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	applied := map[int64]time.Time{}
+	var version int64
+	var at time.Time
+	_, err = pgx.ForEachRow(rows, []any{&version, &at}, func() error {
+		applied[version] = at
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	return fn(conn, applied)
+}
+
+/*
+Run executes the migrate subcommand: "up", "down [steps]" (one step by default) or "status".
+@params
+w - where the outcome is printed.
+*/
+func (m *Migrator) Run(ctx context.Context, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up | down [steps] | status")
+	}
+
+	switch args[0] {
+	case "up":
+		count, err := m.Up(ctx)
+		fmt.Fprintf(w, "Applied %d migration(s)\n", count)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		count, err := m.Down(ctx, steps)
+		fmt.Fprintf(w, "Reverted %d migration(s)\n", count)
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q, must be up, down or status", args[0])
+	}
+}
@@ -0,0 +1,46 @@
+// Kept identical in blog-service and auth-service, change both copies together.
+
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseOrdersByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0010_add_index.up.sql":      {Data: []byte("CREATE INDEX i ON t (c);")},
+		"0010_add_index.down.sql":    {Data: []byte("DROP INDEX i;")},
+		"0002_create_table.up.sql":   {Data: []byte("CREATE TABLE t (c INT);")},
+		"0002_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+	}
+
+	migrations, err := Parse(fsys)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(migrations) != 2 || migrations[0].Version != 2 || migrations[1].Name != "add_index" {
+		t.Fatalf("Unexpected migrations: %+v", migrations)
+	}
+	if migrations[0].Down != "DROP TABLE t;" {
+		t.Errorf("Down = %q, want the content of the down file", migrations[0].Down)
+	}
+}
+
+func TestParseRejectsIncompleteMigrations(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"missing down": {"0001_create.up.sql": {Data: []byte("SELECT 1;")}},
+		"bad name":     {"create.sql": {Data: []byte("SELECT 1;")}},
+		"name clash": {
+			"0001_create.up.sql":  {Data: []byte("SELECT 1;")},
+			"0001_other.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+
+	for scenario, fsys := range tests {
+		if _, err := Parse(fsys); err == nil {
+			t.Errorf("%s: expected an error", scenario)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -22,6 +23,55 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// What happens to the articles and comments of a deleted user.
+type DeletionMode int32
+
+const (
+	// Kept, credited to "deleted user"
+	DeletionMode_DELETION_MODE_ANONYMISE DeletionMode = 0
+	// Deleted
+	DeletionMode_DELETION_MODE_PURGE DeletionMode = 1
+)
+
+// Enum value maps for DeletionMode.
+var (
+	DeletionMode_name = map[int32]string{
+		0: "DELETION_MODE_ANONYMISE",
+		1: "DELETION_MODE_PURGE",
+	}
+	DeletionMode_value = map[string]int32{
+		"DELETION_MODE_ANONYMISE": 0,
+		"DELETION_MODE_PURGE":     1,
+	}
+)
+
+func (x DeletionMode) Enum() *DeletionMode {
+	p := new(DeletionMode)
+	*p = x
+	return p
+}
+
+func (x DeletionMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DeletionMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_auth_proto_enumTypes[0].Descriptor()
+}
+
+func (DeletionMode) Type() protoreflect.EnumType {
+	return &file_auth_proto_enumTypes[0]
+}
+
+func (x DeletionMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DeletionMode.Descriptor instead.
+func (DeletionMode) EnumDescriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{0}
+}
+
 type LoginRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
@@ -181,6 +231,7 @@ func (x *CreateUserRequest) GetPassword() string {
 type DeleteUserRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Mode          DeletionMode           `protobuf:"varint,2,opt,name=mode,proto3,enum=auth.DeletionMode" json:"mode,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -222,6 +273,378 @@ func (x *DeleteUserRequest) GetId() int32 {
 	return 0
 }
 
+func (x *DeleteUserRequest) GetMode() DeletionMode {
+	if x != nil {
+		return x.Mode
+	}
+	return DeletionMode_DELETION_MODE_ANONYMISE
+}
+
+type DeleteUserResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The job removing the content of the user, see GetUserDeletion
+	DeletionId    int64 `protobuf:"varint,1,opt,name=deletion_id,json=deletionId,proto3" json:"deletion_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteUserResponse) Reset() {
+	*x = DeleteUserResponse{}
+	mi := &file_auth_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteUserResponse) ProtoMessage() {}
+
+func (x *DeleteUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteUserResponse.ProtoReflect.Descriptor instead.
+func (*DeleteUserResponse) Descriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DeleteUserResponse) GetDeletionId() int64 {
+	if x != nil {
+		return x.DeletionId
+	}
+	return 0
+}
+
+type GetUserDeletionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserDeletionRequest) Reset() {
+	*x = GetUserDeletionRequest{}
+	mi := &file_auth_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserDeletionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserDeletionRequest) ProtoMessage() {}
+
+func (x *GetUserDeletionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserDeletionRequest.ProtoReflect.Descriptor instead.
+func (*GetUserDeletionRequest) Descriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetUserDeletionRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type UserDeletion struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId   int32                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Username string                 `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	Mode     DeletionMode           `protobuf:"varint,4,opt,name=mode,proto3,enum=auth.DeletionMode" json:"mode,omitempty"`
+	// pending or done
+	Status      string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	Attempts    int32                  `protobuf:"varint,6,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	LastError   string                 `protobuf:"bytes,7,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	RequestedAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=requested_at,json=requestedAt,proto3" json:"requested_at,omitempty"`
+	CompletedAt *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	// How many rows or documents each step changed
+	Outcome       map[string]int64 `protobuf:"bytes,10,rep,name=outcome,proto3" json:"outcome,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserDeletion) Reset() {
+	*x = UserDeletion{}
+	mi := &file_auth_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserDeletion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserDeletion) ProtoMessage() {}
+
+func (x *UserDeletion) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserDeletion.ProtoReflect.Descriptor instead.
+func (*UserDeletion) Descriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UserDeletion) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UserDeletion) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *UserDeletion) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *UserDeletion) GetMode() DeletionMode {
+	if x != nil {
+		return x.Mode
+	}
+	return DeletionMode_DELETION_MODE_ANONYMISE
+}
+
+func (x *UserDeletion) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *UserDeletion) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *UserDeletion) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *UserDeletion) GetRequestedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RequestedAt
+	}
+	return nil
+}
+
+func (x *UserDeletion) GetCompletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return nil
+}
+
+func (x *UserDeletion) GetOutcome() map[string]int64 {
+	if x != nil {
+		return x.Outcome
+	}
+	return nil
+}
+
+type ClaimUserDeletionsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Limit int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	// How long the claimed deletions are hidden from other claims
+	LeaseSeconds  int32 `protobuf:"varint,2,opt,name=lease_seconds,json=leaseSeconds,proto3" json:"lease_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClaimUserDeletionsRequest) Reset() {
+	*x = ClaimUserDeletionsRequest{}
+	mi := &file_auth_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClaimUserDeletionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClaimUserDeletionsRequest) ProtoMessage() {}
+
+func (x *ClaimUserDeletionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClaimUserDeletionsRequest.ProtoReflect.Descriptor instead.
+func (*ClaimUserDeletionsRequest) Descriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ClaimUserDeletionsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ClaimUserDeletionsRequest) GetLeaseSeconds() int32 {
+	if x != nil {
+		return x.LeaseSeconds
+	}
+	return 0
+}
+
+type ClaimUserDeletionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Deletions     []*UserDeletion        `protobuf:"bytes,1,rep,name=deletions,proto3" json:"deletions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClaimUserDeletionsResponse) Reset() {
+	*x = ClaimUserDeletionsResponse{}
+	mi := &file_auth_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClaimUserDeletionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClaimUserDeletionsResponse) ProtoMessage() {}
+
+func (x *ClaimUserDeletionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClaimUserDeletionsResponse.ProtoReflect.Descriptor instead.
+func (*ClaimUserDeletionsResponse) Descriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ClaimUserDeletionsResponse) GetDeletions() []*UserDeletion {
+	if x != nil {
+		return x.Deletions
+	}
+	return nil
+}
+
+// Completes a deletion, or records why it failed when error is set, it is then claimed again after the lease.
+type ReportUserDeletionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Outcome       map[string]int64       `protobuf:"bytes,2,rep,name=outcome,proto3" json:"outcome,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportUserDeletionRequest) Reset() {
+	*x = ReportUserDeletionRequest{}
+	mi := &file_auth_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportUserDeletionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportUserDeletionRequest) ProtoMessage() {}
+
+func (x *ReportUserDeletionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportUserDeletionRequest.ProtoReflect.Descriptor instead.
+func (*ReportUserDeletionRequest) Descriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ReportUserDeletionRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ReportUserDeletionRequest) GetOutcome() map[string]int64 {
+	if x != nil {
+		return x.Outcome
+	}
+	return nil
+}
+
+func (x *ReportUserDeletionRequest) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
 type UpdateUserRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -235,7 +658,7 @@ type UpdateUserRequest struct {
 
 func (x *UpdateUserRequest) Reset() {
 	*x = UpdateUserRequest{}
-	mi := &file_auth_proto_msgTypes[4]
+	mi := &file_auth_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -247,7 +670,7 @@ func (x *UpdateUserRequest) String() string {
 func (*UpdateUserRequest) ProtoMessage() {}
 
 func (x *UpdateUserRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_auth_proto_msgTypes[4]
+	mi := &file_auth_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -260,7 +683,7 @@ func (x *UpdateUserRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateUserRequest.ProtoReflect.Descriptor instead.
 func (*UpdateUserRequest) Descriptor() ([]byte, []int) {
-	return file_auth_proto_rawDescGZIP(), []int{4}
+	return file_auth_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *UpdateUserRequest) GetId() int32 {
@@ -307,7 +730,7 @@ type VerifyTokenRequest struct {
 
 func (x *VerifyTokenRequest) Reset() {
 	*x = VerifyTokenRequest{}
-	mi := &file_auth_proto_msgTypes[5]
+	mi := &file_auth_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -319,7 +742,7 @@ func (x *VerifyTokenRequest) String() string {
 func (*VerifyTokenRequest) ProtoMessage() {}
 
 func (x *VerifyTokenRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_auth_proto_msgTypes[5]
+	mi := &file_auth_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -332,7 +755,7 @@ func (x *VerifyTokenRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VerifyTokenRequest.ProtoReflect.Descriptor instead.
 func (*VerifyTokenRequest) Descriptor() ([]byte, []int) {
-	return file_auth_proto_rawDescGZIP(), []int{5}
+	return file_auth_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *VerifyTokenRequest) GetToken() string {
@@ -353,7 +776,7 @@ type VerifyTokenResponse struct {
 
 func (x *VerifyTokenResponse) Reset() {
 	*x = VerifyTokenResponse{}
-	mi := &file_auth_proto_msgTypes[6]
+	mi := &file_auth_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -365,7 +788,7 @@ func (x *VerifyTokenResponse) String() string {
 func (*VerifyTokenResponse) ProtoMessage() {}
 
 func (x *VerifyTokenResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_auth_proto_msgTypes[6]
+	mi := &file_auth_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -378,7 +801,7 @@ func (x *VerifyTokenResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VerifyTokenResponse.ProtoReflect.Descriptor instead.
 func (*VerifyTokenResponse) Descriptor() ([]byte, []int) {
-	return file_auth_proto_rawDescGZIP(), []int{6}
+	return file_auth_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *VerifyTokenResponse) GetUsername() string {
@@ -407,7 +830,7 @@ var File_auth_proto protoreflect.FileDescriptor
 const file_auth_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
-	"auth.proto\x12\x04auth\x1a\x1bgoogle/protobuf/empty.proto\"F\n" +
+	"auth.proto\x12\x04auth\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"F\n" +
 	"\fLoginRequest\x12\x1a\n" +
 	"\busername\x18\x01 \x01(\tR\busername\x12\x1a\n" +
 	"\bpassword\x18\x02 \x01(\tR\bpassword\"%\n" +
@@ -416,9 +839,43 @@ const file_auth_proto_rawDesc = "" +
 	"\x11CreateUserRequest\x12\x14\n" +
 	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1a\n" +
 	"\busername\x18\x02 \x01(\tR\busername\x12\x1a\n" +
-	"\bpassword\x18\x03 \x01(\tR\bpassword\"#\n" +
+	"\bpassword\x18\x03 \x01(\tR\bpassword\"K\n" +
 	"\x11DeleteUserRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x05R\x02id\"\x85\x01\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12&\n" +
+	"\x04mode\x18\x02 \x01(\x0e2\x12.auth.DeletionModeR\x04mode\"5\n" +
+	"\x12DeleteUserResponse\x12\x1f\n" +
+	"\vdeletion_id\x18\x01 \x01(\x03R\n" +
+	"deletionId\"(\n" +
+	"\x16GetUserDeletionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\xc3\x03\n" +
+	"\fUserDeletion\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x05R\x06userId\x12\x1a\n" +
+	"\busername\x18\x03 \x01(\tR\busername\x12&\n" +
+	"\x04mode\x18\x04 \x01(\x0e2\x12.auth.DeletionModeR\x04mode\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x1a\n" +
+	"\battempts\x18\x06 \x01(\x05R\battempts\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\a \x01(\tR\tlastError\x12=\n" +
+	"\frequested_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\vrequestedAt\x12=\n" +
+	"\fcompleted_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\vcompletedAt\x129\n" +
+	"\aoutcome\x18\n" +
+	" \x03(\v2\x1f.auth.UserDeletion.OutcomeEntryR\aoutcome\x1a:\n" +
+	"\fOutcomeEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"V\n" +
+	"\x19ClaimUserDeletionsRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12#\n" +
+	"\rlease_seconds\x18\x02 \x01(\x05R\fleaseSeconds\"N\n" +
+	"\x1aClaimUserDeletionsResponse\x120\n" +
+	"\tdeletions\x18\x01 \x03(\v2\x12.auth.UserDeletionR\tdeletions\"\xc5\x01\n" +
+	"\x19ReportUserDeletionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12F\n" +
+	"\aoutcome\x18\x02 \x03(\v2,.auth.ReportUserDeletionRequest.OutcomeEntryR\aoutcome\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x1a:\n" +
+	"\fOutcomeEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"\x85\x01\n" +
 	"\x11UpdateUserRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x14\n" +
 	"\x05email\x18\x02 \x01(\tR\x05email\x12\x1a\n" +
@@ -430,16 +887,22 @@ const file_auth_proto_rawDesc = "" +
 	"\x13VerifyTokenResponse\x12\x1a\n" +
 	"\busername\x18\x01 \x01(\tR\busername\x12\x0e\n" +
 	"\x02id\x18\x02 \x01(\x05R\x02id\x12\x12\n" +
-	"\x04role\x18\x03 \x01(\tR\x04role2\xc0\x02\n" +
+	"\x04role\x18\x03 \x01(\tR\x04role*D\n" +
+	"\fDeletionMode\x12\x1b\n" +
+	"\x17DELETION_MODE_ANONYMISE\x10\x00\x12\x17\n" +
+	"\x13DELETION_MODE_PURGE\x10\x012\xaf\x04\n" +
 	"\vAuthService\x120\n" +
 	"\x05Login\x12\x12.auth.LoginRequest\x1a\x13.auth.LoginResponse\x12=\n" +
 	"\n" +
 	"UpdateUser\x12\x17.auth.UpdateUserRequest\x1a\x16.google.protobuf.Empty\x12=\n" +
 	"\n" +
-	"CreateUser\x12\x17.auth.CreateUserRequest\x1a\x16.google.protobuf.Empty\x12=\n" +
+	"CreateUser\x12\x17.auth.CreateUserRequest\x1a\x16.google.protobuf.Empty\x12?\n" +
 	"\n" +
-	"DeleteUser\x12\x17.auth.DeleteUserRequest\x1a\x16.google.protobuf.Empty\x12B\n" +
-	"\vVerifyToken\x12\x18.auth.VerifyTokenRequest\x1a\x19.auth.VerifyTokenResponseB\x19Z\x17auth-service/auth-protob\x06proto3"
+	"DeleteUser\x12\x17.auth.DeleteUserRequest\x1a\x18.auth.DeleteUserResponse\x12C\n" +
+	"\x0fGetUserDeletion\x12\x1c.auth.GetUserDeletionRequest\x1a\x12.auth.UserDeletion\x12B\n" +
+	"\vVerifyToken\x12\x18.auth.VerifyTokenRequest\x1a\x19.auth.VerifyTokenResponse\x12W\n" +
+	"\x12ClaimUserDeletions\x12\x1f.auth.ClaimUserDeletionsRequest\x1a .auth.ClaimUserDeletionsResponse\x12M\n" +
+	"\x12ReportUserDeletion\x12\x1f.auth.ReportUserDeletionRequest\x1a\x16.google.protobuf.EmptyB\x19Z\x17auth-service/auth-protob\x06proto3"
 
 var (
 	file_auth_proto_rawDescOnce sync.Once
@@ -453,33 +916,57 @@ func file_auth_proto_rawDescGZIP() []byte {
 	return file_auth_proto_rawDescData
 }
 
-var file_auth_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_auth_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_auth_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
 var file_auth_proto_goTypes = []any{
-	(*LoginRequest)(nil),        // 0: auth.LoginRequest
-	(*LoginResponse)(nil),       // 1: auth.LoginResponse
-	(*CreateUserRequest)(nil),   // 2: auth.CreateUserRequest
-	(*DeleteUserRequest)(nil),   // 3: auth.DeleteUserRequest
-	(*UpdateUserRequest)(nil),   // 4: auth.UpdateUserRequest
-	(*VerifyTokenRequest)(nil),  // 5: auth.VerifyTokenRequest
-	(*VerifyTokenResponse)(nil), // 6: auth.VerifyTokenResponse
-	(*emptypb.Empty)(nil),       // 7: google.protobuf.Empty
+	(DeletionMode)(0),                  // 0: auth.DeletionMode
+	(*LoginRequest)(nil),               // 1: auth.LoginRequest
+	(*LoginResponse)(nil),              // 2: auth.LoginResponse
+	(*CreateUserRequest)(nil),          // 3: auth.CreateUserRequest
+	(*DeleteUserRequest)(nil),          // 4: auth.DeleteUserRequest
+	(*DeleteUserResponse)(nil),         // 5: auth.DeleteUserResponse
+	(*GetUserDeletionRequest)(nil),     // 6: auth.GetUserDeletionRequest
+	(*UserDeletion)(nil),               // 7: auth.UserDeletion
+	(*ClaimUserDeletionsRequest)(nil),  // 8: auth.ClaimUserDeletionsRequest
+	(*ClaimUserDeletionsResponse)(nil), // 9: auth.ClaimUserDeletionsResponse
+	(*ReportUserDeletionRequest)(nil),  // 10: auth.ReportUserDeletionRequest
+	(*UpdateUserRequest)(nil),          // 11: auth.UpdateUserRequest
+	(*VerifyTokenRequest)(nil),         // 12: auth.VerifyTokenRequest
+	(*VerifyTokenResponse)(nil),        // 13: auth.VerifyTokenResponse
+	nil,                                // 14: auth.UserDeletion.OutcomeEntry
+	nil,                                // 15: auth.ReportUserDeletionRequest.OutcomeEntry
+	(*timestamppb.Timestamp)(nil),      // 16: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),              // 17: google.protobuf.Empty
 }
 var file_auth_proto_depIdxs = []int32{
-	0, // 0: auth.AuthService.Login:input_type -> auth.LoginRequest
-	4, // 1: auth.AuthService.UpdateUser:input_type -> auth.UpdateUserRequest
-	2, // 2: auth.AuthService.CreateUser:input_type -> auth.CreateUserRequest
-	3, // 3: auth.AuthService.DeleteUser:input_type -> auth.DeleteUserRequest
-	5, // 4: auth.AuthService.VerifyToken:input_type -> auth.VerifyTokenRequest
-	1, // 5: auth.AuthService.Login:output_type -> auth.LoginResponse
-	7, // 6: auth.AuthService.UpdateUser:output_type -> google.protobuf.Empty
-	7, // 7: auth.AuthService.CreateUser:output_type -> google.protobuf.Empty
-	7, // 8: auth.AuthService.DeleteUser:output_type -> google.protobuf.Empty
-	6, // 9: auth.AuthService.VerifyToken:output_type -> auth.VerifyTokenResponse
-	5, // [5:10] is the sub-list for method output_type
-	0, // [0:5] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0,  // 0: auth.DeleteUserRequest.mode:type_name -> auth.DeletionMode
+	0,  // 1: auth.UserDeletion.mode:type_name -> auth.DeletionMode
+	16, // 2: auth.UserDeletion.requested_at:type_name -> google.protobuf.Timestamp
+	16, // 3: auth.UserDeletion.completed_at:type_name -> google.protobuf.Timestamp
+	14, // 4: auth.UserDeletion.outcome:type_name -> auth.UserDeletion.OutcomeEntry
+	7,  // 5: auth.ClaimUserDeletionsResponse.deletions:type_name -> auth.UserDeletion
+	15, // 6: auth.ReportUserDeletionRequest.outcome:type_name -> auth.ReportUserDeletionRequest.OutcomeEntry
+	1,  // 7: auth.AuthService.Login:input_type -> auth.LoginRequest
+	11, // 8: auth.AuthService.UpdateUser:input_type -> auth.UpdateUserRequest
+	3,  // 9: auth.AuthService.CreateUser:input_type -> auth.CreateUserRequest
+	4,  // 10: auth.AuthService.DeleteUser:input_type -> auth.DeleteUserRequest
+	6,  // 11: auth.AuthService.GetUserDeletion:input_type -> auth.GetUserDeletionRequest
+	12, // 12: auth.AuthService.VerifyToken:input_type -> auth.VerifyTokenRequest
+	8,  // 13: auth.AuthService.ClaimUserDeletions:input_type -> auth.ClaimUserDeletionsRequest
+	10, // 14: auth.AuthService.ReportUserDeletion:input_type -> auth.ReportUserDeletionRequest
+	2,  // 15: auth.AuthService.Login:output_type -> auth.LoginResponse
+	17, // 16: auth.AuthService.UpdateUser:output_type -> google.protobuf.Empty
+	17, // 17: auth.AuthService.CreateUser:output_type -> google.protobuf.Empty
+	5,  // 18: auth.AuthService.DeleteUser:output_type -> auth.DeleteUserResponse
+	7,  // 19: auth.AuthService.GetUserDeletion:output_type -> auth.UserDeletion
+	13, // 20: auth.AuthService.VerifyToken:output_type -> auth.VerifyTokenResponse
+	9,  // 21: auth.AuthService.ClaimUserDeletions:output_type -> auth.ClaimUserDeletionsResponse
+	17, // 22: auth.AuthService.ReportUserDeletion:output_type -> google.protobuf.Empty
+	15, // [15:23] is the sub-list for method output_type
+	7,  // [7:15] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_auth_proto_init() }
@@ -492,13 +979,14 @@ func file_auth_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_auth_proto_rawDesc), len(file_auth_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      1,
+			NumMessages:   15,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_auth_proto_goTypes,
 		DependencyIndexes: file_auth_proto_depIdxs,
+		EnumInfos:         file_auth_proto_enumTypes,
 		MessageInfos:      file_auth_proto_msgTypes,
 	}.Build()
 	File_auth_proto = out.File
@@ -5,38 +5,79 @@ import (
 	"auth-service/internal/auth/models"
 	"auth-service/internal/crypto"
 	"auth-service/internal/db"
+	"auth-service/internal/logging"
+	"auth-service/internal/metrics"
 	pb "auth-service/internal/protobuf"
 	"context"
+	"crypto/subtle"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"regexp"
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-const PORT string = "9001"
-
-const expirationTime = 5 * time.Minute
+const (
+	healthInterval = 5 * time.Second
+	healthTimeout  = 2 * time.Second
+	// maxClaim bounds the deletions and the lease of one ClaimUserDeletions call
+	maxClaim      = 100
+	maxClaimLease = time.Hour
+)
 
 type userClaimsKey struct{}
 
+// Config holds the settings of the gRPC server.
+type Config struct {
+	Port      int
+	JWTSecret []byte
+	// TokenTTL is how long the tokens issued on login are valid
+	TokenTTL time.Duration
+	// ServiceToken authenticates blog-service on the user deletion calls, they are refused while it is empty
+	ServiceToken string
+}
+
 type Server struct {
 	pb.UnimplementedAuthServiceServer
-	db *db.Database
+	config     Config
+	db         *db.Database
+	grpcServer *grpc.Server
+	health     *health.Server
 }
 
-func NewGRPCServer(database *db.Database) *Server {
-	return &Server{
-		db: database,
+func NewGRPCServer(config Config, database *db.Database) *Server {
+	s := &Server{
+		config: config,
+		db:     database,
+		health: health.NewServer(),
 	}
+	s.grpcServer = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(logging.UnaryServerInterceptor, metrics.UnaryServerInterceptor, s.AuthInterceptor),
+		// Continues the trace sent by blog-service, the interceptors run within its span
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+	)
+
+	reflection.Register(s.grpcServer) // Register the reflection service for easier debugging
+
+	// Standard grpc.health.v1 service, NOT_SERVING until the database has been checked
+	s.setServing(false)
+	healthpb.RegisterHealthServer(s.grpcServer, s.health)
+
+	pb.RegisterAuthServiceServer(s.grpcServer, s)
+
+	return s
 }
 
 // Check valid email.
@@ -46,10 +87,18 @@ func isEmailValid(e string) bool {
 }
 
 // Midleware to intercept API calls and validate them before reaching theire handlers.
-func AuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	// Ignore Login and CreatUser requsts ( they don't have a token )
+func (s *Server) AuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	// Calls of other services carry the service token instead of a user token
+	if isServiceMethod(info.FullMethod) {
+		if err := s.checkServiceToken(ctx); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+
+	// Ignore Login and CreatUser requsts ( they don't have a token ), nor health probes
 	if strings.HasSuffix(info.FullMethod, "Login") || strings.HasSuffix(info.FullMethod, "CreateUser") ||
-		strings.HasSuffix(info.FullMethod, "VerifyToken") {
+		strings.HasSuffix(info.FullMethod, "VerifyToken") || strings.HasPrefix(info.FullMethod, "/"+healthpb.Health_ServiceDesc.ServiceName+"/") {
 		return handler(ctx, req)
 	}
 
@@ -69,41 +118,71 @@ func AuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServe
 	tokenStr := strings.TrimPrefix(tokenStrings[0], "Bearer ")
 
 	// Token Validation
-	_, claims, err := jwt.ValidateJWT(tokenStr)
+	_, claims, err := jwt.ValidateJWT(s.config.JWTSecret, tokenStr)
 	if err != nil {
 		return nil, status.Error(codes.Unauthenticated, "invalid token: "+err.Error())
 	}
 
 	// Extract JWT Claim and add it to the request context
 	newCtx := context.WithValue(ctx, userClaimsKey{}, claims)
+	logging.SetUserID(ctx, int64(claims.ID))
 
 	return handler(newCtx, req)
 }
 
+// isServiceMethod tells the methods only blog-service calls, with the service token.
+func isServiceMethod(fullMethod string) bool {
+	return fullMethod == pb.AuthService_ClaimUserDeletions_FullMethodName ||
+		fullMethod == pb.AuthService_ReportUserDeletion_FullMethodName
+}
+
+// checkServiceToken verifies the bearer token of a service call.
+func (s *Server) checkServiceToken(ctx context.Context) error {
+	if s.config.ServiceToken == "" {
+		return status.Error(codes.PermissionDenied, "service calls are disabled, SERVICE_TOKEN is not set")
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get("authorization")) == 0 {
+		return status.Error(codes.Unauthenticated, "service token is not provided")
+	}
+
+	token := strings.TrimPrefix(md.Get("authorization")[0], "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.config.ServiceToken)) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid service token")
+	}
+
+	return nil
+}
+
 // Login handler.
 func (s *Server) Login(ctx context.Context, request *pb.LoginRequest) (*pb.LoginResponse, error) {
 	//	Validate requst
 	if request.Password == "" || request.Username == "" {
+		metrics.LoginsFailed.WithLabelValues("invalid_request").Inc()
 		return nil, status.Error(codes.InvalidArgument, "Invalid request, missing some request parameter(s).")
 	}
 
 	// Extract user from db with request credentials
-	user, err := s.db.SelectUserByUsername(request.Username)
+	user, err := s.db.SelectUserByUsername(ctx, request.Username)
 	if err != nil {
+		metrics.LoginsFailed.WithLabelValues("unknown_user").Inc()
 		return nil, status.Error(codes.NotFound, "invalid username or password")
 	}
 
 	// Hash Password check
 	ok, err := crypto.VerifyPassword(request.Password, user.Password)
 	if err != nil {
+		metrics.LoginsFailed.WithLabelValues("error").Inc()
 		return nil, status.Error(codes.Internal, "invalid username or password")
 	}
 	if !ok {
+		metrics.LoginsFailed.WithLabelValues("wrong_password").Inc()
 		return nil, status.Error(codes.PermissionDenied, "invalid username or password")
 	}
 
 	// JWT Token creation
-	token, err := jwt.GenerateJWT(*user, expirationTime)
+	token, err := jwt.GenerateJWT(s.config.JWTSecret, *user, s.config.TokenTTL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
@@ -134,7 +213,7 @@ func (s *Server) VerifyToken(ctx context.Context, request *pb.VerifyTokenRequest
 		return nil, status.Error(codes.InvalidArgument, "invalid request, missing token")
 	}
 
-	_, claims, err := jwt.ValidateJWT(request.Token)
+	_, claims, err := jwt.ValidateJWT(s.config.JWTSecret, request.Token)
 	if err != nil {
 		return nil, status.Error(codes.PermissionDenied, "invalid token supplied")
 	}
@@ -175,12 +254,12 @@ func (s *Server) CreateUser(ctx context.Context, request *pb.CreateUserRequest)
 	}
 
 	// Query db for a new user entry
-	_, err = s.db.CreateUser(user)
+	_, err = s.db.CreateUser(ctx, user)
 	if err != nil {
-		fmt.Printf("Failed creating user with err: %s", err)
+		slog.WarnContext(ctx, "Failed creating user", "username", user.Username, "error", err)
 		return nil, status.Error(codes.AlreadyExists, "email or username already taken "+err.Error())
 	}
-	fmt.Printf("Created user %s", user.Username)
+	slog.InfoContext(ctx, "Created user", "username", user.Username)
 
 	return nil, nil
 }
@@ -205,7 +284,7 @@ func (s *Server) UpdateUser(ctx context.Context, request *pb.UpdateUserRequest)
 	}
 
 	// Query db for user entry to be update
-	user, err := s.db.SelectUserByID(int(request.Id))
+	user, err := s.db.SelectUserByID(ctx, int(request.Id))
 	if err != nil {
 		if err.Error() == "user not found" {
 			return nil, status.Error(codes.NotFound, "invalid id supplied")
@@ -245,7 +324,7 @@ func (s *Server) UpdateUser(ctx context.Context, request *pb.UpdateUserRequest)
 	}
 
 	// Query db for user entry to update
-	_, err = s.db.UpdateUser(user)
+	_, err = s.db.UpdateUser(ctx, user)
 	if err != nil {
 		if strings.Contains(err.Error(), "23505") {
 			return nil, status.Error(codes.AlreadyExists, "username or email already taken")
@@ -256,8 +335,13 @@ func (s *Server) UpdateUser(ctx context.Context, request *pb.UpdateUserRequest)
 	return nil, nil
 }
 
-// DeleteUser handler.
-func (s *Server) DeleteUser(ctx context.Context, request *pb.DeleteUserRequest) (*emptypb.Empty, error) {
+/*
+DeleteUser handler.
+The user is deleted with a job, returned to the caller, in which blog-service anonymises
+or purges their content. It starts once the tokens the user still holds expired,
+so nothing they write meanwhile is left behind.
+*/
+func (s *Server) DeleteUser(ctx context.Context, request *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
 	// check for corect request
 	if request == nil {
 		return nil, status.Errorf(codes.InvalidArgument, "cannot process the request")
@@ -272,44 +356,218 @@ func (s *Server) DeleteUser(ctx context.Context, request *pb.DeleteUserRequest)
 		return nil, status.Errorf(codes.InvalidArgument, "a valid User ID must be provided")
 	}
 
+	mode, ok := deletionModeFromProto(request.Mode)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown deletion mode %d", request.Mode)
+	}
+
 	// convert from int32 to int
 	intID := int(request.Id)
 
 	// Select user to delte
-	userToDelete, err := s.db.SelectUserByID(intID)
+	userToDelete, err := s.db.SelectUserByID(ctx, intID)
 	if err != nil {
 		// log DB level error
-		log.Printf("ERROR: could not retrieve user %d from database: %v", intID, err)
+		slog.ErrorContext(ctx, "Could not retrieve user from database", "user", intID, "error", err)
 		return nil, status.Errorf(codes.NotFound, "user with id %d not found", intID)
 	}
 
-	_, err = s.db.DeleteUser(userToDelete)
+	deletionID, err := s.db.DeleteUser(ctx, userToDelete, mode, time.Now().Add(s.config.TokenTTL))
 	if err != nil {
 		// log DB level error
-		log.Printf("ERROR: could not retrieve user %d from database: %v", intID, err)
+		slog.ErrorContext(ctx, "Could not delete user from database", "user", intID, "error", err)
 		return nil, status.Errorf(codes.NotFound, "user with id %d not found", intID)
 	}
+	slog.InfoContext(ctx, "Deleted user", "user", intID, "mode", mode, "deletion", deletionID)
 
-	return nil, nil
+	return &pb.DeleteUserResponse{DeletionId: deletionID}, nil
 }
 
-func (s *Server) ListenAndServe(ctx context.Context) {
-	lc := net.ListenConfig{}
-	lis, err := lc.Listen(ctx, "tcp", fmt.Sprintf(":%s", PORT))
+// GetUserDeletion handler, admins follow the removal of the content of a deleted user.
+func (s *Server) GetUserDeletion(ctx context.Context, request *pb.GetUserDeletionRequest) (*pb.UserDeletion, error) {
+	if err := checkRole(ctx, models.ADMIN); err != nil {
+		return nil, err
+	}
+
+	if request.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "a valid deletion ID must be provided")
+	}
+
+	deletion, err := s.db.SelectUserDeletion(ctx, request.Id)
 	if err != nil {
-		log.Fatalf("failed to listen: %s", err)
+		if err.Error() == "user deletion not found" {
+			return nil, status.Errorf(codes.NotFound, "user deletion %d not found", request.Id)
+		}
+		slog.ErrorContext(ctx, "Could not retrieve user deletion", "deletion", request.Id, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
 	}
-	fmt.Printf("Listening on port %s...\n", PORT)
 
-	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(AuthInterceptor),
-	)
+	return userDeletionToProto(deletion), nil
+}
+
+// ClaimUserDeletions handler, blog-service takes the deletions it has to carry out.
+func (s *Server) ClaimUserDeletions(ctx context.Context, request *pb.ClaimUserDeletionsRequest) (*pb.ClaimUserDeletionsResponse, error) {
+	lease := time.Duration(request.GetLeaseSeconds()) * time.Second
+	if request.GetLimit() <= 0 || request.GetLimit() > maxClaim || lease <= 0 || lease > maxClaimLease {
+		return nil, status.Error(codes.InvalidArgument, "limit and lease out of range")
+	}
+
+	deletions, err := s.db.ClaimUserDeletions(ctx, int(request.Limit), lease)
+	if err != nil {
+		slog.ErrorContext(ctx, "Could not claim user deletions", "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	response := &pb.ClaimUserDeletionsResponse{}
+	for i := range deletions {
+		response.Deletions = append(response.Deletions, userDeletionToProto(&deletions[i]))
+	}
+
+	return response, nil
+}
+
+// ReportUserDeletion handler, completes a deletion or records why it failed.
+func (s *Server) ReportUserDeletion(ctx context.Context, request *pb.ReportUserDeletionRequest) (*emptypb.Empty, error) {
+	if request.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "a valid deletion ID must be provided")
+	}
+
+	var err error
+	if request.Error == "" {
+		err = s.db.CompleteUserDeletion(ctx, request.Id, request.Outcome)
+	} else {
+		slog.WarnContext(ctx, "User deletion failed", "deletion", request.Id, "error", request.Error)
+		err = s.db.FailUserDeletion(ctx, request.Id, request.Outcome, request.Error)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Could not record user deletion report", "deletion", request.Id, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// deletionModeFromProto maps the mode of a request, anonymising is the default.
+func deletionModeFromProto(mode pb.DeletionMode) (models.DeletionMode, bool) {
+	switch mode {
+	case pb.DeletionMode_DELETION_MODE_ANONYMISE:
+		return models.DeletionAnonymise, true
+	case pb.DeletionMode_DELETION_MODE_PURGE:
+		return models.DeletionPurge, true
+	default:
+		return "", false
+	}
+}
+
+func userDeletionToProto(deletion *models.UserDeletion) *pb.UserDeletion {
+	mode := pb.DeletionMode_DELETION_MODE_ANONYMISE
+	if deletion.Mode == models.DeletionPurge {
+		mode = pb.DeletionMode_DELETION_MODE_PURGE
+	}
+
+	out := &pb.UserDeletion{
+		Id:          deletion.ID,
+		UserId:      deletion.UserID,
+		Username:    deletion.Username,
+		Mode:        mode,
+		Status:      deletion.Status,
+		Attempts:    deletion.Attempts,
+		LastError:   deletion.LastError,
+		RequestedAt: timestamppb.New(deletion.RequestedAt),
+		Outcome:     deletion.Outcome,
+	}
+	if deletion.CompletedAt != nil {
+		out.CompletedAt = timestamppb.New(*deletion.CompletedAt)
+	}
+
+	return out
+}
+
+/*
+WatchHealth checks the database periodically until ctx is cancelled,
+the health service reports NOT_SERVING while it cannot be used.
+*/
+func (s *Server) WatchHealth(ctx context.Context) {
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+
+	serving := false
+	for {
+		pingCtx, cancel := context.WithTimeout(ctx, healthTimeout)
+		err := s.db.Ping(pingCtx)
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+		if (err == nil) != serving {
+			serving = err == nil
+			if serving {
+				slog.Info("Database is healthy, serving")
+			} else {
+				slog.Error("Database is unhealthy, not serving", "error", err)
+			}
+			s.setServing(serving)
+		}
 
-	reflection.Register(grpcServer) // Register the reflection service for easier debugging
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// setServing updates the status of the server as a whole and of the auth service.
+func (s *Server) setServing(serving bool) {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
 
-	pb.RegisterAuthServiceServer(grpcServer, s)
+	s.health.SetServingStatus("", status)
+	s.health.SetServingStatus(pb.AuthService_ServiceDesc.ServiceName, status)
+}
+
+/*
+ListenAndServe serves gRPC on the configured port until Shutdown is called.
+@returns
+error - nil after a shutdown, otherwise why serving failed.
+*/
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	lc := net.ListenConfig{}
+	lis, err := lc.Listen(ctx, "tcp", fmt.Sprintf(":%d", s.config.Port))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	slog.Info(fmt.Sprintf("Listening on port %d...", s.config.Port))
+
+	if err := s.grpcServer.Serve(lis); err != nil {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+
+	return nil
+}
 
-	if err := grpcServer.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %s", err)
+/*
+Shutdown stops accepting calls and waits for in-flight ones until ctx expires,
+the remaining calls are then cancelled.
+*/
+func (s *Server) Shutdown(ctx context.Context) error {
+	// Probes see NOT_SERVING from now on, and the status cannot change anymore
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return fmt.Errorf("in-flight calls did not finish in time: %w", ctx.Err())
 	}
 }
@@ -0,0 +1,78 @@
+// Kept identical in blog-service and auth-service, change both copies together.
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans makes a provider recording in memory the global one for the test.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+
+	return exporter
+}
+
+func TestPgxTracer(t *testing.T) {
+	exporter := recordSpans(t)
+
+	tests := []struct {
+		name   string
+		err    error
+		status codes.Code
+	}{
+		{"found", nil, codes.Unset},
+		{"no rows", pgx.ErrNoRows, codes.Unset},
+		{"failed", errors.New("connection reset"), codes.Error},
+	}
+
+	for _, test := range tests {
+		exporter.Reset()
+
+		ctx := PgxTracer{}.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "select 1"})
+		PgxTracer{}.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1"), Err: test.err})
+
+		spans := exporter.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("%s: recorded %d spans, want 1", test.name, len(spans))
+		}
+		if spans[0].Name != "postgres SELECT" {
+			t.Errorf("%s: span name = %q, want postgres SELECT", test.name, spans[0].Name)
+		}
+		if spans[0].Status.Code != test.status {
+			t.Errorf("%s: span status = %v, want %v", test.name, spans[0].Status.Code, test.status)
+		}
+	}
+}
+
+func TestQueryOperation(t *testing.T) {
+	tests := map[string]string{
+		"SELECT id FROM comments":             "SELECT",
+		"\n\t  insert into likes VALUES ($1)": "INSERT",
+		"":                                    "QUERY",
+	}
+
+	for sql, want := range tests {
+		if got := queryOperation(sql); got != want {
+			t.Errorf("queryOperation(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+package tracing
+
+// ServiceName names the spans of this service.
+const ServiceName = "auth-service"
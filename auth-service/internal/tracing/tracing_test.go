@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestServerContinuesTraceOfBlogService(t *testing.T) {
+	exporter := recordSpans(t)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	lis := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	// A plain client sending the traceparent metadata, as the instrumented blog-service client does
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create gRPC client: %v", err)
+	}
+	defer conn.Close()
+
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "traceparent", "00-"+traceID+"-"+parentID+"-01")
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("gRPC call failed: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Recorded %d spans, want the gRPC server one", len(spans))
+	}
+	if got := spans[0].SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("Span has trace %s, want blog-service's %s", got, traceID)
+	}
+	if got := spans[0].Parent.SpanID().String(); got != parentID || !spans[0].Parent.IsRemote() {
+		t.Errorf("Span parent = %s, want the remote %s", got, parentID)
+	}
+	if got := spans[0].Resource.String(); got != "service.name="+ServiceName {
+		t.Errorf("Span resource = %s, want auth-service", got)
+	}
+}
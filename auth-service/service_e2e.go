@@ -59,6 +59,11 @@ const (
 	userID       int32 = 2
 )
 
+// jwtSecret returns the secret the service under test signs its tokens with.
+func jwtSecret() []byte {
+	return []byte(os.Getenv("JWT_SECRET"))
+}
+
 func initDummyUser(email, username, password string) dummyUser {
 	return dummyUser{
 		email:    email,
@@ -110,7 +115,7 @@ func createDummyUser(user *dummyUser) error {
 		log.Fatalf("Failed to fetch dummy user ID: %s\n", err)
 	}
 
-	_, userClaims, err := jwt.ValidateJWT(loginResp.Token)
+	_, userClaims, err := jwt.ValidateJWT(jwtSecret(), loginResp.Token)
 	if err != nil {
 		log.Fatalf("Failed to fetch dummy user ID: %s\n", err)
 	}
@@ -420,7 +425,7 @@ func TestLogin(t *testing.T) {
 					t.Errorf("Err -> \nWant: %q\nGot: %q\n", tt.expected.err, err)
 				}
 			} else {
-				if _, _, tokenErr := jwt.ValidateJWT(out.Token); tokenErr != nil {
+				if _, _, tokenErr := jwt.ValidateJWT(jwtSecret(), out.Token); tokenErr != nil {
 					t.Errorf("Out -> Received invalid token : '%s'\n%s", out.Token, tokenErr)
 				}
 			}
@@ -604,7 +609,6 @@ func TestDeleteUser(t *testing.T) {
 
 	// Test correct user create request
 	type expectation struct {
-		out    *emptypb.Empty
 		status codes.Code
 	}
 
@@ -616,7 +620,6 @@ func TestDeleteUser(t *testing.T) {
 		"Unauthorized": {
 			in: &pb.DeleteUserRequest{},
 			expected: expectation{
-				out:    &emptypb.Empty{},
 				status: codes.PermissionDenied,
 			},
 			ctx: loggedInContext(USER),
@@ -625,7 +628,6 @@ func TestDeleteUser(t *testing.T) {
 		"Malformed_Token": {
 			in: &pb.DeleteUserRequest{},
 			expected: expectation{
-				out:    &emptypb.Empty{},
 				status: codes.Unauthenticated,
 			},
 			ctx: malformedTokenContext(),
@@ -634,7 +636,6 @@ func TestDeleteUser(t *testing.T) {
 		"Missing_Argument": {
 			in: &pb.DeleteUserRequest{},
 			expected: expectation{
-				out:    &emptypb.Empty{},
 				status: codes.InvalidArgument,
 			},
 			ctx: loggedInContext(ADMIN),
@@ -643,7 +644,6 @@ func TestDeleteUser(t *testing.T) {
 		"Missing_Auth_token": {
 			in: &pb.DeleteUserRequest{},
 			expected: expectation{
-				out:    &emptypb.Empty{},
 				status: codes.Unauthenticated,
 			},
 			ctx: context.Background(),
@@ -654,7 +654,6 @@ func TestDeleteUser(t *testing.T) {
 				Id: 999999999, //nolint:mnd // Not magic
 			},
 			expected: expectation{
-				out:    &emptypb.Empty{},
 				status: codes.NotFound,
 			},
 			ctx: loggedInContext(ADMIN),
@@ -665,7 +664,6 @@ func TestDeleteUser(t *testing.T) {
 				Id: deleteDummy.id,
 			},
 			expected: expectation{
-				out:    &emptypb.Empty{},
 				status: codes.OK,
 			},
 			ctx: loggedInContext(ADMIN),
@@ -685,8 +683,8 @@ func TestDeleteUser(t *testing.T) {
 				if tt.expected.status != outCode {
 					t.Errorf("Err -> \nWant: %q\nGot: %q\nErr msg: %s\n", tt.expected.status, outCode, err)
 				}
-			} else if tt.expected.out.String() != out.String() {
-				t.Errorf("Out -> \nWant: %q\nGot : %q", tt.expected.out, out)
+			} else if out.GetDeletionId() <= 0 {
+				t.Errorf("Out -> \nWant: a deletion job\nGot : %q", out)
 			}
 		})
 	}
@@ -1,51 +1,356 @@
 package main
 
 import (
+	"blog-service/internal/blob"
+	"blog-service/internal/config"
+	"blog-service/internal/contentfilter"
 	"blog-service/internal/db/mongo"
 	pg "blog-service/internal/db/postgres"
+	"blog-service/internal/events"
 	"blog-service/internal/grpc"
+	"blog-service/internal/health"
+	"blog-service/internal/lifecycle"
+	"blog-service/internal/live"
+	"blog-service/internal/logging"
+	"blog-service/internal/metrics"
+	"blog-service/internal/migrate"
+	"blog-service/internal/notify"
+	"blog-service/internal/outbox"
+	"blog-service/internal/ratelimit"
+	"blog-service/internal/scheduler"
 	"blog-service/internal/server"
+	"blog-service/internal/server/models"
+	"blog-service/internal/tracing"
+	"blog-service/internal/userdeletion"
 	"context"
+	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+const probeTimeout = 3 * time.Second
+
+// Exit codes, so the orchestrator can tell a bad start from a messy stop.
+// When several things fail, the higher code wins.
+const (
+	exitOK       = 0
+	exitStartup  = 1
+	exitShutdown = 2
+	exitServe    = 3
+)
+
+// pinger is implemented by the backends reached over the network, the local ones have nothing to check.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
 func main() {
-	fmt.Println("Starting blog-service")
+	healthcheck := flag.Bool("healthcheck", false, "probe the readiness of a running instance and exit")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration, secrets redacted, and exit")
+	flag.Parse()
 
-	// Create new mongodb client
-	mongoClient, err := mongo.NewMongoClient()
+	// An invalid configuration stops the service before it touches anything
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(exitStartup)
+	}
+
+	switch {
+	case *printConfig:
+		cfg.Print(os.Stdout)
+	case flag.Arg(0) == "migrate":
+		os.Exit(runMigrate(cfg, flag.Args()[1:]))
+	case *healthcheck:
+		os.Exit(probe(cfg))
+	default:
+		os.Exit(run(cfg))
+	}
+}
+
+func run(cfg *config.Config) int {
+	logging.Setup(cfg.LogLevel)
+	slog.Info("Starting blog-service", "config", cfg)
+
+	// Resources are closed newest first once the server and the workers are gone
+	closers := &lifecycle.Closers{}
+	workers := lifecycle.NewWorkers()
+
+	// Closes whatever was opened when startup fails half way
+	abort := func() int {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+
+		if err := workers.Stop(ctx); err != nil {
+			slog.Error("Failed to stop workers", "error", err)
+		}
+		if err := closers.Close(ctx); err != nil {
+			slog.Error("Failed to close resources", "error", err)
+		}
+
+		return exitStartup
+	}
+
+	// Export traces, the provider is closed last so spans of the shutdown are flushed too
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracesExporter)
 	if err != nil {
-		log.Fatalf("Failed to connect to MongoDB: %v", err)
+		slog.Error("Failed to set up tracing", "error", err)
+		return exitStartup
 	}
-	defer func() {
-		if err = mongoClient.Close(context.Background()); err != nil {
-			log.Printf("Error closing MongoDB connection: %v", err)
+	closers.Add("tracer provider", shutdownTracing)
+
+	// Expose the metrics on their own listener, closed last to cover the whole shutdown
+	metricsServer := metrics.NewServer(cfg.MetricsAddr)
+	go func() {
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("Metrics listener failed", "error", err)
 		}
 	}()
+	closers.Add("metrics listener", metricsServer.Shutdown)
+
+	// Create new mongodb client
+	mongoClient, err := mongo.NewMongoClient(cfg.Mongo)
+	if err != nil {
+		slog.Error("Failed to connect to MongoDB", "error", err)
+		return abort()
+	}
+	closers.Add("MongoDB", mongoClient.Close)
 
 	// Create new postgres client
-	postgresClient, err := pg.NewPostgresClient()
+	postgresClient, err := pg.NewPostgresClient(cfg.Postgres)
 	if err != nil {
-		log.Printf("Failed to create postgres client: %s", err)
-		return
+		slog.Error("Failed to create postgres client", "error", err)
+		return abort()
+	}
+	closers.Add("PostgreSQL pool", func(context.Context) error {
+		postgresClient.ConnPool.Close()
+		return nil
+	})
+	if err := metrics.RegisterPool(postgresClient.ConnPool); err != nil {
+		slog.Error("Failed to register PostgreSQL pool metrics", "error", err)
+		return abort()
+	}
+
+	// Bring the schema up to date, replicas starting together wait for each other
+	if cfg.MigrateOnStart {
+		migrator, err := migrate.New(postgresClient.ConnPool, tracing.ServiceName, pg.Migrations())
+		if err != nil {
+			slog.Error("Failed to read migrations", "error", err)
+			return abort()
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			slog.Error("Failed to migrate PostgreSQL", "error", err)
+			return abort()
+		}
+		if _, err := mongoClient.Migrate(context.Background()); err != nil {
+			slog.Error("Failed to migrate MongoDB", "error", err)
+			return abort()
+		}
 	}
-	defer postgresClient.ConnPool.Close()
 
 	// Create new gRPC client
-	grpcClient, err := grpc.NewGRPCClient()
+	grpcClient, err := grpc.NewGRPCClient(cfg.AuthURI)
+	if err != nil {
+		slog.Error("Failed to create gRPC client", "error", err)
+		return abort()
+	}
+	closers.Add("auth-service connection", func(context.Context) error {
+		return grpcClient.Close()
+	})
+
+	// Create the store for uploaded files
+	blobStore, err := blob.NewBlobStore(context.Background(), cfg.Blob)
+	if err != nil {
+		slog.Error("Failed to create blob store", "error", err)
+		return abort()
+	}
+
+	// Start publishing scheduled articles in the background
+	workers.Go("scheduler", scheduler.NewScheduler(mongoClient).Run)
+
+	// Create the pub/sub carrying comment changes and notifications to streaming clients
+	bus, err := live.NewBus(cfg.PubSubBackend, postgresClient.ConnPool)
+	if err != nil {
+		slog.Error("Failed to create comment event bus", "error", err)
+		return abort()
+	}
+	workers.Go("comment events", bus.Run)
+
+	// Deliver notifications in the background, away from the request path
+	notifier := notify.NewDispatcher(postgresClient, bus)
+	workers.Go("notifications", notifier.Run)
+
+	// Create the publisher sending domain events to other services
+	publisher, err := events.NewPublisher(context.Background(), cfg.Events)
+	if err != nil {
+		slog.Error("Failed to create event publisher", "error", err)
+		return abort()
+	}
+	closers.Add("event publisher", func(context.Context) error {
+		return publisher.Close()
+	})
+
+	// Relay the events recorded with the writes to the counters, cascades, notifications and publisher
+	relay := outbox.NewRelay(postgresClient)
+	models.RegisterEventHandlers(relay, postgresClient, mongoClient, notifier, publisher)
+	workers.Go("outbox relay", relay.Run)
+
+	// Remove the content of the users deleted in auth-service
+	if cfg.AuthServiceToken != "" {
+		worker := userdeletion.NewWorker(grpcClient.Client, cfg.AuthServiceToken, postgresClient)
+		models.RegisterUserDeletionSteps(worker, postgresClient, mongoClient, blobStore)
+		workers.Go("user deletions", worker.Run)
+	} else {
+		slog.Warn("AUTH_SERVICE_TOKEN is not set, the content of deleted users is kept")
+	}
+
+	// Create the filters comments pass before they are stored
+	commentFilter, bayes, err := contentfilter.New(postgresClient, cfg.CommentFilter)
+	if err != nil {
+		slog.Error("Failed to create comment filter", "error", err)
+		return abort()
+	}
+	workers.Go("spam classifier", bayes.Run)
+
+	// Create the store holding the rate limit buckets
+	rateLimitStore, err := ratelimit.NewStore(context.Background(), cfg.RateLimit)
 	if err != nil {
-		log.Printf("Failed to create gRPC client: %s", err)
-		return
+		slog.Error("Failed to create rate limit store", "error", err)
+		return abort()
+	}
+	if closer, ok := rateLimitStore.(io.Closer); ok {
+		closers.Add("rate limit store", func(context.Context) error {
+			return closer.Close()
+		})
+	}
+
+	// Check every dependency for the health and readiness probes
+	checks := []health.Check{
+		{Name: "mongodb", Run: mongoClient.Ping},
+		{Name: "postgres", Run: postgresClient.Ping},
+		{Name: "auth-service", Run: grpcClient.Ping},
+	}
+	if pinger, ok := blobStore.(pinger); ok {
+		checks = append(checks, health.Check{Name: "blob store", Run: pinger.Ping})
+	}
+	if pinger, ok := rateLimitStore.(pinger); ok {
+		checks = append(checks, health.Check{Name: "rate limit store", Run: pinger.Ping})
 	}
 
 	// Create an instance of server
-	srv := server.NewServer(mongoClient, postgresClient, grpcClient)
+	srv := server.NewServer(cfg.Server, mongoClient, postgresClient, grpcClient.Client, blobStore, notifier, bus, commentFilter, rateLimitStore, health.NewChecker(checks...))
+
+	// Start the server on the configured port
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Start(cfg.Addr())
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	code := exitOK
+	select {
+	case sig := <-signals:
+		slog.Info("Shutting down", "signal", sig.String())
+	case err := <-serveErr:
+		slog.Error("Server failed", "error", err)
+		code = exitServe
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// Drain in-flight requests first, they may still rely on the workers and the pools
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("Failed to drain HTTP requests", "error", err)
+		code = max(code, exitShutdown)
+	}
+
+	if err := workers.Stop(ctx); err != nil {
+		slog.Error("Failed to stop workers", "error", err)
+		code = max(code, exitShutdown)
+	}
+
+	if err := closers.Close(ctx); err != nil {
+		slog.Error("Failed to close resources", "error", err)
+		code = max(code, exitShutdown)
+	}
+
+	slog.Info("blog-service stopped")
+
+	return code
+}
+
+// runMigrate runs the migrate subcommand against the configured database.
+func runMigrate(cfg *config.Config, args []string) int {
+	logging.Setup(cfg.LogLevel)
+
+	postgresClient, err := pg.NewPostgresClient(cfg.Postgres)
+	if err != nil {
+		slog.Error("Failed to create postgres client", "error", err)
+		return exitStartup
+	}
+	defer postgresClient.ConnPool.Close()
+
+	migrator, err := migrate.New(postgresClient.ConnPool, tracing.ServiceName, pg.Migrations())
+	if err != nil {
+		slog.Error("Failed to read migrations", "error", err)
+		return exitStartup
+	}
+
+	if err := migrator.Run(context.Background(), args, os.Stdout); err != nil {
+		slog.Error("Migration failed", "error", err)
+		return exitStartup
+	}
+
+	// Document migrations only go forward, indexes and validators are declared in the code
+	if len(args) > 0 && args[0] == "up" {
+		mongoClient, err := mongo.NewMongoClient(cfg.Mongo)
+		if err != nil {
+			slog.Error("Failed to connect to MongoDB", "error", err)
+			return exitStartup
+		}
+		defer mongoClient.Close(context.Background())
+
+		count, err := mongoClient.Migrate(context.Background())
+		fmt.Printf("Applied %d document migration(s)\n", count)
+		if err != nil {
+			slog.Error("Document migration failed", "error", err)
+			return exitStartup
+		}
+	}
+
+	return exitOK
+}
+
+/*
+probe asks the instance listening locally whether it is ready, as the container health check.
+The image has no shell or curl, so the binary probes itself.
+*/
+func probe(cfg *config.Config) int {
+	client := &http.Client{Timeout: probeTimeout}
+
+	resp, err := client.Get("http://localhost" + cfg.Addr() + "/readyz")
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	fmt.Print(string(body))
 
-	// Start the server on port 8080
-	if err := srv.Start(":8080"); err != nil {
-		log.Printf("Server failed to start: %v", err)
+	if resp.StatusCode != http.StatusOK {
+		return 1
 	}
 
-	select {}
+	return 0
 }
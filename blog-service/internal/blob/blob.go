@@ -0,0 +1,53 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	BackendFS = "fs"
+	BackendS3 = "s3"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Info describes a stored blob.
+type Info struct {
+	ContentType string
+	Size        int64
+}
+
+/*
+BlobStore stores uploaded files under opaque keys.
+Keys are generated by the service and may contain slashes to group related blobs.
+*/
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, *Info, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Config selects the blob store, S3 is only read by the s3 backend.
+type Config struct {
+	Backend string
+	FSRoot  string
+	S3      S3Config
+}
+
+/*
+NewBlobStore creates the blob store selected by cfg.Backend.
+"fs" stores blobs below cfg.FSRoot, "s3" uses an S3 compatible server such as MinIO.
+*/
+func NewBlobStore(ctx context.Context, cfg Config) (BlobStore, error) {
+	switch cfg.Backend {
+	case BackendFS:
+		return NewFileStore(cfg.FSRoot)
+	case BackendS3:
+		return NewS3Store(ctx, cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown blob backend %q, must be fs or s3", cfg.Backend)
+	}
+}
@@ -0,0 +1,88 @@
+package blob
+
+import (
+	"blog-service/internal/db/testutil"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// testStore runs the same round trip against every BlobStore implementation.
+func testStore(t *testing.T, store BlobStore) {
+	ctx := context.Background()
+	content := "blob content"
+	key := "articles/" + testutil.GenerateRandomString() + ".txt"
+
+	err := store.Put(ctx, key, strings.NewReader(content), int64(len(content)), "text/plain")
+	if err != nil {
+		t.Fatalf("Failed to put blob: %s", err)
+	}
+
+	r, info, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Failed to get blob: %s", err)
+	}
+
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("Failed to read blob: %s", err)
+	}
+
+	if string(got) != content || info.ContentType != "text/plain" || info.Size != int64(len(content)) {
+		t.Errorf("Blob was stored, but is corrupted\nGot: %q %+v", got, info)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Failed to delete blob: %s", err)
+	}
+
+	if _, _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got: %v", err)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create file store: %s", err)
+	}
+
+	testStore(t, store)
+
+	path, err := store.path("../../escape")
+	if err != nil || !strings.HasPrefix(path, store.root) {
+		t.Errorf("Key with .. was not kept inside the root: %s", path)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	ctx := context.Background()
+
+	container, cfg, err := testutil.CreateMinioContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to setup minio container: %s", err)
+	}
+	defer func() {
+		if err := testcontainers.TerminateContainer(container); err != nil {
+			t.Logf("failed to terminate container: %s", err)
+		}
+	}()
+
+	store, err := NewS3Store(ctx, S3Config{
+		Endpoint:  cfg.Endpoint,
+		AccessKey: cfg.AccessKey,
+		SecretKey: cfg.SecretKey,
+		Bucket:    "blog-test",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create S3 store: %s", err)
+	}
+
+	testStore(t, store)
+}
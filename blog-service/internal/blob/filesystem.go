@@ -0,0 +1,129 @@
+package blob
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	metaSuffix = ".meta"
+	dirPerm    = 0o750
+	filePerm   = 0o640
+)
+
+// FileStore keeps blobs as files below a root directory, with the content type in a sidecar file.
+type FileStore struct {
+	root string
+}
+
+func NewFileStore(root string) (*FileStore, error) {
+	if err := os.MkdirAll(root, dirPerm); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	return &FileStore{root: root}, nil
+}
+
+// path resolves a key below the root, rejecting keys that would escape it.
+func (s *FileStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if key == "" || strings.HasSuffix(clean, metaSuffix) || clean == "/" {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
+
+func (s *FileStore) Put(_ context.Context, key string, r io.Reader, _ int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	// Write to a temporary file first so readers never see a partial blob
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+
+	meta, err := json.Marshal(Info{ContentType: contentType})
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path+metaSuffix, meta, filePerm); err != nil {
+		return fmt.Errorf("failed to write blob metadata: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *FileStore) Get(_ context.Context, key string) (io.ReadCloser, *Info, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("failed to stat blob: %w", err)
+	}
+
+	info := &Info{Size: stat.Size()}
+
+	if meta, err := os.ReadFile(path + metaSuffix); err == nil {
+		var stored Info
+		if json.Unmarshal(meta, &stored) == nil {
+			info.ContentType = stored.ContentType
+		}
+	}
+
+	return f, info, nil
+}
+
+func (s *FileStore) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+
+	if err := os.Remove(path + metaSuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob metadata: %w", err)
+	}
+
+	return nil
+}
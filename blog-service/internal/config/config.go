@@ -0,0 +1,272 @@
+package config
+
+import (
+	"blog-service/internal/blob"
+	"blog-service/internal/contentfilter"
+	"blog-service/internal/db/mongo"
+	pg "blog-service/internal/db/postgres"
+	"blog-service/internal/events"
+	"blog-service/internal/live"
+	"blog-service/internal/ratelimit"
+	"blog-service/internal/server"
+	"blog-service/internal/server/models"
+	"blog-service/internal/tracing"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FileVariable names the optional file holding settings, as KEY=value lines. The environment overrides it.
+const FileVariable = "CONFIG_FILE"
+
+// Config is everything blog-service reads from its environment, loaded once at startup.
+type Config struct {
+	Port            int
+	ShutdownTimeout time.Duration
+	LogLevel        slog.Level
+	MetricsAddr     string
+	TracesExporter  string
+	// MigrateOnStart applies the pending migrations before serving, otherwise run "migrate up" first
+	MigrateOnStart bool
+	AuthURI        string
+	// AuthServiceToken lets the user deletion worker call auth-service, it is disabled while empty
+	AuthServiceToken string
+	PubSubBackend    string
+
+	Server        server.Config
+	Mongo         mongo.Config
+	Postgres      pg.Config
+	Blob          blob.Config
+	RateLimit     ratelimit.Config
+	Events        events.Config
+	CommentFilter contentfilter.Config
+
+	entries []entry
+}
+
+/*
+Load reads the configuration from the environment and CONFIG_FILE, applies the defaults and validates it.
+Secrets (passwords, keys and the Mongo URI, which holds credentials) can be read from <KEY>_FILE instead.
+@returns
+error - every invalid or missing setting, the service must not start with it.
+*/
+func Load() (*Config, error) {
+	return load(os.Environ())
+}
+
+func load(environ []string) (*Config, error) {
+	l := newLoader(environ)
+
+	c := &Config{
+		Port:             l.int("PORT", 8080),
+		ShutdownTimeout:  l.duration("SHUTDOWN_TIMEOUT", 20*time.Second),
+		LogLevel:         l.level("LOG_LEVEL", slog.LevelInfo),
+		MetricsAddr:      l.string("METRICS_ADDR", ":9090"),
+		TracesExporter:   l.string("OTEL_TRACES_EXPORTER", tracing.ExporterNone),
+		MigrateOnStart:   l.bool("MIGRATE_ON_START", true),
+		AuthURI:          l.string("AUTH_URI", ""),
+		AuthServiceToken: l.secret("AUTH_SERVICE_TOKEN"),
+		PubSubBackend:    l.string("PUBSUB_BACKEND", live.BackendMemory),
+	}
+
+	c.Server = server.Config{
+		AllowedOrigins:    l.list("CORS_ALLOWED_ORIGINS", []string{"http://localhost:5173"}),
+		SiteURL:           strings.TrimSuffix(l.string("SITE_URL", "http://localhost:5173"), "/"),
+		PublicURL:         strings.TrimSuffix(l.string("PUBLIC_URL", "http://localhost:8080"), "/"),
+		AutoHideThreshold: l.int("REPORT_AUTO_HIDE_THRESHOLD", models.DefaultAutoHideThreshold),
+		TrustProxy:        l.bool("RATE_LIMIT_TRUST_PROXY", false),
+		RateLimits:        map[string]ratelimit.Limit{},
+	}
+
+	c.Mongo = mongo.Config{
+		URI:      l.secret("MONGO_URI"),
+		Database: l.string("MONGO_DB", ""),
+	}
+
+	c.Postgres = pg.Config{
+		Host:     l.string("PG_HOST", ""),
+		Port:     l.int("PG_PORT", 5432),
+		User:     l.string("PG_USER", ""),
+		Password: l.secret("PG_PASSWORD"),
+		Dbname:   l.string("PG_DB", ""),
+	}
+
+	c.Blob = blob.Config{
+		Backend: l.string("BLOB_BACKEND", blob.BackendFS),
+		FSRoot:  l.string("BLOB_FS_ROOT", "./data/blobs"),
+		S3: blob.S3Config{
+			Endpoint:  l.string("S3_ENDPOINT", ""),
+			AccessKey: l.string("S3_ACCESS_KEY", ""),
+			SecretKey: l.secret("S3_SECRET_KEY"),
+			Bucket:    l.string("S3_BUCKET", ""),
+			UseSSL:    l.bool("S3_USE_SSL", false),
+		},
+	}
+
+	c.RateLimit = ratelimit.Config{
+		Backend:       l.string("RATE_LIMIT_BACKEND", ratelimit.BackendMemory),
+		RedisAddr:     l.string("REDIS_ADDR", "localhost:6379"),
+		RedisPassword: l.secret("REDIS_PASSWORD"),
+		RedisDB:       l.int("REDIS_DB", 0),
+	}
+
+	c.Events = events.Config{
+		Backend:    l.string("EVENTS_BACKEND", events.BackendMemory),
+		NATSURL:    l.string("NATS_URL", "nats://localhost:4222"),
+		NATSStream: l.string("NATS_STREAM", events.DefaultStream),
+	}
+
+	// RATE_LIMIT_<NAME> changes the limit of a route, e.g. RATE_LIMIT_COMMENT_CREATE=5/1m
+	for key, value := range l.prefixed("RATE_LIMIT_", "RATE_LIMIT_BACKEND", "RATE_LIMIT_TRUST_PROXY") {
+		limit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("invalid %s: %w", key, err))
+			continue
+		}
+		name := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(key, "RATE_LIMIT_"), "_", "-"))
+		c.Server.RateLimits[name] = limit
+	}
+
+	c.CommentFilter = contentfilter.Config{
+		Blocklist:       l.list("COMMENT_BLOCKLIST", nil),
+		BlocklistFile:   l.string("COMMENT_BLOCKLIST_FILE", ""),
+		MaxLinks:        l.int("COMMENT_MAX_LINKS", 2),
+		DuplicateWindow: l.duration("COMMENT_DUPLICATE_WINDOW", 24*time.Hour),
+	}
+
+	c.entries = l.entries
+
+	if err := errors.Join(l.err(), c.Validate()); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	return c, nil
+}
+
+// Validate checks the settings are usable, the parsing errors are reported by Load.
+func (c *Config) Validate() error {
+	var errs []error
+	require := func(key, value string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", key))
+		}
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		if !slices.Contains(allowed, value) {
+			errs = append(errs, fmt.Errorf("%s must be one of %s, got %q", key, strings.Join(allowed, ", "), value))
+		}
+	}
+	positive := func(key string, value int64) {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", key))
+		}
+	}
+	absoluteURL := func(key, value string) {
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s must be an absolute URL, got %q", key, value))
+		}
+	}
+
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be between 1 and 65535, got %d", c.Port))
+	}
+	positive("SHUTDOWN_TIMEOUT", int64(c.ShutdownTimeout))
+	require("METRICS_ADDR", c.MetricsAddr)
+	oneOf("OTEL_TRACES_EXPORTER", c.TracesExporter, tracing.ExporterOTLP, tracing.ExporterStdout, tracing.ExporterNone)
+	require("AUTH_URI", c.AuthURI)
+	oneOf("PUBSUB_BACKEND", c.PubSubBackend, live.BackendMemory, live.BackendPostgres)
+
+	if len(c.Server.AllowedOrigins) == 0 {
+		errs = append(errs, errors.New("CORS_ALLOWED_ORIGINS is required"))
+	}
+	absoluteURL("SITE_URL", c.Server.SiteURL)
+	absoluteURL("PUBLIC_URL", c.Server.PublicURL)
+	positive("REPORT_AUTO_HIDE_THRESHOLD", int64(c.Server.AutoHideThreshold))
+
+	require("MONGO_URI", c.Mongo.URI)
+	require("MONGO_DB", c.Mongo.Database)
+
+	require("PG_HOST", c.Postgres.Host)
+	require("PG_USER", c.Postgres.User)
+	require("PG_DB", c.Postgres.Dbname)
+	positive("PG_PORT", int64(c.Postgres.Port))
+
+	oneOf("BLOB_BACKEND", c.Blob.Backend, blob.BackendFS, blob.BackendS3)
+	switch c.Blob.Backend {
+	case blob.BackendFS:
+		require("BLOB_FS_ROOT", c.Blob.FSRoot)
+	case blob.BackendS3:
+		require("S3_ENDPOINT", c.Blob.S3.Endpoint)
+		require("S3_ACCESS_KEY", c.Blob.S3.AccessKey)
+		require("S3_SECRET_KEY", c.Blob.S3.SecretKey)
+		require("S3_BUCKET", c.Blob.S3.Bucket)
+	}
+
+	oneOf("RATE_LIMIT_BACKEND", c.RateLimit.Backend, ratelimit.BackendMemory, ratelimit.BackendRedis)
+	if c.RateLimit.Backend == ratelimit.BackendRedis {
+		require("REDIS_ADDR", c.RateLimit.RedisAddr)
+	}
+	if c.RateLimit.RedisDB < 0 {
+		errs = append(errs, errors.New("REDIS_DB must not be negative"))
+	}
+
+	oneOf("EVENTS_BACKEND", c.Events.Backend, events.BackendMemory, events.BackendNATS)
+	if c.Events.Backend == events.BackendNATS {
+		require("NATS_URL", c.Events.NATSURL)
+	}
+
+	if c.CommentFilter.MaxLinks < 0 {
+		errs = append(errs, errors.New("COMMENT_MAX_LINKS must not be negative"))
+	}
+	positive("COMMENT_DUPLICATE_WINDOW", int64(c.CommentFilter.DuplicateWindow))
+
+	return errors.Join(errs...)
+}
+
+// Addr returns the address the HTTP API listens on.
+func (c *Config) Addr() string {
+	return fmt.Sprintf(":%d", c.Port)
+}
+
+// list reads a comma separated list, blank items are dropped.
+func (l *loader) list(key string, fallback []string) []string {
+	raw, ok := l.lookup(key, false)
+	if !ok {
+		l.record(key, strings.Join(fallback, ","), false)
+		return fallback
+	}
+	l.record(key, raw, false)
+
+	values := []string{}
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+
+	return values
+}
+
+// prefixed returns the settings starting with prefix, other than the ones listed in except, by key.
+func (l *loader) prefixed(prefix string, except ...string) map[string]string {
+	values := map[string]string{}
+	for _, source := range []map[string]string{l.file, l.env} {
+		for key, value := range source {
+			if strings.HasPrefix(key, prefix) && !slices.Contains(except, key) && value != "" {
+				values[key] = value
+			}
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		l.record(key, values[key], false)
+	}
+
+	return values
+}
@@ -0,0 +1,118 @@
+// Kept identical in blog-service and auth-service, change both copies together.
+
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+
+	return path
+}
+
+func TestReadFile(t *testing.T) {
+	path := writeFile(t, `
+# A comment
+NAME=plain
+ SPACED = trimmed
+QUOTED="tab\there"
+EMPTY=
+`)
+
+	values, err := readFile(path)
+	if err != nil {
+		t.Fatalf("readFile failed: %v", err)
+	}
+
+	want := map[string]string{"NAME": "plain", "SPACED": "trimmed", "QUOTED": "tab\there", "EMPTY": ""}
+	for key, value := range want {
+		if got, ok := values[key]; !ok || got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+	if len(values) != len(want) {
+		t.Errorf("Read %d values, want %d: %v", len(values), len(want), values)
+	}
+
+	path = writeFile(t, "NAME=plain\nNOT A SETTING\n")
+	if _, err := readFile(path); err == nil || !strings.Contains(err.Error(), path+":2:") {
+		t.Errorf("Expected the malformed line to be reported, got %v", err)
+	}
+}
+
+func TestLookupOrder(t *testing.T) {
+	secret := writeFile(t, "from-secret-file\n")
+	file := writeFile(t, "NAME=from-file\nOTHER=from-file\nEMPTY=from-file\nTOKEN_FILE="+secret+"\nPLAIN_FILE="+secret+"\n")
+
+	l := newLoader([]string{FileVariable + "=" + file, "NAME=from-env", "EMPTY="})
+
+	tests := []struct {
+		key    string
+		secret bool
+		want   string
+		ok     bool
+	}{
+		{"NAME", false, "from-env", true},
+		{"OTHER", false, "from-file", true},
+		{"EMPTY", false, "from-file", true},
+		{"TOKEN", true, "from-secret-file", true},
+		{"PLAIN", false, "", false},
+		{"MISSING", true, "", false},
+	}
+
+	for _, test := range tests {
+		if got, ok := l.lookup(test.key, test.secret); got != test.want || ok != test.ok {
+			t.Errorf("lookup(%s) = %q, %v; want %q, %v", test.key, got, ok, test.want, test.ok)
+		}
+	}
+	if err := l.err(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestLoaderGathersErrors(t *testing.T) {
+	l := newLoader([]string{"COUNT=many", "TIMEOUT=soon", "LEVEL=loud", "TOKEN_FILE=/nonexistent", FileVariable + "=/nonexistent"})
+
+	l.int("COUNT", 1)
+	l.duration("TIMEOUT", time.Second)
+	l.level("LEVEL", slog.LevelInfo)
+	l.secret("TOKEN")
+
+	if got := l.duration("UNSET", time.Minute); got != time.Minute {
+		t.Errorf("Unset duration = %v, want the fallback", got)
+	}
+
+	err := l.err()
+	for _, want := range []string{"config file", "COUNT", "TIMEOUT", "LEVEL", "TOKEN_FILE"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Error does not mention %s: %v", want, err)
+		}
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	l := newLoader([]string{"NAME=visible", "PASSWORD=hunter2"})
+	l.string("NAME", "")
+	l.secret("PASSWORD")
+	l.secret("UNSET_PASSWORD")
+
+	var out bytes.Buffer
+	(&Config{entries: l.entries}).Print(&out)
+
+	if want := "NAME=visible\nPASSWORD=" + redacted + "\nUNSET_PASSWORD=\n"; out.String() != want {
+		t.Errorf("Print wrote:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,186 @@
+package contentfilter
+
+import (
+	"blog-service/internal/db/postgres/models"
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// Both classes need this many examples before the classifier is trusted
+	minTrainingSamples  = 10
+	trainingSampleLimit = 5000
+	reloadInterval      = 10 * time.Minute
+	reloadTimeout       = 30 * time.Second
+
+	holdProbability   = 0.9
+	rejectProbability = 0.99
+
+	linkToken = "__link__"
+)
+
+// TrainingStore provides comments labelled by moderators, *postgres.Client implements it.
+type TrainingStore interface {
+	GetFilterSamples(ctx context.Context, limit int) ([]models.FilterSample, error)
+}
+
+/*
+Bayes is a naive Bayes spam classifier over the words of a comment.
+It is trained from moderation decisions: comments moderators hid or deleted are spam,
+comments they approved or restored are not.
+*/
+type Bayes struct {
+	store TrainingStore
+
+	mu     sync.RWMutex
+	tokens map[string]*[2]int
+	docs   [2]int
+}
+
+const (
+	ham = iota
+	spam
+)
+
+func NewBayes(store TrainingStore) *Bayes {
+	return &Bayes{store: store, tokens: map[string]*[2]int{}}
+}
+
+func tokenize(text string) map[string]struct{} {
+	tokens := map[string]struct{}{}
+
+	if linkRe.MatchString(text) {
+		tokens[linkToken] = struct{}{}
+	}
+
+	for _, word := range normalizedWords(linkRe.ReplaceAllString(text, " ")) {
+		if len(word) >= 2 && len(word) <= 30 {
+			tokens[word] = struct{}{}
+		}
+	}
+
+	return tokens
+}
+
+// Train adds one labelled comment to the model.
+func (b *Bayes) Train(text string, isSpam bool) {
+	class := ham
+	if isSpam {
+		class = spam
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.train(text, class)
+}
+
+func (b *Bayes) train(text string, class int) {
+	b.docs[class]++
+
+	for token := range tokenize(text) {
+		counts, ok := b.tokens[token]
+		if !ok {
+			counts = &[2]int{}
+			b.tokens[token] = counts
+		}
+		counts[class]++
+	}
+}
+
+/*
+SpamProbability estimates how likely text is spam.
+@returns
+float64 - the probability, between 0 and 1.
+bool - false while the model has too few examples to judge.
+*/
+func (b *Bayes) SpamProbability(text string) (float64, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.docs[spam] < minTrainingSamples || b.docs[ham] < minTrainingSamples {
+		return 0, false
+	}
+
+	total := float64(b.docs[spam] + b.docs[ham])
+
+	// Sum in log space, the product of many small probabilities underflows
+	logOdds := math.Log(float64(b.docs[spam])/total) - math.Log(float64(b.docs[ham])/total)
+
+	for token := range tokenize(text) {
+		counts, ok := b.tokens[token]
+		if !ok {
+			continue
+		}
+
+		// Laplace smoothing keeps tokens seen in one class only from deciding alone
+		pSpam := (float64(counts[spam]) + 1) / (float64(b.docs[spam]) + 2)
+		pHam := (float64(counts[ham]) + 1) / (float64(b.docs[ham]) + 2)
+		logOdds += math.Log(pSpam) - math.Log(pHam)
+	}
+
+	return 1 / (1 + math.Exp(-logOdds)), true
+}
+
+func (b *Bayes) Check(_ context.Context, comment *Comment) (Result, error) {
+	probability, ok := b.SpamProbability(comment.Content)
+
+	switch {
+	case !ok:
+		return Result{Verdict: Accept}, nil
+	case probability >= rejectProbability:
+		return Result{Verdict: Reject, Reason: "classified as spam"}, nil
+	case probability >= holdProbability:
+		return Result{Verdict: Hold, Reason: "likely spam"}, nil
+	default:
+		return Result{Verdict: Accept}, nil
+	}
+}
+
+// Reload retrains the model from the latest moderation decisions.
+func (b *Bayes) Reload(ctx context.Context) error {
+	samples, err := b.store.GetFilterSamples(ctx, trainingSampleLimit)
+	if err != nil {
+		return err
+	}
+
+	tokens := map[string]*[2]int{}
+	fresh := &Bayes{tokens: tokens}
+	for _, sample := range samples {
+		class := ham
+		if sample.Spam {
+			class = spam
+		}
+		fresh.train(sample.Content, class)
+	}
+
+	b.mu.Lock()
+	b.tokens = fresh.tokens
+	b.docs = fresh.docs
+	b.mu.Unlock()
+
+	return nil
+}
+
+// Run trains the model at startup and again periodically until ctx is cancelled.
+func (b *Bayes) Run(ctx context.Context) {
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		reloadCtx, cancel := context.WithTimeout(ctx, reloadTimeout)
+		if err := b.Reload(reloadCtx); err != nil {
+			slog.ErrorContext(ctx, "Failed to train spam classifier", "error", err)
+		}
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
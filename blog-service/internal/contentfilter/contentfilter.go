@@ -0,0 +1,78 @@
+package contentfilter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Store is everything the built-in filters read from the database.
+type Store interface {
+	CommentHistory
+	TrainingStore
+}
+
+/*
+Config sets up the built-in filters:
+Blocklist - words rejected in comments.
+BlocklistFile - a file with one blocked word per line, added to the list.
+MaxLinks - how many links a comment may contain before it is held.
+DuplicateWindow - how long a user cannot repeat a comment.
+*/
+type Config struct {
+	Blocklist       []string
+	BlocklistFile   string
+	MaxLinks        int
+	DuplicateWindow time.Duration
+}
+
+/*
+New builds the comment filter chain.
+@returns
+Chain - the filters in the order they run.
+*Bayes - the classifier, its Run method keeps it trained.
+*/
+func New(store Store, cfg Config) (Chain, *Bayes, error) {
+	words := append([]string{}, cfg.Blocklist...)
+
+	if cfg.BlocklistFile != "" {
+		fileWords, err := readWordFile(cfg.BlocklistFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		words = append(words, fileWords...)
+	}
+
+	bayes := NewBayes(store)
+
+	// Cheap local checks run first, a rejection skips the database lookup
+	chain := Chain{
+		NewWordList(words, Reject),
+		&LinkLimit{Max: cfg.MaxLinks},
+		bayes,
+		&Duplicate{History: store, Window: cfg.DuplicateWindow},
+	}
+
+	return chain, bayes, nil
+}
+
+func readWordFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blocklist: %w", err)
+	}
+	defer file.Close()
+
+	words := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			words = append(words, line)
+		}
+	}
+
+	return words, scanner.Err()
+}
@@ -0,0 +1,30 @@
+package contentfilter
+
+import (
+	"context"
+	"time"
+)
+
+// CommentHistory tells whether a user posted the same content before, *postgres.Client implements it.
+type CommentHistory interface {
+	HasRecentComment(ctx context.Context, userID int, content string, since time.Time) (bool, error)
+}
+
+// Duplicate rejects comments a user already posted within Window, on any article.
+type Duplicate struct {
+	History CommentHistory
+	Window  time.Duration
+}
+
+func (d *Duplicate) Check(ctx context.Context, comment *Comment) (Result, error) {
+	found, err := d.History.HasRecentComment(ctx, comment.UserID, comment.Content, time.Now().Add(-d.Window))
+	if err != nil {
+		return Result{}, err
+	}
+
+	if found {
+		return Result{Verdict: Reject, Reason: "duplicate of a recent comment"}, nil
+	}
+
+	return Result{Verdict: Accept}, nil
+}
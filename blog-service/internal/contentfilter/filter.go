@@ -0,0 +1,74 @@
+package contentfilter
+
+import (
+	"context"
+	"fmt"
+)
+
+// Verdict is the decision of a filter, ordered from most to least permissive.
+type Verdict int
+
+const (
+	Accept Verdict = iota
+	// Hold stores the comment but only shows it to its author and moderators until it is reviewed
+	Hold
+	Reject
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case Accept:
+		return "accept"
+	case Hold:
+		return "hold"
+	case Reject:
+		return "reject"
+	default:
+		return fmt.Sprintf("Verdict(%d)", int(v))
+	}
+}
+
+// Comment is what filters get to see of a comment before it is stored.
+type Comment struct {
+	Content   string
+	ArticleID string
+	UserID    int
+}
+
+// Result is a verdict and, unless the comment was accepted, why.
+type Result struct {
+	Reason  string
+	Verdict Verdict
+}
+
+// ContentFilter decides whether a comment may be published.
+type ContentFilter interface {
+	Check(ctx context.Context, comment *Comment) (Result, error)
+}
+
+/*
+Chain runs filters in order and returns the strictest result.
+It stops at the first rejection, later filters could not change the outcome.
+*/
+type Chain []ContentFilter
+
+func (c Chain) Check(ctx context.Context, comment *Comment) (Result, error) {
+	result := Result{Verdict: Accept}
+
+	for _, filter := range c {
+		res, err := filter.Check(ctx, comment)
+		if err != nil {
+			return Result{}, err
+		}
+
+		if res.Verdict > result.Verdict {
+			result = res
+		}
+
+		if result.Verdict == Reject {
+			break
+		}
+	}
+
+	return result, nil
+}
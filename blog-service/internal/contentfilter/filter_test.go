@@ -0,0 +1,149 @@
+package contentfilter
+
+import (
+	"blog-service/internal/db/postgres/models"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type sampleStore struct {
+	samples []models.FilterSample
+}
+
+func (s *sampleStore) GetFilterSamples(_ context.Context, limit int) ([]models.FilterSample, error) {
+	if len(s.samples) > limit {
+		return s.samples[:limit], nil
+	}
+	return s.samples, nil
+}
+
+type fixedHistory bool
+
+func (h fixedHistory) HasRecentComment(context.Context, int, string, time.Time) (bool, error) {
+	return bool(h), nil
+}
+
+func check(t *testing.T, filter ContentFilter, content string) Result {
+	t.Helper()
+
+	result, err := filter.Check(context.Background(), &Comment{Content: content, UserID: 1})
+	if err != nil {
+		t.Fatalf("check %q: %v", content, err)
+	}
+	return result
+}
+
+func TestWordListNormalizesLeetspeak(t *testing.T) {
+	filter := NewWordList([]string{"scam"}, Reject)
+
+	tests := []struct {
+		content string
+		want    Verdict
+	}{
+		{"a normal comment", Accept},
+		{"this is a scam", Reject},
+		{"this is a SCAM!", Reject},
+		{"total 5c4m", Reject},
+		{"s.c.a.m", Reject},
+		{"sssccaaam", Reject},
+		{"scampi for dinner", Accept},
+	}
+
+	for _, tt := range tests {
+		if got := check(t, filter, tt.content).Verdict; got != tt.want {
+			t.Errorf("%q: got %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestWordListKeepsDoubleLetters(t *testing.T) {
+	filter := NewWordList([]string{"ass", "bad", "kill"}, Reject)
+
+	tests := []struct {
+		content string
+		want    Verdict
+	}{
+		{"as good as it gets", Accept},
+		{"what an ass", Reject},
+		{"what an asss", Reject},
+		{"so baaad", Reject},
+		{"kill", Reject},
+		{"kiiill them", Reject},
+		{"k1lll", Reject},
+		{"a kiln", Accept},
+	}
+
+	for _, tt := range tests {
+		if got := check(t, filter, tt.content).Verdict; got != tt.want {
+			t.Errorf("%q: got %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestLinkLimit(t *testing.T) {
+	filter := &LinkLimit{Max: 1}
+
+	if got := check(t, filter, "see https://example.com").Verdict; got != Accept {
+		t.Errorf("one link: got %v, want accept", got)
+	}
+
+	if got := check(t, filter, "see https://example.com and www.example.org").Verdict; got != Hold {
+		t.Errorf("two links: got %v, want hold", got)
+	}
+}
+
+func TestChainReturnsStrictestResult(t *testing.T) {
+	chain := Chain{
+		&LinkLimit{Max: 0},
+		NewWordList([]string{"scam"}, Reject),
+		&Duplicate{History: fixedHistory(false), Window: time.Hour},
+	}
+
+	if got := check(t, chain, "hello").Verdict; got != Accept {
+		t.Errorf("clean comment: got %v, want accept", got)
+	}
+
+	result := check(t, chain, "www.example.com")
+	if result.Verdict != Hold || result.Reason == "" {
+		t.Errorf("link: got %+v, want hold with a reason", result)
+	}
+
+	if got := check(t, chain, "scam at www.example.com").Verdict; got != Reject {
+		t.Errorf("link and blocked word: got %v, want reject", got)
+	}
+
+	chain[2] = &Duplicate{History: fixedHistory(true), Window: time.Hour}
+	if got := check(t, chain, "hello").Verdict; got != Reject {
+		t.Errorf("duplicate: got %v, want reject", got)
+	}
+}
+
+func TestBayesLearnsFromSamples(t *testing.T) {
+	store := &sampleStore{}
+	bayes := NewBayes(store)
+
+	if got := check(t, bayes, "cheap pills").Verdict; got != Accept {
+		t.Fatalf("untrained: got %v, want accept", got)
+	}
+
+	for i := range 20 {
+		store.samples = append(store.samples,
+			models.FilterSample{Content: fmt.Sprintf("buy cheap pills now offer %d https://spam.example", i), Spam: true},
+			models.FilterSample{Content: fmt.Sprintf("great article about go interfaces, thanks %d", i), Spam: false},
+		)
+	}
+
+	if err := bayes.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := check(t, bayes, "buy cheap pills at https://pills.example").Verdict; got != Reject {
+		t.Errorf("spam: got %v, want reject", got)
+	}
+
+	if got := check(t, bayes, "thanks for the great article").Verdict; got != Accept {
+		t.Errorf("ham: got %v, want accept", got)
+	}
+}
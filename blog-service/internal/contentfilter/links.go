@@ -0,0 +1,21 @@
+package contentfilter
+
+import (
+	"context"
+	"regexp"
+)
+
+var linkRe = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// LinkLimit holds comments with more links than allowed, a common trait of spam.
+type LinkLimit struct {
+	Max int
+}
+
+func (l *LinkLimit) Check(_ context.Context, comment *Comment) (Result, error) {
+	if len(linkRe.FindAllStringIndex(comment.Content, l.Max+1)) > l.Max {
+		return Result{Verdict: Hold, Reason: "contains too many links"}, nil
+	}
+
+	return Result{Verdict: Accept}, nil
+}
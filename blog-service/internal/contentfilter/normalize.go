@@ -0,0 +1,66 @@
+package contentfilter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// leetspeak maps characters commonly used in place of letters back to the letter.
+var leetspeak = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'@': 'a',
+	'$': 's',
+	'!': 'i',
+	'|': 'l',
+	'+': 't',
+}
+
+const sentencePunctuation = ".,;:!?\"')"
+
+/*
+normalizedWords splits text into lower case words, undoing leetspeak on the way.
+Separators inside a word ("b.a.d") are dropped, so spelling a word out does not hide it.
+Punctuation ending a word is trimmed first, "bad!" must not read as "badi".
+*/
+func normalizedWords(text string) []string {
+	words := []string{}
+
+	for _, field := range strings.Fields(strings.ToLower(text)) {
+		var b strings.Builder
+		for _, r := range strings.TrimRight(field, sentencePunctuation) {
+			if mapped, ok := leetspeak[r]; ok {
+				r = mapped
+			}
+			if unicode.IsLetter(r) {
+				b.WriteRune(r)
+			}
+		}
+
+		if b.Len() > 0 {
+			words = append(words, b.String())
+		}
+	}
+
+	return words
+}
+
+// collapseRepeats shortens runs of the same letter to one letter, "baaad" becomes "bad".
+func collapseRepeats(word string) string {
+	var b strings.Builder
+	var last rune
+
+	for i, r := range word {
+		if i == 0 || r != last {
+			b.WriteRune(r)
+		}
+		last = r
+	}
+
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package contentfilter
+
+import (
+	"context"
+)
+
+/*
+WordList flags comments containing a listed word.
+Words are compared after normalisation as written, and words stretching letters are also
+compared with repeated letters collapsed on both sides, so "b4aad" matches a listed "bad"
+and "kiiill" a listed "kill". A word without repeated letters is only compared as written,
+a listed "ass" does not block "as".
+*/
+type WordList struct {
+	words map[string]struct{}
+	// collapsed holds the listed words with repeated letters collapsed
+	collapsed map[string]struct{}
+	verdict   Verdict
+}
+
+// NewWordList creates a filter giving verdict to comments that use one of words.
+func NewWordList(words []string, verdict Verdict) *WordList {
+	w := &WordList{words: map[string]struct{}{}, collapsed: map[string]struct{}{}, verdict: verdict}
+
+	for _, word := range words {
+		for _, normalized := range normalizedWords(word) {
+			w.words[normalized] = struct{}{}
+			w.collapsed[collapseRepeats(normalized)] = struct{}{}
+		}
+	}
+
+	return w
+}
+
+func (w *WordList) Check(_ context.Context, comment *Comment) (Result, error) {
+	if len(w.words) == 0 {
+		return Result{Verdict: Accept}, nil
+	}
+
+	for _, word := range normalizedWords(comment.Content) {
+		if listed(w.words, word) {
+			return Result{Verdict: w.verdict, Reason: "contains a blocked word"}, nil
+		}
+
+		if collapsed := collapseRepeats(word); collapsed != word && listed(w.collapsed, collapsed) {
+			return Result{Verdict: w.verdict, Reason: "contains a blocked word"}, nil
+		}
+	}
+
+	return Result{Verdict: Accept}, nil
+}
+
+func listed(words map[string]struct{}, word string) bool {
+	_, ok := words[word]
+	return ok
+}
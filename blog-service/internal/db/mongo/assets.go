@@ -0,0 +1,70 @@
+package mongo
+
+import (
+	"blog-service/internal/db/mongo/models"
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (c *Client) InsertAsset(ctx context.Context, asset *models.AssetDB) (*mongo.InsertOneResult, error) {
+	collection := c.DB.Collection(assetCollection)
+
+	result, err := collection.InsertOne(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindAssetsByIDs retrieves the assets with the given ids, missing ids are skipped.
+func (c *Client) FindAssetsByIDs(ctx context.Context, assetIDs []primitive.ObjectID) ([]models.AssetDB, error) {
+	collection := c.DB.Collection(assetCollection)
+	filter := bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: assetIDs}}}}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute find command: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	assets := []models.AssetDB{}
+	if err = cursor.All(ctx, &assets); err != nil {
+		return nil, fmt.Errorf("failed to decode assets from cursor: %w", err)
+	}
+
+	return assets, nil
+}
+
+// FindAssetsByOwner retrieves every asset uploaded by a user.
+func (c *Client) FindAssetsByOwner(ctx context.Context, ownerID int) ([]models.AssetDB, error) {
+	collection := c.DB.Collection(assetCollection)
+
+	cursor, err := collection.Find(ctx, bson.D{{Key: "ownerId", Value: ownerID}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute find command: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	assets := []models.AssetDB{}
+	if err = cursor.All(ctx, &assets); err != nil {
+		return nil, fmt.Errorf("failed to decode assets from cursor: %w", err)
+	}
+
+	return assets, nil
+}
+
+// DeleteAsset removes the document of an asset, its blobs are left to the caller.
+func (c *Client) DeleteAsset(ctx context.Context, assetID *primitive.ObjectID) error {
+	collection := c.DB.Collection(assetCollection)
+
+	if _, err := collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: assetID}}); err != nil {
+		return fmt.Errorf("failed to delete asset: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package mongo
+
+import (
+	"blog-service/internal/db/mongo/models"
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+FindArticleSummaries retrieves the articles with the given ids without their bodies.
+Ids without an article are left out of the result.
+*/
+func (c *Client) FindArticleSummaries(ctx context.Context, articleIDs []primitive.ObjectID) ([]models.ArticleDB, error) {
+	collection := c.DB.Collection(articleCollection)
+
+	findOptions := options.Find().SetProjection(bson.D{
+		{Key: "content", Value: 0},
+		{Key: "contentHtml", Value: 0},
+	})
+
+	cursor, err := collection.Find(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: articleIDs}}}}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find articles: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	articles := []models.ArticleDB{}
+	if err = cursor.All(ctx, &articles); err != nil {
+		return nil, fmt.Errorf("failed to decode articles: %w", err)
+	}
+
+	return articles, nil
+}
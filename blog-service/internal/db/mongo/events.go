@@ -0,0 +1,58 @@
+package mongo
+
+import (
+	"blog-service/internal/db/mongo/models"
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// pushEvents adds events to the pending events of the article changed by update.
+func pushEvents(update bson.D, events []models.ArticleEventDB) bson.D {
+	if len(events) == 0 {
+		return update
+	}
+
+	return append(update, bson.E{Key: "$push", Value: bson.D{
+		{Key: "pendingEvents", Value: bson.D{{Key: "$each", Value: events}}},
+	}})
+}
+
+// FindPendingArticleEvents returns up to limit articles with pending events, only their id and events are loaded.
+func (c *Client) FindPendingArticleEvents(ctx context.Context, limit int64) ([]models.ArticleDB, error) {
+	collection := c.DB.Collection(articleCollection)
+	filter := bson.D{{Key: "pendingEvents.key", Value: bson.D{{Key: "$exists", Value: true}}}}
+	findOptions := options.Find().
+		SetProjection(bson.D{{Key: "pendingEvents", Value: 1}}).
+		SetLimit(limit)
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute find command: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	articles := []models.ArticleDB{}
+	if err = cursor.All(ctx, &articles); err != nil {
+		return nil, fmt.Errorf("failed to decode articles from cursor: %w", err)
+	}
+
+	return articles, nil
+}
+
+// ClearArticleEvents removes the events with the given keys once they are in the outbox.
+func (c *Client) ClearArticleEvents(ctx context.Context, articleID *primitive.ObjectID, keys []string) error {
+	collection := c.DB.Collection(articleCollection)
+	update := bson.D{{Key: "$pull", Value: bson.D{
+		{Key: "pendingEvents", Value: bson.D{{Key: "key", Value: bson.D{{Key: "$in", Value: keys}}}}},
+	}}}
+
+	if _, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: articleID}}, update); err != nil {
+		return fmt.Errorf("failed to clear article events: %w", err)
+	}
+
+	return nil
+}
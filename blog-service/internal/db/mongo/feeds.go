@@ -0,0 +1,103 @@
+package mongo
+
+import (
+	"blog-service/internal/db/mongo/models"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FeedFilter narrows a feed down to one author or one category, zero values match everything.
+type FeedFilter struct {
+	Category    string
+	PublisherID int
+}
+
+func (f FeedFilter) query() bson.D {
+	query := bson.D{publishedFilter()}
+
+	if f.PublisherID != 0 {
+		query = append(query, bson.E{Key: "publisherId", Value: f.PublisherID})
+	}
+
+	if f.Category != "" {
+		query = append(query, bson.E{Key: "category", Value: f.Category})
+	}
+
+	return query
+}
+
+// FeedState summarises the articles of a feed so clients can be answered without loading them.
+type FeedState struct {
+	LastModified time.Time
+	// IDs of the articles in the feed, newest first
+	IDs []primitive.ObjectID
+}
+
+// feedSort orders a feed newest first, served by the publishAt indexes.
+func feedSort() bson.D {
+	return bson.D{{Key: "publishAt", Value: -1}, {Key: "createdAt", Value: -1}}
+}
+
+/*
+GetFeedState returns which articles the feed lists and when the newest change to one of them happened.
+Only the dates of the limit newest articles are read, through the same indexed sort as the feed.
+An article leaving or joining the feed changes the IDs, an edit the date.
+*/
+func (c *Client) GetFeedState(ctx context.Context, filter FeedFilter, limit int64) (*FeedState, error) {
+	collection := c.DB.Collection(articleCollection)
+
+	findOptions := options.Find().
+		SetSort(feedSort()).
+		SetLimit(limit).
+		SetProjection(bson.D{{Key: "createdAt", Value: 1}, {Key: "publishAt", Value: 1}, {Key: "updatedAt", Value: 1}})
+
+	cursor, err := collection.Find(ctx, filter.query(), findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find feed state: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var articles []models.ArticleDB
+	if err := cursor.All(ctx, &articles); err != nil {
+		return nil, fmt.Errorf("failed to decode feed state: %w", err)
+	}
+
+	state := &FeedState{IDs: make([]primitive.ObjectID, 0, len(articles))}
+	for _, article := range articles {
+		state.IDs = append(state.IDs, article.ID)
+		for _, t := range []time.Time{article.CreatedAt, article.PublishAt, article.UpdatedAt} {
+			if t.After(state.LastModified) {
+				state.LastModified = t
+			}
+		}
+	}
+
+	return state, nil
+}
+
+// FindFeedArticles retrieves the newest published articles of a feed.
+func (c *Client) FindFeedArticles(ctx context.Context, filter FeedFilter, limit int64) ([]models.ArticleDB, error) {
+	collection := c.DB.Collection(articleCollection)
+
+	findOptions := options.Find().
+		SetSort(feedSort()).
+		SetLimit(limit)
+
+	cursor, err := collection.Find(ctx, filter.query(), findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find feed articles: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	articles := []models.ArticleDB{}
+	if err = cursor.All(ctx, &articles); err != nil {
+		return nil, fmt.Errorf("failed to decode articles: %w", err)
+	}
+
+	return articles, nil
+}
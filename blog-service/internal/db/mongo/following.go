@@ -0,0 +1,72 @@
+package mongo
+
+import (
+	"blog-service/internal/db/mongo/models"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FollowingCursor marks the last article of a feed page, the next page starts after it.
+type FollowingCursor struct {
+	PublishAt time.Time
+	ID        primitive.ObjectID
+}
+
+/*
+FindFollowingArticles retrieves published articles written by one of publisherIDs
+or filed under one of categories, newest first.
+Pages are addressed by the position of the last article seen instead of an offset,
+so the cost of a page does not grow with how far the reader scrolled.
+*/
+func (c *Client) FindFollowingArticles(ctx context.Context, publisherIDs []int, categories []string,
+	after *FollowingCursor, limit int64) ([]models.ArticleDB, error) {
+	collection := c.DB.Collection(articleCollection)
+
+	sources := bson.A{}
+	if len(publisherIDs) > 0 {
+		sources = append(sources, bson.D{{Key: "publisherId", Value: bson.D{{Key: "$in", Value: publisherIDs}}}})
+	}
+	if len(categories) > 0 {
+		sources = append(sources, bson.D{{Key: "category", Value: bson.D{{Key: "$in", Value: categories}}}})
+	}
+
+	if len(sources) == 0 {
+		return []models.ArticleDB{}, nil
+	}
+
+	query := bson.D{publishedFilter(), {Key: "$or", Value: sources}}
+
+	if after != nil {
+		query = append(query, bson.E{Key: "$and", Value: bson.A{
+			bson.D{{Key: "$or", Value: bson.A{
+				bson.D{{Key: "publishAt", Value: bson.D{{Key: "$lt", Value: after.PublishAt}}}},
+				bson.D{
+					{Key: "publishAt", Value: after.PublishAt},
+					{Key: "_id", Value: bson.D{{Key: "$lt", Value: after.ID}}},
+				},
+			}}},
+		}})
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "publishAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(limit)
+
+	cursor, err := collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find followed articles: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	articles := []models.ArticleDB{}
+	if err = cursor.All(ctx, &articles); err != nil {
+		return nil, fmt.Errorf("failed to decode articles: %w", err)
+	}
+
+	return articles, nil
+}
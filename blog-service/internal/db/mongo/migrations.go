@@ -0,0 +1,181 @@
+package mongo
+
+import (
+	"blog-service/internal/db/mongo/models"
+	"blog-service/internal/render"
+	"blog-service/internal/slug"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+DocumentMigration rewrites the stored documents into the shape the code now expects.
+Up must be idempotent: there is no lock, replicas starting together may both run it,
+and a migration interrupted before being recorded runs again on the next start.
+*/
+type DocumentMigration struct {
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+	Version int
+}
+
+// migrationRecord is kept in schema_migrations for every document migration applied.
+type migrationRecord struct {
+	AppliedAt time.Time `bson:"appliedAt"`
+	Name      string    `bson:"name"`
+	Version   int       `bson:"_id"`
+}
+
+// documentMigrations lists the document migrations in the order they are applied, append new ones.
+func documentMigrations() []DocumentMigration {
+	return []DocumentMigration{
+		{Version: 1, Name: "backfill_status_and_format", Up: backfillStatusAndFormat},
+		{Version: 2, Name: "backfill_slugs", Up: backfillSlugs},
+		{Version: 3, Name: "backfill_publish_at", Up: backfillPublishAt},
+	}
+}
+
+/*
+migrateDocuments applies the migrations that schema_migrations does not record yet, in order.
+@returns
+int - how many were applied.
+*/
+func (c *Client) migrateDocuments(ctx context.Context, migrations []DocumentMigration) (int, error) {
+	collection := c.DB.Collection(migrationCollection)
+
+	cursor, err := collection.Find(ctx, bson.D{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read applied document migrations: %w", err)
+	}
+
+	var records []migrationRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return 0, fmt.Errorf("failed to decode applied document migrations: %w", err)
+	}
+
+	applied := map[int]bool{}
+	for _, record := range records {
+		applied[record.Version] = true
+	}
+
+	count := 0
+	for _, migration := range migrations {
+		if applied[migration.Version] {
+			continue
+		}
+
+		if err := migration.Up(ctx, c.DB); err != nil {
+			return count, fmt.Errorf("failed to apply document migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		record := migrationRecord{AppliedAt: time.Now(), Name: migration.Name, Version: migration.Version}
+		_, err := collection.ReplaceOne(ctx, bson.D{{Key: "_id", Value: migration.Version}}, record, options.Replace().SetUpsert(true))
+		if err != nil {
+			return count, fmt.Errorf("failed to record document migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		slog.InfoContext(ctx, "Applied document migration", "version", migration.Version, "name", migration.Name)
+		count++
+	}
+
+	return count, nil
+}
+
+/*
+backfillStatusAndFormat gives the articles written before the lifecycle and the formats
+the values they were read with: published, in plain text.
+*/
+func backfillStatusAndFormat(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection(articleCollection)
+
+	defaults := []struct {
+		field string
+		value string
+	}{
+		{field: "status", value: models.StatusPublished},
+		{field: "contentFormat", value: render.FormatPlain},
+	}
+
+	for _, d := range defaults {
+		filter := bson.D{{Key: d.field, Value: bson.D{{Key: "$in", Value: bson.A{nil, ""}}}}}
+		update := bson.D{{Key: "$set", Value: bson.D{{Key: d.field, Value: d.value}}}}
+
+		if _, err := collection.UpdateMany(ctx, filter, update); err != nil {
+			return fmt.Errorf("failed to backfill %s: %w", d.field, err)
+		}
+	}
+
+	return nil
+}
+
+/*
+backfillSlugs reserves a slug for the articles created before slugs existed, built from
+their title like the slug of a new article. Running it again keeps the slug an interrupted
+run already reserved, reserving a slug is idempotent for its article.
+*/
+func backfillSlugs(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection(articleCollection)
+
+	filter := bson.D{{Key: "slug", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$type", Value: "string"}}}}}}
+	findOptions := options.Find().SetProjection(bson.D{{Key: "title", Value: 1}})
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return fmt.Errorf("failed to find articles without a slug: %w", err)
+	}
+
+	var articles []models.ArticleDB
+	if err := cursor.All(ctx, &articles); err != nil {
+		return fmt.Errorf("failed to decode articles without a slug: %w", err)
+	}
+
+	for _, article := range articles {
+		base, reserved := slug.Make(article.Title), ""
+		for _, candidate := range slug.Candidates(base, article.ID.Hex()) {
+			ok, err := reserveSlug(ctx, db, candidate, &article.ID)
+			if err != nil {
+				return err
+			}
+			if ok {
+				reserved = candidate
+				break
+			}
+		}
+		if reserved == "" {
+			return fmt.Errorf("failed to find a free slug for article %s", article.ID.Hex())
+		}
+
+		update := bson.D{{Key: "$set", Value: bson.D{{Key: "slug", Value: reserved}, {Key: "slugBase", Value: base}}}}
+		if _, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: article.ID}}, update); err != nil {
+			return fmt.Errorf("failed to set slug of article %s: %w", article.ID.Hex(), err)
+		}
+	}
+
+	return nil
+}
+
+/*
+backfillPublishAt dates the published and archived articles written before the lifecycle by
+their creation, the feeds sort and page by publishAt and would otherwise skip them.
+*/
+func backfillPublishAt(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection(articleCollection)
+
+	filter := bson.D{
+		{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{models.StatusPublished, models.StatusArchived, "", nil}}}},
+		{Key: "publishAt", Value: bson.D{{Key: "$in", Value: bson.A{nil, time.Time{}}}}},
+	}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.D{{Key: "publishAt", Value: "$createdAt"}}}}}
+
+	if _, err := collection.UpdateMany(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to backfill publishAt: %w", err)
+	}
+
+	return nil
+}
@@ -3,15 +3,115 @@ package models
 import (
 	"time"
 
+	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Article lifecycle states.
+const (
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+	// StatusHidden is set by moderators, only they can take an article out of it
+	StatusHidden = "hidden"
+)
+
 type ArticleDB struct {
 	CreatedAt     time.Time          `bson:"createdAt"`
+	UpdatedAt     time.Time          `bson:"updatedAt"`
+	PublishAt     time.Time          `bson:"publishAt"`
 	Title         string             `bson:"title"`
+	Slug          string             `bson:"slug,omitempty"`
+	SlugBase      string             `bson:"slugBase,omitempty" json:"-"`
 	Content       string             `bson:"content"`
+	ContentFormat string             `bson:"contentFormat"`
+	ContentHTML   string             `bson:"contentHtml" json:"-"`
+	Excerpt       string             `bson:"excerpt"`
 	Category      string             `bson:"category"`
 	PublisherName string             `bson:"publisherName"`
+	Status        string             `bson:"status"`
+	CoverImage    *CoverImage        `bson:"coverImage,omitempty"`
+	AssetIDs      []string           `bson:"assetIds,omitempty"`
+	PendingEvents []ArticleEventDB   `bson:"pendingEvents,omitempty" json:"-"`
 	ID            primitive.ObjectID `bson:"_id,omitempty"`
 	PublisherID   int                `bson:"publisherId"`
+	RenderVersion int                `bson:"renderVersion" json:"-"`
+}
+
+/*
+ArticleEventDB is an event about an article, written by the same update as the change it describes
+so neither is kept without the other. The outbox relay moves it to Postgres, where Key becomes
+the idempotency key of the outbox event.
+*/
+type ArticleEventDB struct {
+	CreatedAt time.Time `bson:"createdAt"`
+	Key       string    `bson:"key"`
+	Type      string    `bson:"type"`
+	Revision  int       `bson:"revision,omitempty"`
+}
+
+// NewArticleEvent creates an event of eventType with a new key, revision is only set on article.updated.
+func NewArticleEvent(eventType string, revision int) ArticleEventDB {
+	return ArticleEventDB{
+		CreatedAt: time.Now(),
+		Key:       uuid.NewString(),
+		Type:      eventType,
+		Revision:  revision,
+	}
+}
+
+// CoverImage is copied onto the article so feed cards can show it without loading the asset.
+type CoverImage struct {
+	AssetID      string `bson:"assetId" json:"assetId"`
+	URL          string `bson:"url" json:"url"`
+	ThumbnailURL string `bson:"thumbnailUrl" json:"thumbnailUrl"`
+}
+
+/*
+SlugDB reserves a slug for an article.
+Every slug an article ever had is kept, so links using an old title keep working.
+*/
+type SlugDB struct {
+	CreatedAt time.Time          `bson:"createdAt"`
+	Slug      string             `bson:"_id"`
+	ArticleID primitive.ObjectID `bson:"articleId"`
+}
+
+// AssetDB describes an uploaded file kept in the blob store.
+type AssetDB struct {
+	CreatedAt    time.Time          `bson:"createdAt"`
+	Filename     string             `bson:"filename"`
+	ContentType  string             `bson:"contentType"`
+	Key          string             `bson:"key"`
+	ThumbnailKey string             `bson:"thumbnailKey,omitempty"`
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	Size         int64              `bson:"size"`
+	OwnerID      int                `bson:"ownerId"`
+	Width        int                `bson:"width,omitempty"`
+	Height       int                `bson:"height,omitempty"`
+}
+
+// RevisionDB is an immutable snapshot of an article taken on every edit.
+type RevisionDB struct {
+	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+	Title         string             `bson:"title" json:"title"`
+	Content       string             `bson:"content" json:"content,omitempty"`
+	ContentFormat string             `bson:"contentFormat" json:"contentFormat"`
+	Category      string             `bson:"category" json:"category"`
+	AuthorName    string             `bson:"authorName" json:"authorName"`
+	Summary       string             `bson:"summary" json:"summary"`
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	ArticleID     primitive.ObjectID `bson:"articleId" json:"articleId"`
+	Number        int                `bson:"number" json:"number"`
+	AuthorID      int                `bson:"authorId" json:"authorId"`
+	RestoredFrom  int                `bson:"restoredFrom,omitempty" json:"restoredFrom,omitempty"`
+}
+
+/*
+IsPublished reports whether the article is visible to everyone.
+Articles created before the lifecycle existed have no status and count as published.
+*/
+func (a *ArticleDB) IsPublished() bool {
+	return a.Status == StatusPublished || a.Status == ""
 }
@@ -2,16 +2,19 @@ package mongo
 
 import (
 	"blog-service/internal/db/mongo/models"
+	"blog-service/internal/metrics"
 	"context"
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 const (
@@ -20,23 +23,36 @@ const (
 )
 
 const (
-	articleCollection = "articles"
+	articleCollection  = "articles"
+	revisionCollection = "article_revisions"
+	assetCollection    = "assets"
+	slugCollection     = "article_slugs"
+	// migrationCollection records the document migrations applied to the database
+	migrationCollection = "schema_migrations"
 )
 
+/*
+publishedFilter matches articles visible to everyone.
+Documents created before the lifecycle existed have no status field,
+which the null entry in $in matches as well.
+*/
+func publishedFilter() bson.E {
+	return bson.E{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{models.StatusPublished, nil}}}}
+}
+
 type Client struct {
 	Client *mongo.Client
 	DB     *mongo.Database
 }
 
-func NewMongoClient() (*Client, error) {
-	mongoURI := os.Getenv("MONGO_URI")
-	mongoDB := os.Getenv("MONGO_DB")
-
-	if mongoURI == "" || mongoDB == "" {
-		return nil, fmt.Errorf("lipsește una sau mai multe variabile de mediu necesare: MONGO_URI, MONGO_DB")
-	}
+// Config locates the MongoDB database of the service.
+type Config struct {
+	URI      string
+	Database string
+}
 
-	clientOptions := options.Client().ApplyURI(mongoURI)
+func NewMongoClient(cfg Config) (*Client, error) {
+	clientOptions := options.Client().ApplyURI(cfg.URI).SetMonitor(combineMonitors(metrics.MongoMonitor(), otelmongo.NewMonitor()))
 
 	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
 	defer cancel()
@@ -50,12 +66,14 @@ func NewMongoClient() (*Client, error) {
 		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
 	}
 
-	log.Println("Successfully connected to MongoDB!")
+	slog.Info("Successfully connected to MongoDB")
 
-	return &Client{
+	c := &Client{
 		Client: client,
-		DB:     client.Database(mongoDB),
-	}, nil
+		DB:     client.Database(cfg.Database),
+	}
+
+	return c, nil
 }
 
 func (c *Client) FindArticleByID(ctx context.Context, articleID *primitive.ObjectID) (*models.ArticleDB, error) {
@@ -71,6 +89,23 @@ func (c *Client) FindArticleByID(ctx context.Context, articleID *primitive.Objec
 	return &article, nil
 }
 
+// ArticleExists tells whether an article is stored, whatever its status. An invalid id matches none.
+func (c *Client) ArticleExists(ctx context.Context, articleID string) (bool, error) {
+	articleOID, err := primitive.ObjectIDFromHex(articleID)
+	if err != nil {
+		return false, nil
+	}
+
+	collection := c.DB.Collection(articleCollection)
+
+	count, err := collection.CountDocuments(ctx, bson.D{{Key: "_id", Value: articleOID}}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
 func (c *Client) InsertArticle(ctx context.Context, article *models.ArticleDB) (*mongo.InsertOneResult, error) {
 	collection := c.DB.Collection(articleCollection)
 
@@ -82,7 +117,12 @@ func (c *Client) InsertArticle(ctx context.Context, article *models.ArticleDB) (
 	return result, nil
 }
 
-func (c *Client) UpdateArticle(ctx context.Context, articleID *primitive.ObjectID, modifiedArticle *models.ArticleDB) (*mongo.UpdateResult, error) {
+/*
+UpdateArticle writes the edited fields of an article.
+events are added to its pending events by the same update, they are only kept if the edit is.
+*/
+func (c *Client) UpdateArticle(ctx context.Context, articleID *primitive.ObjectID, modifiedArticle *models.ArticleDB,
+	events ...models.ArticleEventDB) (*mongo.UpdateResult, error) {
 	if modifiedArticle.Content == "" || modifiedArticle.Category == "" ||
 		modifiedArticle.Title == "" || modifiedArticle.PublisherID == 0 {
 		return nil, fmt.Errorf("missing one or more fields in the modified article")
@@ -95,11 +135,21 @@ func (c *Client) UpdateArticle(ctx context.Context, articleID *primitive.ObjectI
 	filter := bson.D{{Key: "_id", Value: articleID}}
 	update := bson.D{{Key: "$set", Value: bson.D{
 		{Key: "content", Value: modifiedArticle.Content},
+		{Key: "contentFormat", Value: modifiedArticle.ContentFormat},
+		{Key: "contentHtml", Value: modifiedArticle.ContentHTML},
+		{Key: "excerpt", Value: modifiedArticle.Excerpt},
+		{Key: "renderVersion", Value: modifiedArticle.RenderVersion},
 		{Key: "category", Value: modifiedArticle.Category},
 		{Key: "title", Value: modifiedArticle.Title},
-		{Key: "publisher_id", Value: modifiedArticle.PublisherID},
-		{Key: "publisher_name", Value: modifiedArticle.PublisherName},
+		{Key: "slug", Value: modifiedArticle.Slug},
+		{Key: "slugBase", Value: modifiedArticle.SlugBase},
+		{Key: "publisherId", Value: modifiedArticle.PublisherID},
+		{Key: "publisherName", Value: modifiedArticle.PublisherName},
+		{Key: "updatedAt", Value: modifiedArticle.UpdatedAt},
+		{Key: "coverImage", Value: modifiedArticle.CoverImage},
+		{Key: "assetIds", Value: modifiedArticle.AssetIDs},
 	}}}
+	update = pushEvents(update, events)
 
 	collection := c.DB.Collection(articleCollection)
 
@@ -111,6 +161,29 @@ func (c *Client) UpdateArticle(ctx context.Context, articleID *primitive.ObjectI
 	return res, nil
 }
 
+// UpdateArticleRender replaces the cached HTML and excerpt of an article.
+func (c *Client) UpdateArticleRender(ctx context.Context, articleID *primitive.ObjectID, contentHTML, excerpt string, version int) error {
+	if articleID == nil {
+		return fmt.Errorf("missing article id")
+	}
+
+	filter := bson.D{{Key: "_id", Value: articleID}}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "contentHtml", Value: contentHTML},
+		{Key: "excerpt", Value: excerpt},
+		{Key: "renderVersion", Value: version},
+	}}}
+
+	collection := c.DB.Collection(articleCollection)
+
+	_, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (c *Client) DeleteArticle(ctx context.Context, articleID *primitive.ObjectID) error {
 	filter := bson.D{{Key: "_id", Value: articleID}}
 
@@ -124,18 +197,50 @@ func (c *Client) DeleteArticle(ctx context.Context, articleID *primitive.ObjectI
 	return nil
 }
 
+// combineMonitors forwards the command events to every monitor, the driver only accepts one.
+func combineMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m.Started != nil {
+					m.Started(ctx, e)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m.Succeeded != nil {
+					m.Succeeded(ctx, e)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m.Failed != nil {
+					m.Failed(ctx, e)
+				}
+			}
+		},
+	}
+}
+
 func (c *Client) Close(ctx context.Context) error {
 	if c.Client == nil {
 		return nil
 	}
-	log.Println("Closing MongoDB connection.")
+	slog.Info("Closing MongoDB connection")
 	return c.Client.Disconnect(ctx)
 }
 
-// FindArticlesByPublisherID retrieves all articles published by a specific user.
+// Ping checks that the primary of the deployment can be reached.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.Client.Ping(ctx, nil)
+}
+
+// FindArticlesByPublisherID retrieves all published articles of a specific user.
 func (c *Client) FindArticlesByPublisherID(ctx context.Context, publisherID int) ([]*models.ArticleDB, error) {
 	collection := c.DB.Collection(articleCollection)
-	filter := bson.D{{Key: "publisherId", Value: publisherID}}
+	filter := bson.D{{Key: "publisherId", Value: publisherID}, publishedFilter()}
 
 	cursor, err := collection.Find(ctx, filter)
 	if err != nil {
@@ -152,7 +257,7 @@ func (c *Client) FindArticlesByPublisherID(ctx context.Context, publisherID int)
 }
 
 func (c *Client) GetArticlesByEngagement(page, limit int64) ([]models.ArticleDB, error) {
-	collection := c.DB.Collection("articles")
+	collection := c.DB.Collection(articleCollection)
 	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
 	defer cancel()
 
@@ -161,12 +266,12 @@ func (c *Client) GetArticlesByEngagement(page, limit int64) ([]models.ArticleDB,
 		skip = 0
 	}
 
-	log.Printf("Page: %d, Limit: %d, Skip: %d", page, limit, skip)
+	slog.DebugContext(ctx, "Listing articles", "page", page, "limit", limit, "skip", skip)
 
 	sort := bson.D{{Key: "engagement", Value: -1}}
 	findOptions := options.Find().SetSort(sort).SetLimit(limit).SetSkip(skip)
 
-	cursor, err := collection.Find(ctx, bson.D{}, findOptions)
+	cursor, err := collection.Find(ctx, bson.D{publishedFilter()}, findOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find articles: %w", err)
 	}
@@ -183,3 +288,119 @@ func (c *Client) GetArticlesByEngagement(page, limit int64) ([]models.ArticleDB,
 
 	return articles, nil
 }
+
+/*
+SetEngagement stores the engagement of an article, the number of comments and likes it received,
+unless a count taken after countedAt is stored already. Setting a count is idempotent, so an
+event handled twice does not count twice. An article that no longer exists is ignored.
+*/
+func (c *Client) SetEngagement(ctx context.Context, articleID string, engagement int, countedAt int64) error {
+	articleOID, err := primitive.ObjectIDFromHex(articleID)
+	if err != nil {
+		return fmt.Errorf("invalid article id %q: %w", articleID, err)
+	}
+
+	collection := c.DB.Collection(articleCollection)
+	filter := bson.D{
+		{Key: "_id", Value: articleOID},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "engagementCountedAt", Value: bson.D{{Key: "$exists", Value: false}}}},
+			bson.D{{Key: "engagementCountedAt", Value: bson.D{{Key: "$lt", Value: countedAt}}}},
+		}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "engagement", Value: engagement},
+		{Key: "engagementCountedAt", Value: countedAt},
+	}}}
+
+	if _, err := collection.UpdateOne(ctx, filter, update); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindArticlesByStatus retrieves the articles of a publisher that are in one of the given states.
+func (c *Client) FindArticlesByStatus(ctx context.Context, publisherID int, statuses []string) ([]*models.ArticleDB, error) {
+	collection := c.DB.Collection(articleCollection)
+	filter := bson.D{
+		{Key: "publisherId", Value: publisherID},
+		{Key: "status", Value: bson.D{{Key: "$in", Value: statuses}}},
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute find command: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	articles := []*models.ArticleDB{}
+	if err = cursor.All(ctx, &articles); err != nil {
+		return nil, fmt.Errorf("failed to decode articles from cursor: %w", err)
+	}
+
+	return articles, nil
+}
+
+// UpdateArticleStatus moves an article to a new lifecycle state, with the events it causes.
+func (c *Client) UpdateArticleStatus(ctx context.Context, articleID *primitive.ObjectID, status string, publishAt time.Time,
+	events ...models.ArticleEventDB) (*mongo.UpdateResult, error) {
+	if articleID == nil {
+		return nil, fmt.Errorf("missing article id")
+	}
+
+	filter := bson.D{{Key: "_id", Value: articleID}}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: status},
+		{Key: "publishAt", Value: publishAt},
+	}}}
+	update = pushEvents(update, events)
+
+	collection := c.DB.Collection(articleCollection)
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+/*
+PublishDueArticles publishes every scheduled article whose publish time has passed.
+Articles are published one at a time and the update only matches articles that are still
+scheduled, so replicas running it concurrently never publish the same article twice.
+Each article gets an event of eventType in the same update.
+@returns
+[]primitive.ObjectID - the articles that were published, also when an error stopped the run.
+error - for checking the successful execution of the function.
+*/
+func (c *Client) PublishDueArticles(ctx context.Context, now time.Time, eventType string) ([]primitive.ObjectID, error) {
+	filter := bson.D{
+		{Key: "status", Value: models.StatusScheduled},
+		{Key: "publishAt", Value: bson.D{{Key: "$lte", Value: now}}},
+	}
+	opts := options.FindOneAndUpdate().SetProjection(bson.D{{Key: "_id", Value: 1}})
+
+	collection := c.DB.Collection(articleCollection)
+
+	published := []primitive.ObjectID{}
+	for {
+		update := bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: models.StatusPublished},
+		}}}
+		update = pushEvents(update, []models.ArticleEventDB{models.NewArticleEvent(eventType, 0)})
+
+		var article models.ArticleDB
+		err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&article)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return published, nil
+		}
+		if err != nil {
+			return published, fmt.Errorf("failed to publish scheduled articles: %w", err)
+		}
+
+		published = append(published, article.ID)
+	}
+}
@@ -3,7 +3,9 @@ package mongo
 import (
 	"blog-service/internal/db/mongo/models"
 	"blog-service/internal/db/testutil"
+	"blog-service/internal/slug"
 	"context"
+	"errors"
 	"log"
 	"math/rand"
 	"os"
@@ -12,6 +14,7 @@ import (
 	"time"
 
 	"github.com/testcontainers/testcontainers-go"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -27,11 +30,15 @@ func setupMongoContainer(ctx context.Context) (testcontainers.Container, *Client
 	os.Setenv("MONGO_PORT", port) // set port env variable with obtained port
 
 	// Instantiate new mongo client and defer disconnection
-	mongoClient, err := NewMongoClient()
+	mongoClient, err := NewMongoClient(Config{URI: os.Getenv("MONGO_URI"), Database: os.Getenv("MONGO_DB")})
 	if err != nil {
 		log.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
 
+	if _, err := mongoClient.Migrate(ctx); err != nil {
+		log.Fatalf("Failed to migrate MongoDB: %v", err)
+	}
+
 	return mongoContainer, mongoClient, nil
 }
 
@@ -124,6 +131,35 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+func TestSetEngagementKeepsTheNewestCount(t *testing.T) {
+	ctx := context.Background()
+
+	res, err := mongoClient.InsertArticle(ctx, generateTestArticle())
+	if err != nil {
+		t.Fatalf("Failed to insert article: %s", err)
+	}
+	articleID := res.InsertedID.(primitive.ObjectID)
+
+	if err := mongoClient.SetEngagement(ctx, articleID.Hex(), 5, 200); err != nil {
+		t.Fatalf("Failed to set engagement: %s", err)
+	}
+	// Counted before the stored count, by a relay that was slower to write it
+	if err := mongoClient.SetEngagement(ctx, articleID.Hex(), 3, 100); err != nil {
+		t.Fatalf("Failed to set engagement: %s", err)
+	}
+
+	var stored struct {
+		Engagement int `bson:"engagement"`
+	}
+	err = mongoClient.DB.Collection(articleCollection).FindOne(ctx, bson.D{{Key: "_id", Value: articleID}}).Decode(&stored)
+	if err != nil {
+		t.Fatalf("Failed to read engagement: %s", err)
+	}
+	if stored.Engagement != 5 {
+		t.Errorf("Engagement = %d, want the newest count 5", stored.Engagement)
+	}
+}
+
 func TestUpdate(t *testing.T) {
 	ctx := context.Background()
 
@@ -148,3 +184,349 @@ func TestUpdate(t *testing.T) {
 		t.Errorf("Multiple documents modified, but only one ID supplied\n")
 	}
 }
+
+func TestFindArticlesByStatus(t *testing.T) {
+	ctx := context.Background()
+
+	draft := generateTestArticle()
+	draft.PublisherID, draft.Status, draft.CreatedAt = 1001, models.StatusDraft, time.Now().Add(-time.Hour)
+	scheduled := generateTestArticle()
+	scheduled.PublisherID, scheduled.Status, scheduled.CreatedAt = 1001, models.StatusScheduled, time.Now()
+	published := generateTestArticle()
+	published.PublisherID, published.Status = 1001, models.StatusPublished
+	otherDraft := generateTestArticle()
+	otherDraft.PublisherID, otherDraft.Status = 1002, models.StatusDraft
+
+	for _, article := range []*models.ArticleDB{draft, scheduled, published, otherDraft} {
+		if _, err := mongoClient.InsertArticle(ctx, article); err != nil {
+			t.Fatalf("Failed to insert article: %s", err)
+		}
+	}
+
+	articles, err := mongoClient.FindArticlesByStatus(ctx, 1001, []string{models.StatusDraft, models.StatusScheduled})
+	if err != nil {
+		t.Fatalf("Failed to find drafts: %s", err)
+	}
+
+	if len(articles) != 2 || articles[0].Title != scheduled.Title || articles[1].Title != draft.Title {
+		t.Errorf("Want the scheduled article then the draft of the publisher, got %+v", articles)
+	}
+}
+
+func TestRevisionFlow(t *testing.T) {
+	ctx := context.Background()
+
+	res, err := mongoClient.InsertArticle(ctx, generateTestArticle())
+	if err != nil {
+		t.Fatalf("Failed to insert article: %s", err)
+	}
+	articleID := res.InsertedID.(primitive.ObjectID)
+
+	latest, err := mongoClient.LatestRevisionNumber(ctx, &articleID)
+	if err != nil || latest != 0 {
+		t.Fatalf("New article has revision %d (%v), want none", latest, err)
+	}
+
+	for i, content := range []string{"first", "second", "third"} {
+		number, err := mongoClient.InsertRevision(ctx, &models.RevisionDB{ArticleID: articleID, Content: content, Summary: content})
+		if err != nil {
+			t.Fatalf("Failed to insert revision: %s", err)
+		}
+		if number != i+1 {
+			t.Errorf("Revision %q got number %d, want %d", content, number, i+1)
+		}
+	}
+
+	// Rolling back the newest revision hands its number out again
+	third, err := mongoClient.FindRevision(ctx, &articleID, 3)
+	if err != nil || third.Content != "third" {
+		t.Fatalf("Failed to find revision 3: %v %+v", err, third)
+	}
+	if err := mongoClient.DeleteRevision(ctx, &third.ID); err != nil {
+		t.Fatalf("Failed to delete revision: %s", err)
+	}
+	if _, err := mongoClient.FindRevision(ctx, &articleID, 3); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("Deleted revision found, err = %v", err)
+	}
+	if latest, _ := mongoClient.LatestRevisionNumber(ctx, &articleID); latest != 2 {
+		t.Errorf("Latest revision is %d after the rollback, want 2", latest)
+	}
+
+	revisions, err := mongoClient.FindRevisions(ctx, &articleID)
+	if err != nil {
+		t.Fatalf("Failed to list revisions: %s", err)
+	}
+	if len(revisions) != 2 || revisions[0].Number != 2 || revisions[1].Summary != "first" || revisions[0].Content != "" {
+		t.Errorf("Want revisions 2 and 1 without their content, got %+v", revisions)
+	}
+
+	if err := mongoClient.DeleteRevisions(ctx, &articleID); err != nil {
+		t.Fatalf("Failed to delete the history: %s", err)
+	}
+	if revisions, _ := mongoClient.FindRevisions(ctx, &articleID); len(revisions) != 0 {
+		t.Errorf("History not deleted: %+v", revisions)
+	}
+}
+
+func TestPublishDueArticles(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	due := generateTestArticle()
+	due.Status, due.PublishAt = models.StatusScheduled, now.Add(-time.Minute)
+	later := generateTestArticle()
+	later.Status, later.PublishAt = models.StatusScheduled, now.Add(time.Hour)
+
+	dueRes, err := mongoClient.InsertArticle(ctx, due)
+	if err != nil {
+		t.Fatalf("Failed to insert article: %s", err)
+	}
+	if _, err := mongoClient.InsertArticle(ctx, later); err != nil {
+		t.Fatalf("Failed to insert article: %s", err)
+	}
+
+	published, err := mongoClient.PublishDueArticles(ctx, now, "article.published")
+	if err != nil {
+		t.Fatalf("Failed to publish due articles: %s", err)
+	}
+	if len(published) != 1 || published[0] != dueRes.InsertedID {
+		t.Errorf("Published %v, want only the due article %v", published, dueRes.InsertedID)
+	}
+
+	if again, _ := mongoClient.PublishDueArticles(ctx, now, "article.published"); len(again) != 0 {
+		t.Errorf("Published %v again", again)
+	}
+
+	article, err := mongoClient.FindArticleByID(ctx, &published[0])
+	if err != nil {
+		t.Fatalf("Failed to retrieve published article: %s", err)
+	}
+	if len(article.PendingEvents) != 1 || article.PendingEvents[0].Type != "article.published" {
+		t.Errorf("Pending events = %v, want article.published written with the status", article.PendingEvents)
+	}
+}
+
+func TestArticleEventsStayPendingUntilCleared(t *testing.T) {
+	ctx := context.Background()
+
+	article := generateTestArticle()
+	article.PendingEvents = []models.ArticleEventDB{models.NewArticleEvent("article.published", 0)}
+
+	res, err := mongoClient.InsertArticle(ctx, article)
+	if err != nil {
+		t.Fatalf("Failed to insert article: %s", err)
+	}
+	id := res.InsertedID.(primitive.ObjectID)
+
+	updated := models.NewArticleEvent("article.updated", 2)
+	if _, err := mongoClient.UpdateArticle(ctx, &id, article, updated); err != nil {
+		t.Fatalf("Failed to update article: %s", err)
+	}
+
+	pending := func() []models.ArticleEventDB {
+		articles, err := mongoClient.FindPendingArticleEvents(ctx, 1000)
+		if err != nil {
+			t.Fatalf("Failed to find pending events: %s", err)
+		}
+		for _, a := range articles {
+			if a.ID == id {
+				return a.PendingEvents
+			}
+		}
+		return nil
+	}
+
+	events := pending()
+	if len(events) != 2 || events[1].Key != updated.Key || events[1].Revision != 2 {
+		t.Fatalf("Pending events = %v, want the inserted and the updated one", events)
+	}
+
+	if err := mongoClient.ClearArticleEvents(ctx, &id, []string{events[0].Key, events[1].Key}); err != nil {
+		t.Fatalf("Failed to clear events: %s", err)
+	}
+	if events := pending(); len(events) != 0 {
+		t.Errorf("Cleared events are still pending: %v", events)
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+
+	// TestMain already migrated, nothing is left to apply
+	count, err := mongoClient.Migrate(ctx)
+	if err != nil {
+		t.Fatalf("Failed to migrate again: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Applied %d document migrations again, want 0", count)
+	}
+}
+
+func TestArticleValidatorRejectsWrongShape(t *testing.T) {
+	ctx := context.Background()
+	collection := mongoClient.DB.Collection(articleCollection)
+
+	_, err := collection.InsertOne(ctx, bson.D{
+		{Key: "createdAt", Value: time.Now()},
+		{Key: "title", Value: "Title"},
+		{Key: "content", Value: "Content"},
+		{Key: "category", Value: "tv"},
+		{Key: "publisherName", Value: "someone"},
+		{Key: "publisherId", Value: "not a number"},
+	})
+	if err == nil {
+		t.Error("Expected an article with a string publisherId to be rejected")
+	}
+
+	_, err = collection.InsertOne(ctx, bson.D{{Key: "title", Value: "Title"}})
+	if err == nil {
+		t.Error("Expected an article missing its required fields to be rejected")
+	}
+}
+
+func TestBackfillSlugs(t *testing.T) {
+	ctx := context.Background()
+	title := "Backfill " + testutil.GenerateRandomString()
+
+	taken := generateTestArticle()
+	taken.ID, taken.Title = primitive.NewObjectID(), title
+	taken.Slug = slug.Make(title)
+	if ok, err := mongoClient.ReserveSlug(ctx, taken.Slug, &taken.ID); !ok || err != nil {
+		t.Fatalf("Failed to reserve slug: %v", err)
+	}
+
+	var legacy []primitive.ObjectID
+	for _, article := range []*models.ArticleDB{taken, generateTestArticle(), generateTestArticle()} {
+		if article != taken {
+			article.Title = title
+		}
+		res, err := mongoClient.InsertArticle(ctx, article)
+		if err != nil {
+			t.Fatalf("Failed to insert article: %s", err)
+		}
+		if article != taken {
+			legacy = append(legacy, res.InsertedID.(primitive.ObjectID))
+		}
+	}
+
+	// Twice, like replicas starting together
+	for range 2 {
+		if err := backfillSlugs(ctx, mongoClient.DB); err != nil {
+			t.Fatalf("Failed to backfill slugs: %s", err)
+		}
+	}
+
+	want := map[string]bool{slug.WithSuffix(taken.Slug, 2): true, slug.WithSuffix(taken.Slug, 3): true}
+	for _, id := range legacy {
+		article, err := mongoClient.FindArticleByID(ctx, &id)
+		if err != nil {
+			t.Fatalf("Failed to find article: %s", err)
+		}
+		if !want[article.Slug] {
+			t.Errorf("Article got slug %q, want one of %v", article.Slug, want)
+		}
+		delete(want, article.Slug)
+
+		owner, err := mongoClient.FindSlugOwner(ctx, article.Slug)
+		if err != nil || owner != id {
+			t.Errorf("Slug %q is reserved for %s (%v), want %s", article.Slug, owner.Hex(), err, id.Hex())
+		}
+	}
+}
+
+func TestBackfillPublishAt(t *testing.T) {
+	ctx := context.Background()
+	createdAt := time.Now().Add(-24 * time.Hour).Truncate(time.Millisecond)
+
+	legacy := generateTestArticle()
+	legacy.CreatedAt = createdAt
+	draft := generateTestArticle()
+	draft.Status, draft.CreatedAt = models.StatusDraft, createdAt
+
+	var ids []primitive.ObjectID
+	for _, article := range []*models.ArticleDB{legacy, draft} {
+		res, err := mongoClient.InsertArticle(ctx, article)
+		if err != nil {
+			t.Fatalf("Failed to insert article: %s", err)
+		}
+		ids = append(ids, res.InsertedID.(primitive.ObjectID))
+	}
+
+	if err := backfillPublishAt(ctx, mongoClient.DB); err != nil {
+		t.Fatalf("Failed to backfill publishAt: %s", err)
+	}
+
+	backfilled, err := mongoClient.FindArticleByID(ctx, &ids[0])
+	if err != nil || !backfilled.PublishAt.Equal(createdAt) {
+		t.Errorf("Legacy article published at %v (%v), want its creation %v", backfilled.PublishAt, err, createdAt)
+	}
+
+	unchanged, err := mongoClient.FindArticleByID(ctx, &ids[1])
+	if err != nil || !unchanged.PublishAt.IsZero() {
+		t.Errorf("Draft got published at %v (%v), want none", unchanged.PublishAt, err)
+	}
+}
+
+func TestDeleteSlugs(t *testing.T) {
+	ctx := context.Background()
+	articleID := primitive.NewObjectID()
+	slugs := []string{"deleted-" + articleID.Hex(), "renamed-" + articleID.Hex()}
+
+	for _, s := range slugs {
+		if ok, err := mongoClient.ReserveSlug(ctx, s, &articleID); !ok || err != nil {
+			t.Fatalf("Failed to reserve slug %q: %v", s, err)
+		}
+	}
+
+	if err := mongoClient.DeleteSlugs(ctx, &articleID); err != nil {
+		t.Fatalf("Failed to delete slugs: %s", err)
+	}
+
+	// Released slugs can be taken by another article
+	otherID := primitive.NewObjectID()
+	for _, s := range slugs {
+		if ok, err := mongoClient.ReserveSlug(ctx, s, &otherID); !ok || err != nil {
+			t.Errorf("Slug %q was not released: %v", s, err)
+		}
+	}
+}
+
+func TestDeleteAssetsOfOwner(t *testing.T) {
+	ctx := context.Background()
+	ownerID := int(time.Now().UnixNano()%1_000_000) + 1000
+
+	for _, owner := range []int{ownerID, ownerID, ownerID + 1} {
+		if _, err := mongoClient.InsertAsset(ctx, &models.AssetDB{Key: testutil.GenerateRandomString(), OwnerID: owner}); err != nil {
+			t.Fatalf("Failed to insert asset: %s", err)
+		}
+	}
+
+	assets, err := mongoClient.FindAssetsByOwner(ctx, ownerID)
+	if err != nil {
+		t.Fatalf("Failed to find assets: %s", err)
+	}
+	if len(assets) != 2 {
+		t.Fatalf("Found %d assets of the owner, want 2", len(assets))
+	}
+
+	for _, asset := range assets {
+		if err := mongoClient.DeleteAsset(ctx, &asset.ID); err != nil {
+			t.Fatalf("Failed to delete asset: %s", err)
+		}
+	}
+
+	if assets, _ := mongoClient.FindAssetsByOwner(ctx, ownerID); len(assets) != 0 {
+		t.Errorf("Found %d assets after deleting them", len(assets))
+	}
+	if others, _ := mongoClient.FindAssetsByOwner(ctx, ownerID+1); len(others) != 1 {
+		t.Errorf("Found %d assets of another owner, want 1", len(others))
+	}
+}
+
+func TestDocumentMigrationsAreNumberedInOrder(t *testing.T) {
+	for i, migration := range documentMigrations() {
+		if migration.Version != i+1 {
+			t.Errorf("Document migration %s has version %d, want %d", migration.Name, migration.Version, i+1)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package mongo
+
+import (
+	"blog-service/internal/db/mongo/models"
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Number of attempts at claiming a revision number before giving up.
+const revisionInsertAttempts = 3
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// LatestRevisionNumber returns the number of the newest revision of an article, 0 when it has none.
+func (c *Client) LatestRevisionNumber(ctx context.Context, articleID *primitive.ObjectID) (int, error) {
+	collection := c.DB.Collection(revisionCollection)
+
+	var latest models.RevisionDB
+	findOptions := options.FindOne().
+		SetSort(bson.D{{Key: "number", Value: -1}}).
+		SetProjection(bson.D{{Key: "number", Value: 1}})
+
+	err := collection.FindOne(ctx, bson.D{{Key: "articleId", Value: articleID}}, findOptions).Decode(&latest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return latest.Number, nil
+}
+
+/*
+InsertRevision stores a new revision of an article and assigns it the next revision number.
+Revisions are never modified once written.
+@returns
+int - the number assigned to the revision.
+error - for checking the successful execution of the function.
+*/
+func (c *Client) InsertRevision(ctx context.Context, revision *models.RevisionDB) (int, error) {
+	collection := c.DB.Collection(revisionCollection)
+
+	for range revisionInsertAttempts {
+		latest, err := c.LatestRevisionNumber(ctx, &revision.ArticleID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to find latest revision: %w", err)
+		}
+
+		revision.Number = latest + 1
+		revision.ID = primitive.NilObjectID
+
+		res, err := collection.InsertOne(ctx, revision)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert revision: %w", err)
+		}
+
+		if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+			revision.ID = oid
+		}
+
+		return revision.Number, nil
+	}
+
+	return 0, fmt.Errorf("failed to claim a revision number after %d attempts", revisionInsertAttempts)
+}
+
+// DeleteRevision removes a revision, used to roll back when the matching article update fails.
+func (c *Client) DeleteRevision(ctx context.Context, revisionID *primitive.ObjectID) error {
+	collection := c.DB.Collection(revisionCollection)
+
+	_, err := collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: revisionID}})
+
+	return err
+}
+
+// FindRevisions lists the revisions of an article, newest first, without their content.
+func (c *Client) FindRevisions(ctx context.Context, articleID *primitive.ObjectID) ([]models.RevisionDB, error) {
+	collection := c.DB.Collection(revisionCollection)
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "number", Value: -1}}).
+		SetProjection(bson.D{{Key: "content", Value: 0}})
+
+	cursor, err := collection.Find(ctx, bson.D{{Key: "articleId", Value: articleID}}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute find command: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []models.RevisionDB{}
+	if err = cursor.All(ctx, &revisions); err != nil {
+		return nil, fmt.Errorf("failed to decode revisions from cursor: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// FindRevision retrieves a single revision of an article by its number.
+func (c *Client) FindRevision(ctx context.Context, articleID *primitive.ObjectID, number int) (*models.RevisionDB, error) {
+	collection := c.DB.Collection(revisionCollection)
+
+	filter := bson.D{{Key: "articleId", Value: articleID}, {Key: "number", Value: number}}
+
+	var revision models.RevisionDB
+	err := collection.FindOne(ctx, filter).Decode(&revision)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
@@ -0,0 +1,190 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Server error codes handled while bootstrapping.
+const (
+	codeNamespaceExists = 48
+)
+
+// collectionIndexes lists the indexes declared on one collection.
+type collectionIndexes struct {
+	collection string
+	models     []mongo.IndexModel
+}
+
+/*
+indexes declares every index of the database.
+Creating an index that already exists with the same keys and options does nothing, so they are
+created on every migration. Indexes keep their generated names, changing the options of one fails
+until it is dropped, which a document migration can do.
+*/
+func indexes() []collectionIndexes {
+	return []collectionIndexes{
+		{collection: articleCollection, models: []mongo.IndexModel{
+			// Publisher pages and dashboards, newest first
+			{Keys: bson.D{{Key: "publisherId", Value: 1}, {Key: "createdAt", Value: -1}}},
+			{Keys: bson.D{{Key: "createdAt", Value: -1}}},
+			// Sort of the home page, maintained by the outbox handlers of comments and likes
+			{Keys: bson.D{{Key: "engagement", Value: -1}}},
+			// Articles created before slugs existed have none, so the index is partial.
+			// Reserved slugs are unique through their _id.
+			{
+				Keys: bson.D{{Key: "slug", Value: 1}},
+				Options: options.Index().SetUnique(true).SetPartialFilterExpression(
+					bson.D{{Key: "slug", Value: bson.D{{Key: "$type", Value: "string"}}}},
+				),
+			},
+			// Each branch of the feed $or is served by its own index already sorted by
+			// publish time, so Mongo merges the branches instead of sorting in memory.
+			{Keys: bson.D{{Key: "publisherId", Value: 1}, {Key: "publishAt", Value: -1}, {Key: "_id", Value: -1}}},
+			{Keys: bson.D{{Key: "category", Value: 1}, {Key: "publishAt", Value: -1}, {Key: "_id", Value: -1}}},
+			// Site-wide feed and its state, read on every feed request
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "publishAt", Value: -1}, {Key: "createdAt", Value: -1}}},
+			// Polled by the outbox relay, only the few articles with pending events are indexed
+			{
+				Keys: bson.D{{Key: "pendingEvents.key", Value: 1}},
+				Options: options.Index().SetPartialFilterExpression(
+					bson.D{{Key: "pendingEvents.key", Value: bson.D{{Key: "$exists", Value: true}}}},
+				),
+			},
+		}},
+		{collection: revisionCollection, models: []mongo.IndexModel{
+			// Revision numbers are assigned as max+1, the index turns concurrent edits
+			// that picked the same number into a duplicate key error that is retried.
+			{
+				Keys:    bson.D{{Key: "articleId", Value: 1}, {Key: "number", Value: -1}},
+				Options: options.Index().SetUnique(true),
+			},
+		}},
+		{collection: slugCollection, models: []mongo.IndexModel{
+			// Releasing the slugs of an article that failed to insert or got deleted
+			{Keys: bson.D{{Key: "articleId", Value: 1}}},
+		}},
+		{collection: assetCollection, models: []mongo.IndexModel{
+			// Purging the uploads of a deleted user
+			{Keys: bson.D{{Key: "ownerId", Value: 1}}},
+		}},
+	}
+}
+
+/*
+articleValidator is the $jsonSchema of the articles collection, the shape of models.ArticleDB.
+Only the fields every article has always had are required, the others are checked when present.
+Numbers are written as int32 or int64 depending on their size.
+*/
+func articleValidator() bson.M {
+	integer := bson.M{"bsonType": bson.A{"int", "long"}}
+	str := bson.M{"bsonType": "string"}
+	date := bson.M{"bsonType": "date"}
+
+	return bson.M{"$jsonSchema": bson.M{
+		"bsonType": "object",
+		"required": bson.A{"createdAt", "title", "content", "category", "publisherId", "publisherName"},
+		"properties": bson.M{
+			"_id":                 bson.M{"bsonType": "objectId"},
+			"createdAt":           date,
+			"updatedAt":           date,
+			"publishAt":           date,
+			"title":               str,
+			"slug":                str,
+			"slugBase":            str,
+			"content":             str,
+			"contentFormat":       str,
+			"contentHtml":         str,
+			"excerpt":             str,
+			"category":            str,
+			"publisherName":       str,
+			"status":              str,
+			"publisherId":         integer,
+			"renderVersion":       integer,
+			"engagement":          integer,
+			"engagementCountedAt": integer,
+			"assetIds":            bson.M{"bsonType": "array", "items": str},
+			"pendingEvents": bson.M{
+				"bsonType": "array",
+				"items": bson.M{
+					"bsonType": "object",
+					"required": bson.A{"key", "type", "createdAt"},
+					"properties": bson.M{
+						"key":       str,
+						"type":      str,
+						"createdAt": date,
+						"revision":  integer,
+					},
+				},
+			},
+			"coverImage": bson.M{
+				"bsonType": "object",
+				"required": bson.A{"assetId", "url"},
+				"properties": bson.M{
+					"assetId":      str,
+					"url":          str,
+					"thumbnailUrl": str,
+				},
+			},
+		},
+	}}
+}
+
+/*
+Migrate brings the database up to date: it creates the declared indexes, applies the pending
+document migrations, then installs the validator of the articles collection.
+Every step is idempotent, replicas starting together can run it at the same time.
+@returns
+int - how many document migrations were applied.
+*/
+func (c *Client) Migrate(ctx context.Context) (int, error) {
+	if err := c.ensureIndexes(ctx); err != nil {
+		return 0, err
+	}
+
+	count, err := c.migrateDocuments(ctx, documentMigrations())
+	if err != nil {
+		return count, err
+	}
+
+	return count, c.ensureValidator(ctx, articleCollection, articleValidator())
+}
+
+func (c *Client) ensureIndexes(ctx context.Context) error {
+	for _, declared := range indexes() {
+		if _, err := c.DB.Collection(declared.collection).Indexes().CreateMany(ctx, declared.models); err != nil {
+			return fmt.Errorf("failed to create indexes of %s: %w", declared.collection, err)
+		}
+	}
+
+	return nil
+}
+
+/*
+ensureValidator installs validator on collection, creating the collection when it does not exist yet.
+The level is moderate: documents already breaking it can still be updated, only valid ones are kept valid.
+*/
+func (c *Client) ensureValidator(ctx context.Context, collection string, validator bson.M) error {
+	err := c.DB.CreateCollection(ctx, collection)
+	var commandErr mongo.CommandError
+	if err != nil && !(errors.As(err, &commandErr) && commandErr.Code == codeNamespaceExists) {
+		return fmt.Errorf("failed to create collection %s: %w", collection, err)
+	}
+
+	err = c.DB.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: "moderate"},
+		{Key: "validationAction", Value: "error"},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to set the validator of %s: %w", collection, err)
+	}
+
+	return nil
+}
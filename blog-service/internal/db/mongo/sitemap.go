@@ -0,0 +1,64 @@
+package mongo
+
+import (
+	"blog-service/internal/db/mongo/models"
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CountPublishedArticles returns how many articles are visible to everyone.
+func (c *Client) CountPublishedArticles(ctx context.Context) (int64, error) {
+	collection := c.DB.Collection(articleCollection)
+
+	count, err := collection.CountDocuments(ctx, bson.D{publishedFilter()})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count articles: %w", err)
+	}
+
+	return count, nil
+}
+
+/*
+StreamPublishedArticles calls fn for every published article in _id order,
+decoding one document at a time instead of loading the whole result like cursor.All.
+Only the fields needed to link to an article are loaded.
+@params
+skip, limit - the window of articles to stream, limit 0 streams all of them.
+fn - called for every article, returning an error stops the iteration.
+*/
+func (c *Client) StreamPublishedArticles(ctx context.Context, skip, limit int64, fn func(*models.ArticleDB) error) error {
+	collection := c.DB.Collection(articleCollection)
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetSkip(skip).
+		SetLimit(limit).
+		SetProjection(bson.D{
+			{Key: "slug", Value: 1},
+			{Key: "createdAt", Value: 1},
+			{Key: "publishAt", Value: 1},
+			{Key: "updatedAt", Value: 1},
+		})
+
+	cursor, err := collection.Find(ctx, bson.D{publishedFilter()}, findOptions)
+	if err != nil {
+		return fmt.Errorf("failed to find articles: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var article models.ArticleDB
+		if err := cursor.Decode(&article); err != nil {
+			return fmt.Errorf("failed to decode article: %w", err)
+		}
+
+		if err := fn(&article); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
@@ -0,0 +1,98 @@
+package mongo
+
+import (
+	"blog-service/internal/db/mongo/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrSlugNotFound = errors.New("slug not found")
+
+/*
+ReserveSlug claims a slug for an article.
+@returns
+bool - true when the slug is now (or already was) reserved for the article,
+false when it belongs to another article.
+error - for checking the successful execution of the function.
+*/
+func (c *Client) ReserveSlug(ctx context.Context, slug string, articleID *primitive.ObjectID) (bool, error) {
+	return reserveSlug(ctx, c.DB, slug, articleID)
+}
+
+func reserveSlug(ctx context.Context, db *mongo.Database, slug string, articleID *primitive.ObjectID) (bool, error) {
+	collection := db.Collection(slugCollection)
+
+	_, err := collection.InsertOne(ctx, models.SlugDB{
+		CreatedAt: time.Now(),
+		Slug:      slug,
+		ArticleID: *articleID,
+	})
+	if err == nil {
+		return true, nil
+	}
+
+	if !mongo.IsDuplicateKeyError(err) {
+		return false, fmt.Errorf("failed to reserve slug: %w", err)
+	}
+
+	// An article getting back one of its own old slugs, e.g. after a title is reverted
+	owner, err := findSlugOwner(ctx, db, slug)
+	if err != nil {
+		return false, err
+	}
+
+	return owner == *articleID, nil
+}
+
+// FindSlugOwner returns the id of the article a slug is or was reserved for.
+func (c *Client) FindSlugOwner(ctx context.Context, slug string) (primitive.ObjectID, error) {
+	return findSlugOwner(ctx, c.DB, slug)
+}
+
+func findSlugOwner(ctx context.Context, db *mongo.Database, slug string) (primitive.ObjectID, error) {
+	collection := db.Collection(slugCollection)
+
+	var reserved models.SlugDB
+	err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: slug}}).Decode(&reserved)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return primitive.NilObjectID, ErrSlugNotFound
+	}
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return reserved.ArticleID, nil
+}
+
+// FindArticleBySlug retrieves the article whose current slug is the given one.
+func (c *Client) FindArticleBySlug(ctx context.Context, slug string) (*models.ArticleDB, error) {
+	var article models.ArticleDB
+	collection := c.DB.Collection(articleCollection)
+
+	err := collection.FindOne(ctx, bson.D{{Key: "slug", Value: slug}}).Decode(&article)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSlugNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &article, nil
+}
+
+// DeleteSlugs releases every slug reserved for an article.
+func (c *Client) DeleteSlugs(ctx context.Context, articleID *primitive.ObjectID) error {
+	collection := c.DB.Collection(slugCollection)
+
+	if _, err := collection.DeleteMany(ctx, bson.D{{Key: "articleId", Value: articleID}}); err != nil {
+		return fmt.Errorf("failed to delete slugs: %w", err)
+	}
+
+	return nil
+}
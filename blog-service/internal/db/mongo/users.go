@@ -0,0 +1,86 @@
+package mongo
+
+import (
+	"blog-service/internal/db/mongo/models"
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FindArticleIDsByPublisher returns the ids of every article of a publisher, whatever its status.
+func (c *Client) FindArticleIDsByPublisher(ctx context.Context, publisherID int) ([]primitive.ObjectID, error) {
+	collection := c.DB.Collection(articleCollection)
+	findOptions := options.Find().SetProjection(bson.D{{Key: "_id", Value: 1}})
+
+	cursor, err := collection.Find(ctx, bson.D{{Key: "publisherId", Value: publisherID}}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute find command: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	articles := []models.ArticleDB{}
+	if err = cursor.All(ctx, &articles); err != nil {
+		return nil, fmt.Errorf("failed to decode articles from cursor: %w", err)
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(articles))
+	for _, article := range articles {
+		ids = append(ids, article.ID)
+	}
+
+	return ids, nil
+}
+
+// DeleteRevisions removes the history of an article.
+func (c *Client) DeleteRevisions(ctx context.Context, articleID *primitive.ObjectID) error {
+	collection := c.DB.Collection(revisionCollection)
+
+	if _, err := collection.DeleteMany(ctx, bson.D{{Key: "articleId", Value: articleID}}); err != nil {
+		return fmt.Errorf("failed to delete revisions: %w", err)
+	}
+
+	return nil
+}
+
+/*
+AnonymisePublisher credits the articles, revisions and uploads of a user to replacementID and replacementName.
+Running it again changes nothing, the user no longer owns any of them.
+@returns
+int64 - how many articles were changed.
+*/
+func (c *Client) AnonymisePublisher(ctx context.Context, publisherID, replacementID int, replacementName string) (int64, error) {
+	res, err := c.DB.Collection(articleCollection).UpdateMany(ctx,
+		bson.D{{Key: "publisherId", Value: publisherID}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "publisherId", Value: replacementID},
+			{Key: "publisherName", Value: replacementName},
+		}}},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to anonymise articles: %w", err)
+	}
+
+	_, err = c.DB.Collection(revisionCollection).UpdateMany(ctx,
+		bson.D{{Key: "authorId", Value: publisherID}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "authorId", Value: replacementID},
+			{Key: "authorName", Value: replacementName},
+		}}},
+	)
+	if err != nil {
+		return res.ModifiedCount, fmt.Errorf("failed to anonymise revisions: %w", err)
+	}
+
+	_, err = c.DB.Collection(assetCollection).UpdateMany(ctx,
+		bson.D{{Key: "ownerId", Value: publisherID}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "ownerId", Value: replacementID}}}},
+	)
+	if err != nil {
+		return res.ModifiedCount, fmt.Errorf("failed to anonymise assets: %w", err)
+	}
+
+	return res.ModifiedCount, nil
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"blog-service/internal/db/postgres/models"
+	"context"
+	"fmt"
+)
+
+/*
+AddBookmark saves an article to the reading list of a user.
+@returns
+bool - false when the article was already bookmarked.
+error - for checking the execution of the query.
+*/
+func (db *Client) AddBookmark(ctx context.Context, userID int, articleID string) (bool, error) {
+	const query = `INSERT INTO bookmarks (UserID, ArticleID) VALUES($1, $2)
+	          ON CONFLICT DO NOTHING`
+
+	return db.execChanged(ctx, query, userID, articleID)
+}
+
+// RemoveBookmarks removes articles from the reading list of a user.
+func (db *Client) RemoveBookmarks(ctx context.Context, userID int, articleIDs []string) (bool, error) {
+	const query = `DELETE FROM bookmarks WHERE UserID = $1 AND ArticleID = ANY($2)`
+
+	return db.execChanged(ctx, query, userID, articleIDs)
+}
+
+// IsBookmarked reports whether the article is on the reading list of the user.
+func (db *Client) IsBookmarked(ctx context.Context, userID int, articleID string) (bool, error) {
+	const query = `SELECT EXISTS (SELECT 1 FROM bookmarks WHERE UserID = $1 AND ArticleID = $2)`
+
+	if db.ConnPool == nil {
+		return false, fmt.Errorf("unable to connect to database")
+	}
+
+	var bookmarked bool
+	if err := db.ConnPool.QueryRow(ctx, query, userID, articleID).Scan(&bookmarked); err != nil {
+		return false, err
+	}
+
+	return bookmarked, nil
+}
+
+/*
+GetBookmarks returns the reading list of a user, most recently saved first.
+@params
+beforeID - only bookmarks older than this one are returned, 0 starts from the newest.
+*/
+func (db *Client) GetBookmarks(ctx context.Context, userID, beforeID, limit int) ([]models.Bookmark, error) {
+	const query = `SELECT ID, UserID, ArticleID, CreatedAt FROM bookmarks
+	          WHERE UserID = $1 AND ($2 = 0 OR ID < $2)
+	          ORDER BY ID DESC LIMIT $3`
+
+	if db.ConnPool == nil {
+		return nil, fmt.Errorf("unable to connect to database")
+	}
+
+	rows, err := db.ConnPool.Query(ctx, query, userID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bookmarks := make([]models.Bookmark, 0, limit)
+	for rows.Next() {
+		bookmark := models.Bookmark{}
+		if err := rows.Scan(&bookmark.ID, &bookmark.UserID, &bookmark.ArticleID, &bookmark.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to retrieve row: %w", err)
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	return bookmarks, rows.Err()
+}
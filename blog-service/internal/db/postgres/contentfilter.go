@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"blog-service/internal/db/postgres/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+// HasRecentComment reports whether the user posted exactly this content since the given time.
+func (db *Client) HasRecentComment(ctx context.Context, userID int, content string, since time.Time) (bool, error) {
+	const query = `SELECT EXISTS (SELECT 1 FROM comments WHERE UserID = $1 AND CreatedAt >= $2 AND Content = $3)`
+
+	if db.ConnPool == nil {
+		return false, fmt.Errorf("unable to connect to database")
+	}
+
+	var found bool
+	if err := db.ConnPool.QueryRow(ctx, query, userID, since, content).Scan(&found); err != nil {
+		return false, err
+	}
+
+	return found, nil
+}
+
+// AddFilterSample stores a comment labelled by a moderator.
+func (db *Client) AddFilterSample(ctx context.Context, sample models.FilterSample) error {
+	const query = `INSERT INTO filter_samples (Content, Spam) VALUES($1, $2)`
+
+	// Check db connection
+	if db.ConnPool == nil {
+		return fmt.Errorf("unable to connect to database")
+	}
+
+	if _, err := db.ConnPool.Exec(ctx, query, sample.Content, sample.Spam); err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+// GetFilterSamples returns the most recent labelled comments.
+func (db *Client) GetFilterSamples(ctx context.Context, limit int) ([]models.FilterSample, error) {
+	const query = `SELECT Content, Spam FROM filter_samples ORDER BY ID DESC LIMIT $1`
+
+	if db.ConnPool == nil {
+		return nil, fmt.Errorf("unable to connect to database")
+	}
+
+	rows, err := db.ConnPool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	samples := make([]models.FilterSample, 0, limit)
+	for rows.Next() {
+		sample := models.FilterSample{}
+		if err := rows.Scan(&sample.Content, &sample.Spam); err != nil {
+			return nil, fmt.Errorf("failed to retrieve row: %w", err)
+		}
+		samples = append(samples, sample)
+	}
+
+	return samples, rows.Err()
+}
@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"blog-service/internal/db/postgres/models"
+	"context"
+	"fmt"
+)
+
+/*
+FollowAuthor makes followerID follow authorID.
+@returns
+bool - false when the author was already followed.
+error - for checking the execution of the query.
+*/
+func (db *Client) FollowAuthor(ctx context.Context, followerID, authorID int) (bool, error) {
+	const query = `INSERT INTO author_follows (FollowerID, AuthorID) VALUES($1, $2)
+	          ON CONFLICT DO NOTHING`
+
+	return db.execChanged(ctx, query, followerID, authorID)
+}
+
+// UnfollowAuthor removes a follow, returning false when there was none.
+func (db *Client) UnfollowAuthor(ctx context.Context, followerID, authorID int) (bool, error) {
+	const query = `DELETE FROM author_follows WHERE FollowerID = $1 AND AuthorID = $2`
+
+	return db.execChanged(ctx, query, followerID, authorID)
+}
+
+/*
+FollowCategory makes followerID follow a category.
+@returns
+bool - false when the category was already followed.
+error - for checking the execution of the query.
+*/
+func (db *Client) FollowCategory(ctx context.Context, followerID int, category string) (bool, error) {
+	const query = `INSERT INTO category_follows (FollowerID, Category) VALUES($1, $2)
+	          ON CONFLICT DO NOTHING`
+
+	return db.execChanged(ctx, query, followerID, category)
+}
+
+// UnfollowCategory removes a category follow, returning false when there was none.
+func (db *Client) UnfollowCategory(ctx context.Context, followerID int, category string) (bool, error) {
+	const query = `DELETE FROM category_follows WHERE FollowerID = $1 AND Category = $2`
+
+	return db.execChanged(ctx, query, followerID, category)
+}
+
+func (db *Client) execChanged(ctx context.Context, query string, args ...any) (bool, error) {
+	// Check db connection
+	if db.ConnPool == nil {
+		return false, fmt.Errorf("unable to connect to database")
+	}
+
+	commandTag, err := db.ConnPool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return commandTag.RowsAffected() > 0, nil
+}
+
+// GetFollowedAuthors returns the ids of the authors followerID follows.
+func (db *Client) GetFollowedAuthors(ctx context.Context, followerID int) ([]int, error) {
+	const query = `SELECT AuthorID FROM author_follows WHERE FollowerID = $1 ORDER BY AuthorID`
+
+	if db.ConnPool == nil {
+		return nil, fmt.Errorf("unable to connect to database")
+	}
+
+	rows, err := db.ConnPool.Query(ctx, query, followerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	authors := []int{}
+	for rows.Next() {
+		var authorID int
+		if err := rows.Scan(&authorID); err != nil {
+			return nil, fmt.Errorf("failed to retrieve row: %w", err)
+		}
+		authors = append(authors, authorID)
+	}
+
+	return authors, rows.Err()
+}
+
+// GetFollowedCategories returns the categories followerID follows.
+func (db *Client) GetFollowedCategories(ctx context.Context, followerID int) ([]string, error) {
+	const query = `SELECT Category FROM category_follows WHERE FollowerID = $1 ORDER BY Category`
+
+	if db.ConnPool == nil {
+		return nil, fmt.Errorf("unable to connect to database")
+	}
+
+	rows, err := db.ConnPool.Query(ctx, query, followerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []string{}
+	for rows.Next() {
+		var category string
+		if err := rows.Scan(&category); err != nil {
+			return nil, fmt.Errorf("failed to retrieve row: %w", err)
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// GetFollowCounts counts the followers of userID and what userID follows in a single round trip.
+func (db *Client) GetFollowCounts(ctx context.Context, userID int) (*models.FollowCounts, error) {
+	const query = `SELECT
+		(SELECT COUNT(*) FROM author_follows WHERE AuthorID = $1),
+		(SELECT COUNT(*) FROM author_follows WHERE FollowerID = $1),
+		(SELECT COUNT(*) FROM category_follows WHERE FollowerID = $1)`
+
+	if db.ConnPool == nil {
+		return nil, fmt.Errorf("unable to connect to database")
+	}
+
+	counts := &models.FollowCounts{}
+	err := db.ConnPool.QueryRow(ctx, query, userID).Scan(
+		&counts.Followers,
+		&counts.Following,
+		&counts.FollowedCategories,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// IsFollowingAuthor reports whether followerID follows authorID.
+func (db *Client) IsFollowingAuthor(ctx context.Context, followerID, authorID int) (bool, error) {
+	const query = `SELECT EXISTS (SELECT 1 FROM author_follows WHERE FollowerID = $1 AND AuthorID = $2)`
+
+	if db.ConnPool == nil {
+		return false, fmt.Errorf("unable to connect to database")
+	}
+
+	var following bool
+	if err := db.ConnPool.QueryRow(ctx, query, followerID, authorID).Scan(&following); err != nil {
+		return false, err
+	}
+
+	return following, nil
+}
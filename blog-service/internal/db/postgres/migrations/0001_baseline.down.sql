@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS likes;
+DROP TABLE IF EXISTS comments;
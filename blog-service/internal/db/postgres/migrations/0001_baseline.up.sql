@@ -0,0 +1,16 @@
+-- Baseline of the schema created at startup before this series, comments and likes only.
+-- Every statement is idempotent, so databases created that way are adopted as they are.
+
+CREATE TABLE IF NOT EXISTS comments (
+    ID SERIAL PRIMARY KEY,
+    ArticleID VARCHAR(36) NOT NULL,
+    UserID INT NOT NULL,
+    Content VARCHAR(1000) NOT NULL,
+    CreatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS likes (
+    ID SERIAL PRIMARY KEY,
+    CommentID INT NOT NULL,
+    UserID INT NOT NULL
+);
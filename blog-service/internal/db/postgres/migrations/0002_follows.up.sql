@@ -0,0 +1,18 @@
+-- Who follows which author and category. The primary keys serve the "who do I follow" lookups of the feed,
+-- the AuthorID index serves the follower counts on profiles.
+
+CREATE TABLE IF NOT EXISTS author_follows (
+    FollowerID INT NOT NULL,
+    AuthorID INT NOT NULL,
+    CreatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (FollowerID, AuthorID)
+);
+
+CREATE INDEX IF NOT EXISTS author_follows_author_idx ON author_follows (AuthorID);
+
+CREATE TABLE IF NOT EXISTS category_follows (
+    FollowerID INT NOT NULL,
+    Category VARCHAR(100) NOT NULL,
+    CreatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (FollowerID, Category)
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS likes_comment_user_key;
+DROP TABLE IF EXISTS notifications;
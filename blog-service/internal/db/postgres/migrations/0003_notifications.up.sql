@@ -0,0 +1,25 @@
+-- Notifications per recipient. Lists are read newest first by recipient and the unread count
+-- only looks at unread rows, each has an index.
+
+CREATE TABLE IF NOT EXISTS notifications (
+    ID SERIAL PRIMARY KEY,
+    RecipientID INT NOT NULL,
+    ActorID INT NOT NULL,
+    ActorName VARCHAR(100) NOT NULL DEFAULT '',
+    Type VARCHAR(20) NOT NULL,
+    ArticleID VARCHAR(36) NOT NULL DEFAULT '',
+    CommentID INT NOT NULL DEFAULT 0,
+    Message VARCHAR(1000) NOT NULL DEFAULT '',
+    ReadAt TIMESTAMPTZ,
+    CreatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS notifications_recipient_idx ON notifications (RecipientID, ID DESC);
+CREATE INDEX IF NOT EXISTS notifications_unread_idx ON notifications (RecipientID) WHERE ReadAt IS NULL;
+
+-- A user likes a comment at most once, so a like notifies once. Concurrent likes of the same user fail on the index.
+-- Duplicates left by earlier races are dropped first, keeping the oldest like.
+DELETE FROM likes duplicate USING likes original
+    WHERE duplicate.CommentID = original.CommentID AND duplicate.UserID = original.UserID AND duplicate.ID > original.ID;
+
+CREATE UNIQUE INDEX IF NOT EXISTS likes_comment_user_key ON likes (CommentID, UserID);
@@ -0,0 +1 @@
+DROP SEQUENCE IF EXISTS comment_event_seq;
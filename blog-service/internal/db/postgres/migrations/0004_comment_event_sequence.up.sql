@@ -0,0 +1,2 @@
+-- Numbers the live comment events sent by every replica, so readers can resume after the last one they saw.
+CREATE SEQUENCE IF NOT EXISTS comment_event_seq;
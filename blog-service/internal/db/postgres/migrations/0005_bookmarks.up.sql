@@ -0,0 +1,11 @@
+-- The reading list of every user, newest first through the index.
+
+CREATE TABLE IF NOT EXISTS bookmarks (
+    ID SERIAL PRIMARY KEY,
+    UserID INT NOT NULL,
+    ArticleID VARCHAR(36) NOT NULL,
+    CreatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (UserID, ArticleID)
+);
+
+CREATE INDEX IF NOT EXISTS bookmarks_user_idx ON bookmarks (UserID, ID DESC);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS moderation_actions;
+DROP TABLE IF EXISTS moderation_items;
+DROP TABLE IF EXISTS reports;
+ALTER TABLE comments DROP COLUMN IF EXISTS Status;
@@ -0,0 +1,45 @@
+-- Reports, the moderation queue and the audit trail of moderator actions.
+-- A user reports an item once, so ReportCount counts distinct reporters.
+-- ReviewedCount is the ReportCount at the last review, only reports after it count towards auto-hiding.
+-- Comments get a Status so hidden ones can be left out of listings, notifications get the Message of warnings.
+
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS Status VARCHAR(20) NOT NULL DEFAULT 'visible';
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS Message VARCHAR(1000) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS reports (
+    ID SERIAL PRIMARY KEY,
+    TargetType VARCHAR(20) NOT NULL,
+    TargetID VARCHAR(36) NOT NULL,
+    ReporterID INT NOT NULL,
+    Reason VARCHAR(30) NOT NULL,
+    Details VARCHAR(1000) NOT NULL DEFAULT '',
+    CreatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (TargetType, TargetID, ReporterID)
+);
+
+CREATE TABLE IF NOT EXISTS moderation_items (
+    TargetType VARCHAR(20) NOT NULL,
+    TargetID VARCHAR(36) NOT NULL,
+    AuthorID INT NOT NULL,
+    Status VARCHAR(20) NOT NULL DEFAULT 'open',
+    PreviousStatus VARCHAR(20) NOT NULL DEFAULT '',
+    ReportCount INT NOT NULL DEFAULT 0,
+    ReviewedCount INT NOT NULL DEFAULT 0,
+    LastReportedAt TIMESTAMPTZ,
+    UpdatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (TargetType, TargetID)
+);
+
+CREATE INDEX IF NOT EXISTS moderation_items_queue_idx ON moderation_items (Status, ReportCount DESC);
+
+CREATE TABLE IF NOT EXISTS moderation_actions (
+    ID SERIAL PRIMARY KEY,
+    TargetType VARCHAR(20) NOT NULL,
+    TargetID VARCHAR(36) NOT NULL,
+    ActorID INT NOT NULL,
+    Action VARCHAR(20) NOT NULL,
+    Note VARCHAR(1000) NOT NULL DEFAULT '',
+    CreatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS moderation_actions_target_idx ON moderation_actions (TargetType, TargetID);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS comments_user_idx;
+DROP TABLE IF EXISTS filter_samples;
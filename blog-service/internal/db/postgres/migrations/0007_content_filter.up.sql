@@ -0,0 +1,12 @@
+-- Comments labelled by moderators for training the spam classifier.
+-- The content is copied, so samples survive the deletion of the comment.
+-- The comments index serves the duplicate check.
+
+CREATE TABLE IF NOT EXISTS filter_samples (
+    ID SERIAL PRIMARY KEY,
+    Content VARCHAR(1000) NOT NULL,
+    Spam BOOLEAN NOT NULL,
+    CreatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS comments_user_idx ON comments (UserID, CreatedAt DESC);
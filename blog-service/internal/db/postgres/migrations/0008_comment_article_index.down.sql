@@ -0,0 +1 @@
+DROP INDEX IF EXISTS comments_article_idx;
@@ -0,0 +1,2 @@
+-- Comments are listed per article newest first. Likes are looked up per comment through likes_comment_user_key.
+CREATE INDEX IF NOT EXISTS comments_article_idx ON comments (ArticleID, ID DESC);
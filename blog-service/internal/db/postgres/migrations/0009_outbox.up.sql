@@ -0,0 +1,16 @@
+-- Events recorded with the writes causing them, delivered by the relay until every handler succeeded.
+CREATE TABLE IF NOT EXISTS outbox (
+    ID BIGSERIAL PRIMARY KEY,
+    IdempotencyKey UUID NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    Type VARCHAR(50) NOT NULL,
+    Payload JSONB NOT NULL,
+    Handled TEXT[] NOT NULL DEFAULT '{}',
+    Attempts INT NOT NULL DEFAULT 0,
+    LastError TEXT NOT NULL DEFAULT '',
+    CreatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    NextAttemptAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    DeliveredAt TIMESTAMPTZ,
+    FailedAt TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (NextAttemptAt) WHERE DeliveredAt IS NULL AND FailedAt IS NULL;
@@ -0,0 +1,2 @@
+-- The name of the author when the comment was written, so a held comment can be announced once it is restored.
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS AuthorName VARCHAR(100) NOT NULL DEFAULT '';
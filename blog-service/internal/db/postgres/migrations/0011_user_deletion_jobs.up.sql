@@ -0,0 +1,11 @@
+-- Progress of removing the content of users deleted in auth-service, so a crashed job resumes where it stopped.
+CREATE TABLE IF NOT EXISTS user_deletion_jobs (
+    -- The ID of the deletion in auth-service
+    ID BIGINT PRIMARY KEY,
+    UserID INT NOT NULL,
+    Mode VARCHAR(20) NOT NULL,
+    -- Count of each finished step, a step listed here is not run again
+    Outcome JSONB NOT NULL DEFAULT '{}',
+    CreatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CompletedAt TIMESTAMPTZ
+);
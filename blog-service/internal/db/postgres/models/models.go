@@ -8,6 +8,10 @@ type Comment struct {
 	ArticleID string    `json:"articleId"`
 	ID        int       `json:"id"`
 	UserID    int       `json:"userId"`
+	// Status is only read by GetComment, lists filter on it instead
+	Status string `json:"-"`
+	// Held comments wait for review, only their author and moderators see them
+	Held bool `json:"held,omitempty"`
 }
 
 type Like struct {
@@ -15,3 +19,206 @@ type Like struct {
 	CommentID int
 	UserID    int
 }
+
+// FollowCounts are the numbers shown on an author profile.
+type FollowCounts struct {
+	Followers          int `json:"followers"`
+	Following          int `json:"following"`
+	FollowedCategories int `json:"followedCategories"`
+}
+
+// Kinds of notification.
+const (
+	NotificationComment = "comment"
+	NotificationLike    = "like"
+	NotificationFollow  = "follow"
+	NotificationWarning = "warning"
+)
+
+/*
+Notification tells a user that someone interacted with their content.
+ActorName is stored with it so the list can be shown without looking users up.
+CommentID is 0 for notifications that are not about a comment.
+*/
+type Notification struct {
+	CreatedAt   time.Time  `json:"createdAt"`
+	ReadAt      *time.Time `json:"readAt,omitempty"`
+	Type        string     `json:"type"`
+	ActorName   string     `json:"actorName"`
+	ArticleID   string     `json:"articleId,omitempty"`
+	Message     string     `json:"message,omitempty"`
+	ID          int        `json:"id"`
+	RecipientID int        `json:"recipientId"`
+	ActorID     int        `json:"actorId"`
+	CommentID   int        `json:"commentId,omitempty"`
+}
+
+type Bookmark struct {
+	CreatedAt time.Time `json:"createdAt"`
+	ArticleID string    `json:"articleId"`
+	ID        int       `json:"id"`
+	UserID    int       `json:"userId"`
+}
+
+// Kinds of content that can be reported.
+const (
+	TargetArticle = "article"
+	TargetComment = "comment"
+)
+
+// States of a comment.
+const (
+	CommentVisible = "visible"
+	CommentHidden  = "hidden"
+	CommentHeld    = "held"
+)
+
+// States of an item in the moderation queue.
+const (
+	ModerationOpen     = "open"
+	ModerationHidden   = "hidden"
+	ModerationResolved = "resolved"
+	ModerationDeleted  = "deleted"
+)
+
+type Report struct {
+	CreatedAt  time.Time `json:"createdAt"`
+	TargetType string    `json:"targetType"`
+	TargetID   string    `json:"targetId"`
+	Reason     string    `json:"reason"`
+	Details    string    `json:"details,omitempty"`
+	ID         int       `json:"id"`
+	ReporterID int       `json:"reporterId"`
+}
+
+/*
+ModerationItem is reported (or moderated) content in the moderation queue.
+PreviousStatus remembers the article status to go back to when hidden content is restored.
+*/
+type ModerationItem struct {
+	UpdatedAt      time.Time      `json:"updatedAt"`
+	LastReportedAt *time.Time     `json:"lastReportedAt,omitempty"`
+	Reasons        map[string]int `json:"reasons,omitempty"`
+	TargetType     string         `json:"targetType"`
+	TargetID       string         `json:"targetId"`
+	Status         string         `json:"status"`
+	PreviousStatus string         `json:"-"`
+	AuthorID       int            `json:"authorId"`
+	ReportCount    int            `json:"reportCount"`
+	ReviewedCount  int            `json:"reviewedCount"`
+}
+
+// ModerationAction is an entry of the audit trail, ActorID is 0 for actions taken automatically.
+type ModerationAction struct {
+	CreatedAt  time.Time `json:"createdAt"`
+	TargetType string    `json:"targetType"`
+	TargetID   string    `json:"targetId"`
+	Action     string    `json:"action"`
+	Note       string    `json:"note,omitempty"`
+	ID         int       `json:"id"`
+	ActorID    int       `json:"actorId"`
+}
+
+// ModerationFilter narrows the moderation queue, zero values match everything.
+type ModerationFilter struct {
+	Status     string
+	TargetType string
+	Reason     string
+}
+
+// FilterSample is a comment labelled by a moderation decision, used to train the spam classifier.
+type FilterSample struct {
+	Content string
+	Spam    bool
+}
+
+// Kinds of outbox event.
+const (
+	EventCommentCreated   = "comment.created"
+	EventCommentDeleted   = "comment.deleted"
+	EventCommentHidden    = "comment.hidden"
+	EventCommentRestored  = "comment.restored"
+	EventLikeAdded        = "like.added"
+	EventLikeRemoved      = "like.removed"
+	EventArticlePublished = "article.published"
+	EventArticleUpdated   = "article.updated"
+	EventArticleDeleted   = "article.deleted"
+	EventUserDeleted      = "user.deleted"
+	// EventModerationWarning carries the Notification warning the author of moderated content
+	EventModerationWarning = "moderation.warning"
+)
+
+/*
+OutboxEvent is a change recorded in the same transaction as the write causing it, delivered later by the relay.
+Key identifies the event for idempotent handlers, Handled lists the handlers that already succeeded
+so a retry only runs the others. Attempts counts the deliveries started, the current one included.
+*/
+type OutboxEvent struct {
+	CreatedAt time.Time
+	Key       string
+	Type      string
+	Payload   []byte
+	Handled   []string
+	ID        int64
+	Attempts  int
+}
+
+/*
+CommentEvent is the payload of the comment events.
+AuthorName is set on comment.created and comment.restored, PublisherID, the author of the article,
+only on comment.created.
+Likes is the number of likes the comment had when it was deleted.
+Held is set on comment.created when the comment waits for review, and on comment.restored when
+it was held rather than hidden by a moderator. Hidden is set on comment.deleted when the comment
+was held or hidden, so it no longer counted towards the engagement of the article.
+*/
+type CommentEvent struct {
+	ArticleID   string `json:"articleId"`
+	AuthorName  string `json:"authorName,omitempty"`
+	CommentID   int    `json:"commentId"`
+	AuthorID    int    `json:"authorId"`
+	PublisherID int    `json:"publisherId,omitempty"`
+	Likes       int    `json:"likes,omitempty"`
+	Held        bool   `json:"held,omitempty"`
+	Hidden      bool   `json:"hidden,omitempty"`
+}
+
+// LikeEvent is the payload of the like events, UserName is only set on like.added.
+type LikeEvent struct {
+	ArticleID       string `json:"articleId"`
+	UserName        string `json:"userName,omitempty"`
+	CommentID       int    `json:"commentId"`
+	UserID          int    `json:"userId"`
+	CommentAuthorID int    `json:"commentAuthorId"`
+}
+
+// ArticleEvent is the payload of the article events, Revision is only set on article.updated.
+type ArticleEvent struct {
+	ArticleID string `json:"articleId"`
+	Revision  int    `json:"revision,omitempty"`
+}
+
+// UserEvent is the payload of user.deleted, recorded once the content of the user was handled.
+type UserEvent struct {
+	Mode   string `json:"mode"`
+	UserID int    `json:"userId"`
+}
+
+// What happens to the articles and comments of a deleted user.
+const (
+	DeletionAnonymise = "anonymise"
+	DeletionPurge     = "purge"
+)
+
+/*
+UserDeletionJob removes the content of a user deleted in auth-service, ID is the ID of the deletion there.
+Outcome counts what each finished step changed, CompletedAt is set once every step finished.
+*/
+type UserDeletionJob struct {
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+	Outcome     map[string]int64
+	Mode        string
+	ID          int64
+	UserID      int
+}
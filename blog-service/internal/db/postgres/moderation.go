@@ -0,0 +1,368 @@
+package postgres
+
+import (
+	"blog-service/internal/db/postgres/models"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const moderationItemColumns = `TargetType, TargetID, AuthorID, Status, PreviousStatus,
+	ReportCount, ReviewedCount, LastReportedAt, UpdatedAt`
+
+func scanModerationItem(row pgx.Row, item *models.ModerationItem, extra ...any) error {
+	return row.Scan(append([]any{
+		&item.TargetType,
+		&item.TargetID,
+		&item.AuthorID,
+		&item.Status,
+		&item.PreviousStatus,
+		&item.ReportCount,
+		&item.ReviewedCount,
+		&item.LastReportedAt,
+		&item.UpdatedAt,
+	}, extra...)...)
+}
+
+/*
+AddReport stores a report and counts it on the queue item of the reported content.
+A resolved item is opened again by a new report.
+@params
+authorID - the author of the reported content, stored on the queue item.
+@returns
+*models.ModerationItem - the queue item after the report.
+bool - false when the reporter had already reported the content, nothing changed then.
+error - for checking the execution of the query.
+*/
+func (db *Client) AddReport(ctx context.Context, report *models.Report, authorID int) (*models.ModerationItem, bool, error) {
+	const insertReport = `INSERT INTO reports (TargetType, TargetID, ReporterID, Reason, Details)
+	          VALUES($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING RETURNING ID, CreatedAt`
+
+	const upsertItem = `INSERT INTO moderation_items (TargetType, TargetID, AuthorID, ReportCount, LastReportedAt)
+	          VALUES($1, $2, $3, 1, NOW())
+	          ON CONFLICT (TargetType, TargetID) DO UPDATE SET
+	              ReportCount = moderation_items.ReportCount + 1,
+	              LastReportedAt = NOW(),
+	              UpdatedAt = NOW(),
+	              Status = CASE WHEN moderation_items.Status = 'resolved' THEN 'open' ELSE moderation_items.Status END
+	          RETURNING ` + moderationItemColumns
+
+	// Check db connection
+	if db.ConnPool == nil {
+		return nil, false, fmt.Errorf("unable to connect to database")
+	}
+
+	tx, err := db.ConnPool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // Rolling back a committed transaction is a no-op
+
+	err = tx.QueryRow(ctx, insertReport,
+		report.TargetType, report.TargetID, report.ReporterID, report.Reason, report.Details,
+	).Scan(&report.ID, &report.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		item, err := db.GetModerationItem(ctx, report.TargetType, report.TargetID)
+		return item, false, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	item := &models.ModerationItem{}
+	if err := scanModerationItem(tx.QueryRow(ctx, upsertItem, report.TargetType, report.TargetID, authorID), item); err != nil {
+		return nil, false, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to commit report: %w", err)
+	}
+
+	return item, true, nil
+}
+
+// GetModerationItem returns the queue item of a piece of content, pgx.ErrNoRows when it has none.
+func (db *Client) GetModerationItem(ctx context.Context, targetType, targetID string) (*models.ModerationItem, error) {
+	const query = `SELECT ` + moderationItemColumns + ` FROM moderation_items WHERE TargetType = $1 AND TargetID = $2`
+
+	if db.ConnPool == nil {
+		return nil, fmt.Errorf("unable to connect to database")
+	}
+
+	item := &models.ModerationItem{}
+	if err := scanModerationItem(db.ConnPool.QueryRow(ctx, query, targetType, targetID), item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+/*
+GetModerationQueue returns queue items matching the filter, most reported first,
+together with how often each reason was given.
+*/
+func (db *Client) GetModerationQueue(ctx context.Context, filter models.ModerationFilter, limit, page int) ([]models.ModerationItem, error) {
+	const query = `SELECT ` + moderationItemColumns + `,
+	              (SELECT json_object_agg(Reason, n) FROM (
+	                  SELECT Reason, COUNT(*) AS n FROM reports r
+	                  WHERE r.TargetType = m.TargetType AND r.TargetID = m.TargetID GROUP BY Reason) reasons)
+	          FROM moderation_items m
+	          WHERE ($1 = '' OR Status = $1) AND ($2 = '' OR TargetType = $2)
+	              AND ($3 = '' OR EXISTS (SELECT 1 FROM reports r
+	                  WHERE r.TargetType = m.TargetType AND r.TargetID = m.TargetID AND r.Reason = $3))
+	          ORDER BY ReportCount DESC, LastReportedAt DESC NULLS LAST
+	          LIMIT $4 OFFSET $5`
+
+	if db.ConnPool == nil {
+		return nil, fmt.Errorf("unable to connect to database")
+	}
+
+	offset := limit * (page - 1)
+	rows, err := db.ConnPool.Query(ctx, query, filter.Status, filter.TargetType, filter.Reason, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.ModerationItem, 0, limit)
+	for rows.Next() {
+		item := models.ModerationItem{}
+		if err := scanModerationItem(rows, &item, &item.Reasons); err != nil {
+			return nil, fmt.Errorf("failed to retrieve row: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// ErrModerationConflict is returned when the queue item left the expected status before a decision was applied.
+var ErrModerationConflict = errors.New("moderation item was changed meanwhile")
+
+/*
+ModerationChange is a moderation decision with the writes it causes, applied together by Moderate.
+The queue item moves from From to To, CommentStatus or DeleteComment change the reported comment,
+DeleteArticle records the deletion of the reported article and Warning is delivered by the relay.
+Apply runs last with the writes in Mongo, an error from it rolls the others back.
+*/
+type ModerationChange struct {
+	Action         *models.ModerationAction
+	Warning        *models.Notification
+	Apply          func(ctx context.Context) error
+	From           string
+	To             string
+	PreviousStatus string
+	CommentStatus  string
+	AuthorID       int
+	CommentID      int
+	DeleteComment  bool
+	DeleteArticle  bool
+}
+
+/*
+Moderate applies a moderation decision and writes it to the audit trail in one transaction.
+The queue item only moves when it is still in change.From, so two moderators, or a moderator and
+an automatic hide, cannot both act on what they read. Content that was never reported gets a queue item.
+@returns
+error - ErrModerationConflict when the item changed status meanwhile, nothing was written then.
+*/
+func (db *Client) Moderate(ctx context.Context, change *ModerationChange) error {
+	// Check db connection
+	if db.ConnPool == nil {
+		return fmt.Errorf("unable to connect to database")
+	}
+
+	return pgx.BeginFunc(ctx, db.ConnPool, func(tx pgx.Tx) error {
+		moved, err := moveModerationItem(ctx, tx, change.Action, change.AuthorID, change.From, change.To, change.PreviousStatus)
+		if err != nil {
+			return err
+		}
+		if !moved {
+			return ErrModerationConflict
+		}
+
+		switch {
+		case change.DeleteComment:
+			if _, err := deleteComment(ctx, tx, change.CommentID); err != nil {
+				return err
+			}
+		case change.CommentStatus != "":
+			if err := setCommentStatus(ctx, tx, change.CommentID, change.CommentStatus); err != nil {
+				return err
+			}
+		case change.DeleteArticle:
+			// Its handlers remove the comments once Apply deleted the article
+			event := models.ArticleEvent{ArticleID: change.Action.TargetID}
+			if err := insertEvent(ctx, tx, models.EventArticleDeleted, event); err != nil {
+				return err
+			}
+		}
+
+		if change.Warning != nil {
+			if err := insertEvent(ctx, tx, models.EventModerationWarning, change.Warning); err != nil {
+				return err
+			}
+		}
+
+		if err := insertModerationAction(ctx, tx, change.Action); err != nil {
+			return err
+		}
+
+		if change.Apply == nil {
+			return nil
+		}
+
+		return change.Apply(ctx)
+	})
+}
+
+/*
+RecordModerationAction writes an action to the audit trail and moves the queue item to status,
+both or neither, whatever status the item had. Content that was never reported gets a queue item here.
+The item counts as reviewed unless the action was taken automatically.
+@params
+previousStatus - stored on the item when not empty, see models.ModerationItem.
+*/
+func (db *Client) RecordModerationAction(ctx context.Context, action *models.ModerationAction,
+	authorID int, status, previousStatus string) error {
+	// Check db connection
+	if db.ConnPool == nil {
+		return fmt.Errorf("unable to connect to database")
+	}
+
+	return pgx.BeginFunc(ctx, db.ConnPool, func(tx pgx.Tx) error {
+		if _, err := moveModerationItem(ctx, tx, action, authorID, "", status, previousStatus); err != nil {
+			return err
+		}
+
+		return insertModerationAction(ctx, tx, action)
+	})
+}
+
+/*
+moveModerationItem sets the status of the queue item of action's target inside tx, creating the item if needed.
+An existing item is only updated while its status is from, any status matches an empty from.
+@returns
+bool - false when the existing item had another status and was left alone.
+*/
+func moveModerationItem(ctx context.Context, tx pgx.Tx, action *models.ModerationAction,
+	authorID int, from, to, previousStatus string) (bool, error) {
+	const query = `INSERT INTO moderation_items (TargetType, TargetID, AuthorID, Status, PreviousStatus)
+	          VALUES($1, $2, $3, $4, $5)
+	          ON CONFLICT (TargetType, TargetID) DO UPDATE SET
+	              Status = $4,
+	              PreviousStatus = CASE WHEN $5 = '' THEN moderation_items.PreviousStatus ELSE $5 END,
+	              ReviewedCount = CASE WHEN $6 THEN moderation_items.ReportCount ELSE moderation_items.ReviewedCount END,
+	              UpdatedAt = NOW()
+	          WHERE $7 = '' OR moderation_items.Status = $7`
+
+	reviewed := action.ActorID != 0
+	tag, err := tx.Exec(ctx, query, action.TargetType, action.TargetID, authorID, to, previousStatus, reviewed, from)
+	if err != nil {
+		return false, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// insertModerationAction adds action to the audit trail inside tx.
+func insertModerationAction(ctx context.Context, tx pgx.Tx, action *models.ModerationAction) error {
+	const query = `INSERT INTO moderation_actions (TargetType, TargetID, ActorID, Action, Note)
+	          VALUES($1, $2, $3, $4, $5) RETURNING ID, CreatedAt`
+
+	err := tx.QueryRow(ctx, query,
+		action.TargetType, action.TargetID, action.ActorID, action.Action, action.Note,
+	).Scan(&action.ID, &action.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+/*
+GetModerationActions returns the audit trail, newest first.
+Empty targetType and targetID return the actions on all content.
+*/
+func (db *Client) GetModerationActions(ctx context.Context, targetType, targetID string, limit, page int) ([]models.ModerationAction, error) {
+	const query = `SELECT ID, TargetType, TargetID, ActorID, Action, Note, CreatedAt FROM moderation_actions
+	          WHERE ($1 = '' OR (TargetType = $1 AND TargetID = $2))
+	          ORDER BY ID DESC LIMIT $3 OFFSET $4`
+
+	if db.ConnPool == nil {
+		return nil, fmt.Errorf("unable to connect to database")
+	}
+
+	offset := limit * (page - 1)
+	rows, err := db.ConnPool.Query(ctx, query, targetType, targetID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actions := make([]models.ModerationAction, 0, limit)
+	for rows.Next() {
+		action := models.ModerationAction{}
+		err := rows.Scan(
+			&action.ID,
+			&action.TargetType,
+			&action.TargetID,
+			&action.ActorID,
+			&action.Action,
+			&action.Note,
+			&action.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve row: %w", err)
+		}
+		actions = append(actions, action)
+	}
+
+	return actions, rows.Err()
+}
+
+/*
+SetCommentStatus hides a comment from listings or shows it again.
+Hiding a visible comment records comment.hidden, showing a held or hidden one records comment.restored.
+*/
+func (db *Client) SetCommentStatus(ctx context.Context, commentID int, status string) error {
+	// Check db connection
+	if db.ConnPool == nil {
+		return fmt.Errorf("unable to connect to database")
+	}
+
+	return pgx.BeginFunc(ctx, db.ConnPool, func(tx pgx.Tx) error {
+		return setCommentStatus(ctx, tx, commentID, status)
+	})
+}
+
+// setCommentStatus changes the status of a comment inside tx, with the event it causes.
+func setCommentStatus(ctx context.Context, tx pgx.Tx, commentID int, status string) error {
+	const query = `UPDATE comments c SET Status = $2
+	          FROM (SELECT ID, Status FROM comments WHERE ID = $1 FOR UPDATE) previous
+	          WHERE c.ID = previous.ID
+	          RETURNING previous.Status, c.ArticleID, c.UserID, c.AuthorName`
+
+	var previous string
+	event := models.CommentEvent{CommentID: commentID}
+
+	err := tx.QueryRow(ctx, query, commentID, status).Scan(&previous, &event.ArticleID, &event.AuthorID, &event.AuthorName)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	switch {
+	case previous == models.CommentVisible && status != models.CommentVisible:
+		return insertEvent(ctx, tx, models.EventCommentHidden, event)
+	case previous != models.CommentVisible && status == models.CommentVisible:
+		event.Held = previous == models.CommentHeld
+		return insertEvent(ctx, tx, models.EventCommentRestored, event)
+	default:
+		return nil
+	}
+}
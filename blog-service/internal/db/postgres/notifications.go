@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"blog-service/internal/db/postgres/models"
+	"context"
+	"fmt"
+)
+
+// InsertNotification stores a notification and fills in its ID and creation time.
+func (db *Client) InsertNotification(ctx context.Context, notification *models.Notification) error {
+	const query = `INSERT INTO notifications (RecipientID, ActorID, ActorName, Type, ArticleID, CommentID, Message)
+	          VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING ID, CreatedAt`
+
+	// Check db connection
+	if db.ConnPool == nil {
+		return fmt.Errorf("unable to connect to database")
+	}
+
+	err := db.ConnPool.QueryRow(ctx, query,
+		notification.RecipientID,
+		notification.ActorID,
+		notification.ActorName,
+		notification.Type,
+		notification.ArticleID,
+		notification.CommentID,
+		notification.Message,
+	).Scan(&notification.ID, &notification.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+/*
+GetNotifications returns the notifications of a recipient, newest first.
+@params
+beforeID - only notifications older than this one are returned, 0 starts from the newest.
+unreadOnly - leaves out notifications that were already read.
+*/
+func (db *Client) GetNotifications(ctx context.Context, recipientID, beforeID, limit int, unreadOnly bool) ([]models.Notification, error) {
+	const query = `SELECT ID, RecipientID, ActorID, ActorName, Type, ArticleID, CommentID, Message, ReadAt, CreatedAt
+	          FROM notifications
+	          WHERE RecipientID = $1 AND ($2 = 0 OR ID < $2) AND (NOT $3 OR ReadAt IS NULL)
+	          ORDER BY ID DESC LIMIT $4`
+
+	if db.ConnPool == nil {
+		return nil, fmt.Errorf("unable to connect to database")
+	}
+
+	rows, err := db.ConnPool.Query(ctx, query, recipientID, beforeID, unreadOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := make([]models.Notification, 0, limit)
+	for rows.Next() {
+		notification := models.Notification{}
+		err := rows.Scan(
+			&notification.ID,
+			&notification.RecipientID,
+			&notification.ActorID,
+			&notification.ActorName,
+			&notification.Type,
+			&notification.ArticleID,
+			&notification.CommentID,
+			&notification.Message,
+			&notification.ReadAt,
+			&notification.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve row: %w", err)
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+func (db *Client) CountUnreadNotifications(ctx context.Context, recipientID int) (int, error) {
+	const query = `SELECT COUNT(*) FROM notifications WHERE RecipientID = $1 AND ReadAt IS NULL`
+
+	if db.ConnPool == nil {
+		return 0, fmt.Errorf("unable to connect to database")
+	}
+
+	var count int
+	if err := db.ConnPool.QueryRow(ctx, query, recipientID).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+/*
+MarkNotificationsRead marks notifications of a recipient as read.
+A nil ids marks every unread notification of the recipient.
+@returns
+int64 - how many notifications changed from unread to read.
+error - for checking the execution of the query.
+*/
+func (db *Client) MarkNotificationsRead(ctx context.Context, recipientID int, ids []int) (int64, error) {
+	const query = `UPDATE notifications SET ReadAt = NOW()
+	          WHERE RecipientID = $1 AND ReadAt IS NULL AND ($2::int[] IS NULL OR ID = ANY($2))`
+
+	// Check db connection
+	if db.ConnPool == nil {
+		return 0, fmt.Errorf("unable to connect to database")
+	}
+
+	commandTag, err := db.ConnPool.Exec(ctx, query, recipientID, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return commandTag.RowsAffected(), nil
+}
@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"blog-service/internal/db/postgres/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// insertEvent records an outbox event in tx, it is delivered only if tx commits.
+func insertEvent(ctx context.Context, tx pgx.Tx, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	if _, err := tx.Exec(ctx, `INSERT INTO outbox (Type, Payload) VALUES ($1, $2)`, eventType, data); err != nil {
+		return fmt.Errorf("failed to record %s event: %w", eventType, err)
+	}
+
+	return nil
+}
+
+/*
+RecordArticleEvent records the deletion of an article, whose writes happen in Mongo.
+Both stores cannot share a transaction, so it is recorded before the article is removed and its
+handlers wait until the article is gone. Publishing and edits are written with the article instead,
+see ImportEvent.
+*/
+func (db *Client) RecordArticleEvent(ctx context.Context, eventType string, event models.ArticleEvent) error {
+	return pgx.BeginFunc(ctx, db.ConnPool, func(tx pgx.Tx) error {
+		return insertEvent(ctx, tx, eventType, event)
+	})
+}
+
+/*
+ImportEvent records an event first written to another store, with the key and time it got there.
+Importing the same key again records nothing, so an import interrupted before the source
+forgot the event can simply be repeated.
+*/
+func (db *Client) ImportEvent(ctx context.Context, key, eventType string, payload any, createdAt time.Time) error {
+	const query = `INSERT INTO outbox (IdempotencyKey, Type, Payload, CreatedAt) VALUES ($1, $2, $3, $4)
+	          ON CONFLICT (IdempotencyKey) DO NOTHING`
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	if _, err := db.ConnPool.Exec(ctx, query, key, eventType, data, createdAt); err != nil {
+		return fmt.Errorf("failed to import %s event: %w", eventType, err)
+	}
+
+	return nil
+}
+
+/*
+ClaimEvents takes up to limit pending events, oldest first, whose next attempt is due.
+They are hidden from other relays for lease, after which an event that was neither
+delivered nor failed (its relay crashed) is claimed again.
+*/
+func (db *Client) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	const query = `UPDATE outbox SET NextAttemptAt = NOW() + make_interval(secs => $2), Attempts = Attempts + 1
+	          WHERE ID IN (
+	              SELECT ID FROM outbox
+	              WHERE DeliveredAt IS NULL AND FailedAt IS NULL AND NextAttemptAt <= NOW()
+	              ORDER BY ID LIMIT $1
+	              FOR UPDATE SKIP LOCKED
+	          )
+	          RETURNING ID, IdempotencyKey::TEXT, Type, Payload, Handled, Attempts, CreatedAt`
+
+	rows, err := db.ConnPool.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	events := []models.OutboxEvent{}
+	var event models.OutboxEvent
+	_, err = pgx.ForEachRow(rows, []any{&event.ID, &event.Key, &event.Type, &event.Payload, &event.Handled, &event.Attempts, &event.CreatedAt}, func() error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve row: %w", err)
+	}
+
+	return events, nil
+}
+
+/*
+ExtendEventLease hides a claimed event from other relays for lease from now on.
+attempts is the number of attempts the event had when it was claimed, it changes when
+another relay claims it again once the lease ran out.
+@returns
+bool - false when the event was claimed again, delivered or given up on since, it must not be delivered then.
+*/
+func (db *Client) ExtendEventLease(ctx context.Context, id int64, attempts int, lease time.Duration) (bool, error) {
+	const query = `UPDATE outbox SET NextAttemptAt = NOW() + make_interval(secs => $3)
+	          WHERE ID = $1 AND Attempts = $2 AND DeliveredAt IS NULL AND FailedAt IS NULL`
+
+	tag, err := db.ConnPool.Exec(ctx, query, id, attempts, lease.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// MarkEventHandled records that handler succeeded, it is skipped when the event is delivered again.
+func (db *Client) MarkEventHandled(ctx context.Context, id int64, handler string) error {
+	const query = `UPDATE outbox SET Handled = array_append(Handled, $2)
+	          WHERE ID = $1 AND NOT $2 = ANY(Handled)`
+
+	if _, err := db.ConnPool.Exec(ctx, query, id, handler); err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+// MarkEventDelivered records that every handler of the event succeeded.
+func (db *Client) MarkEventDelivered(ctx context.Context, id int64) error {
+	if _, err := db.ConnPool.Exec(ctx, `UPDATE outbox SET DeliveredAt = NOW(), LastError = '' WHERE ID = $1`, id); err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+/*
+MarkEventFailed records why a delivery failed.
+@params
+retryAt - when to try again, nil gives up on the event, which stays in the table for inspection.
+*/
+func (db *Client) MarkEventFailed(ctx context.Context, id int64, cause string, retryAt *time.Time) error {
+	const query = `UPDATE outbox SET LastError = $2,
+	              NextAttemptAt = COALESCE($3, NextAttemptAt),
+	              FailedAt = CASE WHEN $3::TIMESTAMPTZ IS NULL THEN NOW() END
+	          WHERE ID = $1`
+
+	if _, err := db.ConnPool.Exec(ctx, query, id, cause, retryAt); err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+/*
+CountEngagement counts the visible comments of an article and the likes of all its comments.
+@returns
+int - the engagement of the article.
+int64 - when the count was taken, in microseconds, so an older count never overwrites a newer one.
+error - for checking the execution of the query.
+*/
+func (db *Client) CountEngagement(ctx context.Context, articleID string) (int, int64, error) {
+	const query = `SELECT
+	              (SELECT COUNT(*) FROM comments WHERE ArticleID = $1 AND Status = 'visible') +
+	              (SELECT COUNT(*) FROM likes l JOIN comments c ON c.ID = l.CommentID WHERE c.ArticleID = $1),
+	              (EXTRACT(EPOCH FROM statement_timestamp()) * 1000000)::BIGINT`
+
+	var engagement int
+	var countedAt int64
+	if err := db.ConnPool.QueryRow(ctx, query, articleID).Scan(&engagement, &countedAt); err != nil {
+		return 0, 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return engagement, countedAt, nil
+}
+
+// DeleteCommentLikes removes the likes of a comment that no longer exists.
+func (db *Client) DeleteCommentLikes(ctx context.Context, commentID int) error {
+	if _, err := db.ConnPool.Exec(ctx, `DELETE FROM likes WHERE CommentID = $1`, commentID); err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteArticleContent removes the comments, their likes and the bookmarks of an article that no longer exists.
+func (db *Client) DeleteArticleContent(ctx context.Context, articleID string) error {
+	return pgx.BeginFunc(ctx, db.ConnPool, func(tx pgx.Tx) error {
+		queries := []string{
+			`DELETE FROM likes WHERE CommentID IN (SELECT ID FROM comments WHERE ArticleID = $1)`,
+			`DELETE FROM comments WHERE ArticleID = $1`,
+			`DELETE FROM bookmarks WHERE ArticleID = $1`,
+		}
+		for _, query := range queries {
+			if _, err := tx.Exec(ctx, query, articleID); err != nil {
+				return fmt.Errorf("failed to execute query: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
@@ -2,42 +2,55 @@ package postgres
 
 import (
 	"blog-service/internal/db/postgres/models"
+	"blog-service/internal/tracing"
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"log/slog"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 const defaultTimeout = 5 * time.Second
 
+// SQLSTATE of an insert violating a unique index.
+const codeUniqueViolation = "23505"
+
+// ErrAlreadyLiked is returned by AddLike when the user already likes the comment.
+var ErrAlreadyLiked = errors.New("comment already liked")
+
 type Client struct {
 	ConnPool *pgxpool.Pool
+}
+
+// Config locates the PostgreSQL database of the service.
+type Config struct {
 	Host     string
+	Port     int
 	User     string
-	Port     string
 	Password string
 	Dbname   string
 }
 
-func NewPostgresClient() (*Client, error) {
-	client := Client{
-		Host:     os.Getenv("PG_HOST"),
-		Port:     os.Getenv("PG_PORT"),
-		User:     os.Getenv("PG_USER"),
-		Password: os.Getenv("PG_PASSWORD"),
-		Dbname:   os.Getenv("PG_DB"),
-	}
+func NewPostgresClient(cfg Config) (*Client, error) {
+	client := Client{}
 
-	databaseURL := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s?pool_max_conns=10",
-		client.User, client.Password, client.Host, client.Port, client.Dbname)
+	databaseURL := fmt.Sprintf("postgresql://%s:%s@%s:%d/%s?pool_max_conns=10",
+		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Dbname)
 
 	var err error
 
+	config, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database URL: %w", err)
+	}
+	config.ConnConfig.Tracer = tracing.PgxTracer{}
+
 	// Create db connection
-	client.ConnPool, err = pgxpool.New(context.Background(), databaseURL)
+	client.ConnPool, err = pgxpool.NewWithConfig(context.Background(), config)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create connection pool: %w", err)
 	}
@@ -52,15 +65,19 @@ func NewPostgresClient() (*Client, error) {
 		return nil, fmt.Errorf("connection failed: %w", err)
 	}
 
-	fmt.Println("Successfully connected to PostGres DB!")
+	slog.Info("Successfully connected to PostgreSQL")
 
-	result, err := client.ExecuteQuery(ctx, SchemaComments)
-	if err != nil {
-		return nil, fmt.Errorf("cannot CREATE comments and likes Table: %w", err)
+	return &client, nil
+}
+
+// Ping checks that a connection can be taken from the pool and used, which fails too when the pool is exhausted.
+func (db *Client) Ping(ctx context.Context) error {
+	if err := db.ConnPool.Ping(ctx); err != nil {
+		stat := db.ConnPool.Stat()
+		return fmt.Errorf("%w (%d/%d connections in use)", err, stat.AcquiredConns(), stat.MaxConns())
 	}
-	fmt.Println("CREATE comments and likes TABLE Result:", result.String())
 
-	return &client, nil
+	return nil
 }
 
 /*
@@ -104,7 +121,7 @@ func (db *Client) GetCommentLikeCount(ctx context.Context, commentID int) (int,
 }
 
 func (db *Client) GetComment(ctx context.Context, commentID int) (*models.Comment, error) {
-	const query = `SELECT * FROM comments WHERE ID = $1`
+	const query = `SELECT ID, ArticleID, UserID, Content, CreatedAt, Status FROM comments WHERE ID = $1`
 
 	if db.ConnPool == nil {
 		return nil, fmt.Errorf("unable to connect to database")
@@ -118,24 +135,35 @@ func (db *Client) GetComment(ctx context.Context, commentID int) (*models.Commen
 		&comment.UserID,
 		&comment.Content,
 		&comment.CreatedAt,
+		&comment.Status,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
+	comment.Held = comment.Status == models.CommentHeld
+
 	return comment, nil
 }
 
 func (db *Client) GetCommentsCount(ctx context.Context, articleID string) (int, error) {
-	const query = "SELECT COUNT(*) FROM comments WHERE ArticleID = $1"
+	return db.GetCommentsCountForViewer(ctx, articleID, 0, false)
+}
+
+/*
+GetCommentsCountForViewer counts the comments of an article the viewer can see.
+Held comments count for their author (viewerID) and, with allHeld, for moderators.
+*/
+func (db *Client) GetCommentsCountForViewer(ctx context.Context, articleID string, viewerID int, allHeld bool) (int, error) {
+	const query = `SELECT COUNT(*) FROM comments WHERE ArticleID = $1 AND ` + visibleToViewer
 
 	if db.ConnPool == nil {
 		return 0, fmt.Errorf("unable to connect to database")
 	}
 
 	var cnt int
-	err := db.ConnPool.QueryRow(ctx, query, articleID).Scan(&cnt)
+	err := db.ConnPool.QueryRow(ctx, query, articleID, viewerID, allHeld).Scan(&cnt)
 	if err != nil {
 		return 0, err
 	}
@@ -143,15 +171,24 @@ func (db *Client) GetCommentsCount(ctx context.Context, articleID string) (int,
 	return cnt, nil
 }
 
+// visibleToViewer matches the comments shown to the viewer $2, $3 shows every held comment.
+const visibleToViewer = `(Status = 'visible' OR (Status = 'held' AND ($3 OR UserID = $2)))`
+
 func (db *Client) GetComments(ctx context.Context, articleID string, limit, page int) ([]models.Comment, error) {
-	const query = `SELECT * FROM comments WHERE ArticleID = $1 ORDER BY id DESC LIMIT $2 OFFSET $3`
+	return db.GetCommentsForViewer(ctx, articleID, 0, false, limit, page)
+}
+
+// GetCommentsForViewer returns a page of the comments of an article the viewer can see, newest first.
+func (db *Client) GetCommentsForViewer(ctx context.Context, articleID string, viewerID int, allHeld bool, limit, page int) ([]models.Comment, error) {
+	const query = `SELECT ID, ArticleID, UserID, Content, CreatedAt, Status = 'held' FROM comments
+	          WHERE ArticleID = $1 AND ` + visibleToViewer + ` ORDER BY id DESC LIMIT $4 OFFSET $5`
 
 	if db.ConnPool == nil {
 		return nil, fmt.Errorf("unable to connect to database")
 	}
 
 	offset := limit * (page - 1)
-	rows, err := db.ConnPool.Query(ctx, query, articleID, limit, offset)
+	rows, err := db.ConnPool.Query(ctx, query, articleID, viewerID, allHeld, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -167,6 +204,7 @@ func (db *Client) GetComments(ctx context.Context, articleID string, limit, page
 			&comment.UserID,
 			&comment.Content,
 			&comment.CreatedAt,
+			&comment.Held,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to retrieve row: %w", err)
@@ -177,11 +215,22 @@ func (db *Client) GetComments(ctx context.Context, articleID string, limit, page
 	return comments, nil
 }
 
-func (db *Client) CreateComment(ctx context.Context, comment models.Comment) (int, error) {
+/*
+CreateComment stores a comment with its comment.created event.
+@params
+event - what the handlers need beyond the comment, the author name and the publisher of the article.
+The comment fields are filled in.
+*/
+func (db *Client) CreateComment(ctx context.Context, comment models.Comment, event models.CommentEvent) (int, error) {
+	status := models.CommentVisible
+	if comment.Held {
+		status = models.CommentHeld
+	}
+
 	var commentID int
 
-	const query = `INSERT INTO comments (ArticleID, UserID, Content, CreatedAt) 
-	          VALUES($1, $2, $3, $4) RETURNING ID`
+	const query = `INSERT INTO comments (ArticleID, UserID, Content, CreatedAt, Status, AuthorName) 
+	          VALUES($1, $2, $3, $4, $5, $6) RETURNING ID`
 
 	// Check db connection
 	if db.ConnPool == nil {
@@ -189,16 +238,34 @@ func (db *Client) CreateComment(ctx context.Context, comment models.Comment) (in
 	}
 
 	// Execute query
-	err := db.ConnPool.QueryRow(ctx, query, comment.ArticleID, comment.UserID, comment.Content, comment.CreatedAt).Scan(&commentID)
+	err := pgx.BeginFunc(ctx, db.ConnPool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query, comment.ArticleID, comment.UserID, comment.Content, comment.CreatedAt, status,
+			event.AuthorName).Scan(&commentID)
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+
+		event.CommentID = commentID
+		event.ArticleID = comment.ArticleID
+		event.AuthorID = comment.UserID
+		event.Held = comment.Held
+
+		return insertEvent(ctx, tx, models.EventCommentCreated, event)
+	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to execute query: %w", err)
+		return 0, err
 	}
 
 	return commentID, nil
 }
 
-func (db *Client) DeleteComment(ctx context.Context, commentID int) (pgconn.CommandTag, error) {
-	const query = `DELETE FROM comments WHERE id = $1`
+/*
+UpdateComment changes the content of a comment.
+@params
+hold - the content filter held the new content, the comment waits for review again.
+*/
+func (db *Client) UpdateComment(ctx context.Context, commentID int, content string, hold bool) (pgconn.CommandTag, error) {
+	const query = `UPDATE comments SET Content = $2 WHERE id = $1`
 
 	// Check db connection
 	if db.ConnPool == nil {
@@ -206,14 +273,71 @@ func (db *Client) DeleteComment(ctx context.Context, commentID int) (pgconn.Comm
 	}
 
 	// Execute query
-	commandTag, err := db.ConnPool.Exec(ctx, query, commentID)
+	var commandTag pgconn.CommandTag
+	err := pgx.BeginFunc(ctx, db.ConnPool, func(tx pgx.Tx) error {
+		var err error
+		commandTag, err = tx.Exec(ctx, query, commentID, content)
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+
+		if !hold || commandTag.RowsAffected() == 0 {
+			return nil
+		}
+
+		return setCommentStatus(ctx, tx, commentID, models.CommentHeld)
+	})
 	if err != nil {
-		return pgconn.CommandTag{}, fmt.Errorf("failed to execute query: %w", err)
+		return pgconn.CommandTag{}, err
 	}
 
 	return commandTag, nil
 }
 
+// DeleteComment removes a comment, its comment.deleted event removes the likes.
+func (db *Client) DeleteComment(ctx context.Context, commentID int) (pgconn.CommandTag, error) {
+	// Check db connection
+	if db.ConnPool == nil {
+		return pgconn.CommandTag{}, fmt.Errorf("unable to connect to database")
+	}
+
+	// Execute query
+	var commandTag pgconn.CommandTag
+	err := pgx.BeginFunc(ctx, db.ConnPool, func(tx pgx.Tx) error {
+		var err error
+		commandTag, err = deleteComment(ctx, tx, commentID)
+		return err
+	})
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+
+	return commandTag, nil
+}
+
+// deleteComment removes a comment inside tx, with the event it causes.
+func deleteComment(ctx context.Context, tx pgx.Tx, commentID int) (pgconn.CommandTag, error) {
+	const query = `DELETE FROM comments WHERE id = $1
+	          RETURNING ArticleID, UserID, (SELECT COUNT(*) FROM likes WHERE CommentID = $1), Status <> 'visible'`
+
+	rows, err := tx.Query(ctx, query, commentID)
+	if err != nil {
+		return pgconn.CommandTag{}, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	event := models.CommentEvent{CommentID: commentID}
+	commandTag, err := pgx.ForEachRow(rows, []any{&event.ArticleID, &event.AuthorID, &event.Likes, &event.Hidden}, func() error { return nil })
+	if err != nil {
+		return pgconn.CommandTag{}, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return commandTag, nil
+	}
+
+	return commandTag, insertEvent(ctx, tx, models.EventCommentDeleted, event)
+}
+
 func (db *Client) FindLike(ctx context.Context, commentID, userID int) (*models.Like, error) {
 	const query = `SELECT id FROM likes WHERE CommentID = $1 AND UserID = $2`
 
@@ -236,9 +360,14 @@ func (db *Client) FindLike(ctx context.Context, commentID, userID int) (*models.
 	return like, nil
 }
 
-func (db *Client) AddLike(ctx context.Context, like models.Like) (int, error) {
+/*
+AddLike stores a like with its like.added event, ErrAlreadyLiked when the user already likes the comment.
+@params
+event - what the handlers need beyond the like, the name of the user. The other fields are filled in.
+*/
+func (db *Client) AddLike(ctx context.Context, like models.Like, event models.LikeEvent) (int, error) {
 	const query = `INSERT INTO likes (CommentID, UserID) 
-	          VALUES($1, $2) RETURNING id`
+	          VALUES($1, $2) RETURNING id, (SELECT ArticleID FROM comments WHERE ID = $1), (SELECT UserID FROM comments WHERE ID = $1)`
 
 	// Check db connection
 	if db.ConnPool == nil {
@@ -248,16 +377,41 @@ func (db *Client) AddLike(ctx context.Context, like models.Like) (int, error) {
 	var id int
 
 	// Execute query
-	err := db.ConnPool.QueryRow(ctx, query, like.CommentID, like.UserID).Scan(&id)
+	err := pgx.BeginFunc(ctx, db.ConnPool, func(tx pgx.Tx) error {
+		var articleID *string
+		var commentAuthorID *int
+		err := tx.QueryRow(ctx, query, like.CommentID, like.UserID).Scan(&id, &articleID, &commentAuthorID)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == codeUniqueViolation {
+			return ErrAlreadyLiked
+		}
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+
+		// A like on a comment deleted meanwhile is removed along with its likes
+		if articleID == nil || commentAuthorID == nil {
+			return nil
+		}
+
+		event.CommentID = like.CommentID
+		event.UserID = like.UserID
+		event.ArticleID = *articleID
+		event.CommentAuthorID = *commentAuthorID
+
+		return insertEvent(ctx, tx, models.EventLikeAdded, event)
+	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to execute query: %w", err)
+		return 0, err
 	}
 
 	return id, nil
 }
 
+// RemoveLike removes the like of a user on a comment with its like.removed event.
 func (db *Client) RemoveLike(ctx context.Context, commentID, userID int) (pgconn.CommandTag, error) {
-	const query = `DELETE FROM likes WHERE CommentID = $1 AND UserID = $2`
+	const query = `DELETE FROM likes WHERE CommentID = $1 AND UserID = $2
+	          RETURNING (SELECT ArticleID FROM comments WHERE ID = $1), (SELECT UserID FROM comments WHERE ID = $1)`
 
 	// Check db connection
 	if db.ConnPool == nil {
@@ -265,9 +419,32 @@ func (db *Client) RemoveLike(ctx context.Context, commentID, userID int) (pgconn
 	}
 
 	// Execute query
-	commandTag, err := db.ConnPool.Exec(ctx, query, commentID, userID)
+	var commandTag pgconn.CommandTag
+	err := pgx.BeginFunc(ctx, db.ConnPool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, commentID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+
+		event := models.LikeEvent{CommentID: commentID, UserID: userID}
+		var articleID *string
+		var commentAuthorID *int
+		commandTag, err = pgx.ForEachRow(rows, []any{&articleID, &commentAuthorID}, func() error { return nil })
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+
+		// The likes of a comment deleted meanwhile are removed by its comment.deleted event
+		if commandTag.RowsAffected() == 0 || articleID == nil || commentAuthorID == nil {
+			return nil
+		}
+		event.ArticleID = *articleID
+		event.CommentAuthorID = *commentAuthorID
+
+		return insertEvent(ctx, tx, models.EventLikeRemoved, event)
+	})
 	if err != nil {
-		return pgconn.CommandTag{}, fmt.Errorf("failed to execute query: %w", err)
+		return pgconn.CommandTag{}, err
 	}
 
 	return commandTag, nil
@@ -5,11 +5,14 @@ import (
 	mongomodels "blog-service/internal/db/mongo/models"
 	postgresmodels "blog-service/internal/db/postgres/models"
 	"blog-service/internal/db/testutil"
+	"blog-service/internal/migrate"
 
 	"log"
 	"os"
+	"strconv"
 
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -31,7 +34,7 @@ func setupMongoContainer(ctx context.Context) (testcontainers.Container, *mongo.
 	os.Setenv("MONGO_PORT", port) // set port env variable with obtained port
 
 	// Instantiate new mongo client and defer disconnection
-	mongoClient, err := mongo.NewMongoClient()
+	mongoClient, err := mongo.NewMongoClient(mongo.Config{URI: os.Getenv("MONGO_URI"), Database: os.Getenv("MONGO_DB")})
 	if err != nil {
 		log.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
@@ -46,14 +49,31 @@ func setupPostgresContainer(ctx context.Context) (testcontainers.Container, *Cli
 		return nil, nil, fmt.Errorf("failed to start container: %s", err)
 	}
 
-	os.Setenv("PG_PORT", port) // set port env variable with obtained port
+	pgPort, err := strconv.Atoi(port)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid container port %q: %w", port, err)
+	}
 
-	// Instantiate new postgres client
-	postgresClient, err := NewPostgresClient()
+	// Instantiate new postgres client on the obtained port
+	postgresClient, err := NewPostgresClient(Config{
+		Host:     os.Getenv("PG_HOST"),
+		Port:     pgPort,
+		User:     os.Getenv("PG_USER"),
+		Password: os.Getenv("PG_PASSWORD"),
+		Dbname:   os.Getenv("PG_DB"),
+	})
 	if err != nil {
 		return nil, nil, fmt.Errorf("Failed to create postgres client: %w", err)
 	}
 
+	migrator, err := migrate.New(postgresClient.ConnPool, "blog-service", Migrations())
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to read migrations: %w", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		return nil, nil, fmt.Errorf("Failed to migrate: %w", err)
+	}
+
 	return postgresContainer, postgresClient, nil
 }
 
@@ -112,7 +132,7 @@ func TestAddComment(t *testing.T) {
 	ctx := context.Background()
 	comment := generateRandomComment()
 
-	id, err := postgresClient.CreateComment(ctx, *comment)
+	id, err := postgresClient.CreateComment(ctx, *comment, postgresmodels.CommentEvent{})
 	if err != nil {
 		t.Errorf("Failed to add comment: %s", err)
 	}
@@ -123,6 +143,7 @@ func TestAddComment(t *testing.T) {
 	}
 
 	comment.ID = id
+	comment.Status = postgresmodels.CommentVisible
 
 	// Set time values for comments to zero as a workaround
 	// for differing between db and golang.
@@ -142,7 +163,7 @@ func TestDeleteComment(t *testing.T) {
 		CreatedAt: time.Now(),
 	}
 
-	id, err := postgresClient.CreateComment(ctx, comment)
+	id, err := postgresClient.CreateComment(ctx, comment, postgresmodels.CommentEvent{})
 	if err != nil {
 		t.Errorf("Failed to add comment: %s", err)
 	}
@@ -161,7 +182,7 @@ func TestGetComments(t *testing.T) {
 
 	for range nrOfComments {
 		comment := generateRandomComment()
-		id, err := postgresClient.CreateComment(ctx, *comment)
+		id, err := postgresClient.CreateComment(ctx, *comment, postgresmodels.CommentEvent{})
 		if err != nil {
 			t.Errorf("Failed to add comment: %s", err)
 		}
@@ -189,7 +210,7 @@ func TestAddLike(t *testing.T) {
 
 	comment := generateRandomComment()
 
-	id, err := postgresClient.CreateComment(ctx, *comment)
+	id, err := postgresClient.CreateComment(ctx, *comment, postgresmodels.CommentEvent{})
 	if err != nil {
 		t.Errorf("Failed to add comment: %s", err)
 	}
@@ -199,7 +220,7 @@ func TestAddLike(t *testing.T) {
 		UserID:    1,
 	}
 
-	id, err = postgresClient.AddLike(ctx, like)
+	id, err = postgresClient.AddLike(ctx, like, postgresmodels.LikeEvent{})
 	require.NoError(t, err, "failed to add like: %s", err)
 
 	like.ID = id
@@ -210,12 +231,30 @@ func TestAddLike(t *testing.T) {
 	require.Equal(t, &like, addedLike)
 }
 
+func TestAddLikeTwice(t *testing.T) {
+	ctx := context.Background()
+
+	id, err := postgresClient.CreateComment(ctx, *generateRandomComment(), postgresmodels.CommentEvent{})
+	require.NoError(t, err)
+
+	like := postgresmodels.Like{CommentID: id, UserID: 2}
+	_, err = postgresClient.AddLike(ctx, like, postgresmodels.LikeEvent{})
+	require.NoError(t, err)
+
+	_, err = postgresClient.AddLike(ctx, like, postgresmodels.LikeEvent{})
+	require.ErrorIs(t, err, ErrAlreadyLiked)
+
+	count, err := postgresClient.GetCommentLikeCount(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
+
 func TestRemoveLike(t *testing.T) {
 	ctx := context.Background()
 
 	comment := generateRandomComment()
 
-	id, err := postgresClient.CreateComment(ctx, *comment)
+	id, err := postgresClient.CreateComment(ctx, *comment, postgresmodels.CommentEvent{})
 	if err != nil {
 		t.Errorf("Failed to add comment: %s", err)
 	}
@@ -225,7 +264,7 @@ func TestRemoveLike(t *testing.T) {
 		UserID:    1,
 	}
 
-	id, err = postgresClient.AddLike(ctx, like)
+	id, err = postgresClient.AddLike(ctx, like, postgresmodels.LikeEvent{})
 	require.NoError(t, err, "failed to add like: %s", err)
 
 	like.ID = id
@@ -236,3 +275,454 @@ func TestRemoveLike(t *testing.T) {
 	addedLike, err := postgresClient.FindLike(ctx, like.CommentID, like.UserID)
 	require.Error(t, err, "found like, but it should have been deleted: %v", addedLike)
 }
+
+func TestFollows(t *testing.T) {
+	ctx := context.Background()
+
+	created, err := postgresClient.FollowAuthor(ctx, 10, 20)
+	require.NoError(t, err, "failed to follow author: %s", err)
+	require.True(t, created)
+
+	created, err = postgresClient.FollowAuthor(ctx, 10, 20)
+	require.NoError(t, err, "failed to repeat follow: %s", err)
+	require.False(t, created, "repeated follow should not add a row")
+
+	_, err = postgresClient.FollowAuthor(ctx, 11, 20)
+	require.NoError(t, err, "failed to follow author: %s", err)
+
+	_, err = postgresClient.FollowCategory(ctx, 10, "Go")
+	require.NoError(t, err, "failed to follow category: %s", err)
+
+	authors, err := postgresClient.GetFollowedAuthors(ctx, 10)
+	require.NoError(t, err, "failed to get followed authors: %s", err)
+	require.Equal(t, []int{20}, authors)
+
+	categories, err := postgresClient.GetFollowedCategories(ctx, 10)
+	require.NoError(t, err, "failed to get followed categories: %s", err)
+	require.Equal(t, []string{"Go"}, categories)
+
+	counts, err := postgresClient.GetFollowCounts(ctx, 20)
+	require.NoError(t, err, "failed to count followers: %s", err)
+	require.Equal(t, 2, counts.Followers)
+
+	removed, err := postgresClient.UnfollowAuthor(ctx, 10, 20)
+	require.NoError(t, err, "failed to unfollow author: %s", err)
+	require.True(t, removed)
+
+	following, err := postgresClient.IsFollowingAuthor(ctx, 10, 20)
+	require.NoError(t, err, "failed to check follow: %s", err)
+	require.False(t, following)
+}
+
+func TestNotifications(t *testing.T) {
+	ctx := context.Background()
+
+	for range 3 {
+		notification := &postgresmodels.Notification{
+			Type:        postgresmodels.NotificationComment,
+			RecipientID: 30,
+			ActorID:     1,
+			ActorName:   "reader",
+			ArticleID:   postgresTestArticle.ID.Hex(),
+		}
+		err := postgresClient.InsertNotification(ctx, notification)
+		require.NoError(t, err, "failed to insert notification: %s", err)
+		require.NotZero(t, notification.ID)
+	}
+
+	notifications, err := postgresClient.GetNotifications(ctx, 30, 0, 10, false)
+	require.NoError(t, err, "failed to get notifications: %s", err)
+	require.Len(t, notifications, 3)
+	require.Greater(t, notifications[0].ID, notifications[1].ID, "notifications should be newest first")
+
+	changed, err := postgresClient.MarkNotificationsRead(ctx, 30, []int{notifications[0].ID})
+	require.NoError(t, err, "failed to mark notification read: %s", err)
+	require.EqualValues(t, 1, changed)
+
+	unread, err := postgresClient.CountUnreadNotifications(ctx, 30)
+	require.NoError(t, err, "failed to count unread notifications: %s", err)
+	require.Equal(t, 2, unread)
+
+	_, err = postgresClient.MarkNotificationsRead(ctx, 30, nil)
+	require.NoError(t, err, "failed to mark all notifications read: %s", err)
+
+	unreadOnly, err := postgresClient.GetNotifications(ctx, 30, 0, 10, true)
+	require.NoError(t, err, "failed to get unread notifications: %s", err)
+	require.Empty(t, unreadOnly)
+}
+
+func TestUpdateComment(t *testing.T) {
+	ctx := context.Background()
+	comment := generateRandomComment()
+
+	id, err := postgresClient.CreateComment(ctx, *comment, postgresmodels.CommentEvent{})
+	require.NoError(t, err, "failed to add comment: %s", err)
+
+	content := testutil.GenerateRandomString()
+	_, err = postgresClient.UpdateComment(ctx, id, content, false)
+	require.NoError(t, err, "failed to update comment: %s", err)
+
+	updatedComment, err := postgresClient.GetComment(ctx, id)
+	require.NoError(t, err, "failed to get comment: %s", err)
+	require.Equal(t, content, updatedComment.Content)
+}
+
+func TestUpdateCommentHeld(t *testing.T) {
+	ctx := context.Background()
+
+	id, err := postgresClient.CreateComment(ctx, *generateRandomComment(), postgresmodels.CommentEvent{})
+	require.NoError(t, err)
+
+	_, err = postgresClient.UpdateComment(ctx, id, testutil.GenerateRandomString(), true)
+	require.NoError(t, err)
+
+	held, err := postgresClient.GetComment(ctx, id)
+	require.NoError(t, err)
+	require.True(t, held.Held)
+	require.Contains(t, commentEvents(t, id), postgresmodels.EventCommentHidden, "a held comment no longer counts towards engagement")
+}
+
+func TestBookmarks(t *testing.T) {
+	ctx := context.Background()
+	articleIDs := []string{"000000000000000000000001", "000000000000000000000002", "000000000000000000000003"}
+
+	for _, articleID := range articleIDs {
+		created, err := postgresClient.AddBookmark(ctx, 40, articleID)
+		require.NoError(t, err, "failed to add bookmark: %s", err)
+		require.True(t, created)
+	}
+
+	created, err := postgresClient.AddBookmark(ctx, 40, articleIDs[0])
+	require.NoError(t, err, "failed to repeat bookmark: %s", err)
+	require.False(t, created, "repeated bookmark should not add a row")
+
+	firstPage, err := postgresClient.GetBookmarks(ctx, 40, 0, 2)
+	require.NoError(t, err, "failed to get bookmarks: %s", err)
+	require.Len(t, firstPage, 2)
+	require.Equal(t, articleIDs[2], firstPage[0].ArticleID, "bookmarks should be newest first")
+
+	secondPage, err := postgresClient.GetBookmarks(ctx, 40, firstPage[1].ID, 2)
+	require.NoError(t, err, "failed to get bookmarks: %s", err)
+	require.Len(t, secondPage, 1)
+	require.Equal(t, articleIDs[0], secondPage[0].ArticleID)
+
+	_, err = postgresClient.RemoveBookmarks(ctx, 40, []string{articleIDs[1]})
+	require.NoError(t, err, "failed to remove bookmark: %s", err)
+
+	bookmarked, err := postgresClient.IsBookmarked(ctx, 40, articleIDs[1])
+	require.NoError(t, err, "failed to check bookmark: %s", err)
+	require.False(t, bookmarked)
+}
+
+func TestReportsAndModeration(t *testing.T) {
+	ctx := context.Background()
+	targetID := "moderation-test"
+
+	for reporter := 1; reporter <= 2; reporter++ {
+		report := &postgresmodels.Report{
+			TargetType: postgresmodels.TargetArticle,
+			TargetID:   targetID,
+			ReporterID: reporter,
+			Reason:     "spam",
+		}
+		item, created, err := postgresClient.AddReport(ctx, report, 50)
+		require.NoError(t, err, "failed to add report: %s", err)
+		require.True(t, created)
+		require.Equal(t, reporter, item.ReportCount)
+	}
+
+	duplicate := &postgresmodels.Report{
+		TargetType: postgresmodels.TargetArticle,
+		TargetID:   targetID,
+		ReporterID: 1,
+		Reason:     "harassment",
+	}
+	item, created, err := postgresClient.AddReport(ctx, duplicate, 50)
+	require.NoError(t, err, "failed to repeat report: %s", err)
+	require.False(t, created, "a user should only count once")
+	require.Equal(t, 2, item.ReportCount)
+
+	queue, err := postgresClient.GetModerationQueue(ctx, postgresmodels.ModerationFilter{Reason: "spam"}, 10, 1)
+	require.NoError(t, err, "failed to get moderation queue: %s", err)
+	require.NotEmpty(t, queue)
+	require.Equal(t, 2, queue[0].Reasons["spam"])
+
+	action := &postgresmodels.ModerationAction{
+		TargetType: postgresmodels.TargetArticle,
+		TargetID:   targetID,
+		ActorID:    7,
+		Action:     "hide",
+	}
+	err = postgresClient.RecordModerationAction(ctx, action, 50, postgresmodels.ModerationHidden, "published")
+	require.NoError(t, err, "failed to record moderation action: %s", err)
+
+	item, err = postgresClient.GetModerationItem(ctx, postgresmodels.TargetArticle, targetID)
+	require.NoError(t, err, "failed to get moderation item: %s", err)
+	require.Equal(t, postgresmodels.ModerationHidden, item.Status)
+	require.Equal(t, "published", item.PreviousStatus)
+	require.Equal(t, item.ReportCount, item.ReviewedCount, "a moderator action reviews the reports")
+
+	actions, err := postgresClient.GetModerationActions(ctx, postgresmodels.TargetArticle, targetID, 10, 1)
+	require.NoError(t, err, "failed to get audit trail: %s", err)
+	require.Len(t, actions, 1)
+	require.Equal(t, "hide", actions[0].Action)
+}
+
+func TestModerateOnlyMovesFromTheReadStatus(t *testing.T) {
+	ctx := context.Background()
+	targetID := "moderate-test"
+
+	report := &postgresmodels.Report{TargetType: postgresmodels.TargetArticle, TargetID: targetID, ReporterID: 1, Reason: "spam"}
+	item, _, err := postgresClient.AddReport(ctx, report, 50)
+	require.NoError(t, err, "failed to add report: %s", err)
+	require.Equal(t, postgresmodels.ModerationOpen, item.Status)
+
+	hide := func(actorID int) *ModerationChange {
+		return &ModerationChange{
+			Action: &postgresmodels.ModerationAction{
+				TargetType: postgresmodels.TargetArticle, TargetID: targetID, ActorID: actorID, Action: "hide",
+			},
+			From:     postgresmodels.ModerationOpen,
+			To:       postgresmodels.ModerationHidden,
+			AuthorID: 50,
+		}
+	}
+
+	require.NoError(t, postgresClient.Moderate(ctx, hide(7)))
+
+	// A second decision based on the open item it read is refused and leaves no trace
+	err = postgresClient.Moderate(ctx, hide(0))
+	require.ErrorIs(t, err, ErrModerationConflict)
+
+	// A failing write in the other store rolls the decision back
+	restore := &ModerationChange{
+		Action: &postgresmodels.ModerationAction{
+			TargetType: postgresmodels.TargetArticle, TargetID: targetID, ActorID: 7, Action: "restore",
+		},
+		From:     postgresmodels.ModerationHidden,
+		To:       postgresmodels.ModerationResolved,
+		AuthorID: 50,
+		Apply:    func(context.Context) error { return fmt.Errorf("mongo is down") },
+	}
+	require.Error(t, postgresClient.Moderate(ctx, restore))
+
+	item, err = postgresClient.GetModerationItem(ctx, postgresmodels.TargetArticle, targetID)
+	require.NoError(t, err, "failed to get moderation item: %s", err)
+	require.Equal(t, postgresmodels.ModerationHidden, item.Status)
+
+	actions, err := postgresClient.GetModerationActions(ctx, postgresmodels.TargetArticle, targetID, 10, 1)
+	require.NoError(t, err, "failed to get audit trail: %s", err)
+	require.Len(t, actions, 1)
+}
+
+func TestHeldComments(t *testing.T) {
+	ctx := context.Background()
+	articleID := "held-comments-test"
+
+	visible := generateRandomComment()
+	visible.ArticleID = articleID
+	_, err := postgresClient.CreateComment(ctx, *visible, postgresmodels.CommentEvent{})
+	require.NoError(t, err, "failed to add comment: %s", err)
+
+	held := generateRandomComment()
+	held.ArticleID = articleID
+	held.UserID = 2
+	held.Held = true
+	heldID, err := postgresClient.CreateComment(ctx, *held, postgresmodels.CommentEvent{})
+	require.NoError(t, err, "failed to add held comment: %s", err)
+
+	comments, err := postgresClient.GetComments(ctx, articleID, 10, 1)
+	require.NoError(t, err, "failed to get comments: %s", err)
+	require.Len(t, comments, 1, "held comments should be hidden from readers")
+
+	comments, err = postgresClient.GetCommentsForViewer(ctx, articleID, 2, false, 10, 1)
+	require.NoError(t, err, "failed to get comments: %s", err)
+	require.Len(t, comments, 2, "the author should see their held comment")
+
+	count, err := postgresClient.GetCommentsCountForViewer(ctx, articleID, 0, true)
+	require.NoError(t, err, "failed to count comments: %s", err)
+	require.Equal(t, 2, count, "moderators should see held comments")
+
+	comment, err := postgresClient.GetComment(ctx, heldID)
+	require.NoError(t, err, "failed to get comment: %s", err)
+	require.True(t, comment.Held)
+
+	found, err := postgresClient.HasRecentComment(ctx, 2, held.Content, time.Now().Add(-time.Hour))
+	require.NoError(t, err, "failed to look for duplicates: %s", err)
+	require.True(t, found)
+}
+
+// commentEvents claims the pending events about a comment, keyed by type.
+func commentEvents(t *testing.T, commentID int) map[string]postgresmodels.CommentEvent {
+	t.Helper()
+
+	events, err := postgresClient.ClaimEvents(context.Background(), 1000, time.Minute)
+	require.NoError(t, err)
+
+	found := map[string]postgresmodels.CommentEvent{}
+	for _, event := range events {
+		var payload postgresmodels.CommentEvent
+		if json.Unmarshal(event.Payload, &payload) == nil && payload.CommentID == commentID {
+			found[event.Type] = payload
+		}
+	}
+
+	return found
+}
+
+func TestCommentStatusEvents(t *testing.T) {
+	ctx := context.Background()
+
+	held := generateRandomComment()
+	held.Held = true
+	id, err := postgresClient.CreateComment(ctx, *held, postgresmodels.CommentEvent{AuthorName: "author"})
+	require.NoError(t, err)
+
+	require.NoError(t, postgresClient.SetCommentStatus(ctx, id, postgresmodels.CommentVisible))
+	restored, ok := commentEvents(t, id)[postgresmodels.EventCommentRestored]
+	require.True(t, ok, "restoring a held comment should record comment.restored")
+	require.True(t, restored.Held)
+	require.Equal(t, "author", restored.AuthorName, "a restored held comment is announced with its author")
+
+	require.NoError(t, postgresClient.SetCommentStatus(ctx, id, postgresmodels.CommentHidden))
+	require.Contains(t, commentEvents(t, id), postgresmodels.EventCommentHidden)
+
+	hidden, err := postgresClient.GetComment(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, postgresmodels.CommentHidden, hidden.Status)
+
+	// Hiding it again changes nothing
+	require.NoError(t, postgresClient.SetCommentStatus(ctx, id, postgresmodels.CommentHidden))
+	require.Empty(t, commentEvents(t, id))
+
+	_, err = postgresClient.DeleteComment(ctx, id)
+	require.NoError(t, err)
+	deleted, ok := commentEvents(t, id)[postgresmodels.EventCommentDeleted]
+	require.True(t, ok)
+	require.True(t, deleted.Hidden, "a hidden comment no longer counts towards engagement")
+}
+
+func TestCommentEventIsRecordedAndDelivered(t *testing.T) {
+	ctx := context.Background()
+	comment := generateRandomComment()
+
+	id, err := postgresClient.CreateComment(ctx, *comment, postgresmodels.CommentEvent{AuthorName: "author", PublisherID: 7})
+	require.NoError(t, err)
+
+	events, err := postgresClient.ClaimEvents(ctx, 1000, time.Minute)
+	require.NoError(t, err)
+
+	var recorded *postgresmodels.OutboxEvent
+	for i, event := range events {
+		var payload postgresmodels.CommentEvent
+		require.NoError(t, json.Unmarshal(event.Payload, &payload))
+		if event.Type == postgresmodels.EventCommentCreated && payload.CommentID == id {
+			require.Equal(t, "author", payload.AuthorName)
+			require.Equal(t, comment.ArticleID, payload.ArticleID)
+			recorded = &events[i]
+		}
+	}
+	require.NotNil(t, recorded, "comment.created event of comment %d was not recorded", id)
+	require.Equal(t, 1, recorded.Attempts)
+	require.NotEmpty(t, recorded.Key)
+
+	owned, err := postgresClient.ExtendEventLease(ctx, recorded.ID, recorded.Attempts, time.Minute)
+	require.NoError(t, err)
+	require.True(t, owned, "the relay that claimed the event owns it")
+
+	owned, err = postgresClient.ExtendEventLease(ctx, recorded.ID, recorded.Attempts-1, time.Minute)
+	require.NoError(t, err)
+	require.False(t, owned, "a relay holding an older claim no longer owns the event")
+
+	require.NoError(t, postgresClient.MarkEventHandled(ctx, recorded.ID, "engagement"))
+	require.NoError(t, postgresClient.MarkEventDelivered(ctx, recorded.ID))
+
+	// Neither delivered nor leased events are claimed again
+	again, err := postgresClient.ClaimEvents(ctx, 1000, time.Minute)
+	require.NoError(t, err)
+	for _, event := range again {
+		require.NotEqual(t, recorded.ID, event.ID)
+	}
+}
+
+func TestCountEngagement(t *testing.T) {
+	ctx := context.Background()
+	articleID := "engagement-test"
+
+	commentIDs := []int{}
+	for range 2 {
+		comment := generateRandomComment()
+		comment.ArticleID = articleID
+		id, err := postgresClient.CreateComment(ctx, *comment, postgresmodels.CommentEvent{})
+		require.NoError(t, err, "failed to add comment: %s", err)
+		commentIDs = append(commentIDs, id)
+	}
+
+	for userID := 1; userID <= 2; userID++ {
+		_, err := postgresClient.AddLike(ctx, postgresmodels.Like{CommentID: commentIDs[0], UserID: userID}, postgresmodels.LikeEvent{})
+		require.NoError(t, err, "failed to add like: %s", err)
+	}
+	_, err := postgresClient.AddLike(ctx, postgresmodels.Like{CommentID: commentIDs[1], UserID: 1}, postgresmodels.LikeEvent{})
+	require.NoError(t, err, "failed to add like: %s", err)
+
+	// A hidden comment no longer counts, its likes still do
+	require.NoError(t, postgresClient.SetCommentStatus(ctx, commentIDs[1], postgresmodels.CommentHidden))
+
+	engagement, countedAt, err := postgresClient.CountEngagement(ctx, articleID)
+	require.NoError(t, err, "failed to count engagement: %s", err)
+	require.Equal(t, 4, engagement)
+
+	_, later, err := postgresClient.CountEngagement(ctx, articleID)
+	require.NoError(t, err, "failed to count engagement: %s", err)
+	require.Greater(t, later, countedAt)
+}
+
+func TestUserDeletionJob(t *testing.T) {
+	ctx := context.Background()
+	userID := 9001
+
+	comment := generateRandomComment()
+	comment.UserID = userID
+	commentID, err := postgresClient.CreateComment(ctx, *comment, postgresmodels.CommentEvent{})
+	require.NoError(t, err)
+
+	job := &postgresmodels.UserDeletionJob{ID: 42, UserID: userID, Mode: postgresmodels.DeletionAnonymise}
+	require.NoError(t, postgresClient.StartUserDeletionJob(ctx, job))
+	require.Empty(t, job.Outcome)
+
+	count, err := postgresClient.AnonymiseUserComments(ctx, userID, 0, "deleted user")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+	require.NoError(t, postgresClient.RecordUserDeletionStep(ctx, job.ID, "comments", count))
+
+	anonymised, err := postgresClient.GetComment(ctx, commentID)
+	require.NoError(t, err)
+	require.Equal(t, 0, anonymised.UserID)
+
+	// Starting again resumes with the recorded steps
+	resumed := &postgresmodels.UserDeletionJob{ID: job.ID, UserID: userID, Mode: postgresmodels.DeletionAnonymise}
+	require.NoError(t, postgresClient.StartUserDeletionJob(ctx, resumed))
+	require.Equal(t, map[string]int64{"comments": 1}, resumed.Outcome)
+	require.Nil(t, resumed.CompletedAt)
+
+	// Completing twice records a single user.deleted event
+	require.NoError(t, postgresClient.CompleteUserDeletionJob(ctx, resumed))
+	require.NoError(t, postgresClient.CompleteUserDeletionJob(ctx, resumed))
+
+	events, err := postgresClient.ClaimEvents(ctx, 1000, time.Minute)
+	require.NoError(t, err)
+
+	recorded := 0
+	for _, event := range events {
+		var payload postgresmodels.UserEvent
+		require.NoError(t, json.Unmarshal(event.Payload, &payload))
+		if event.Type == postgresmodels.EventUserDeleted && payload.UserID == userID {
+			require.Equal(t, postgresmodels.DeletionAnonymise, payload.Mode)
+			recorded++
+		}
+	}
+	require.Equal(t, 1, recorded)
+
+	require.NoError(t, postgresClient.StartUserDeletionJob(ctx, resumed))
+	require.NotNil(t, resumed.CompletedAt)
+}
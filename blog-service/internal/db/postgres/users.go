@@ -0,0 +1,202 @@
+package postgres
+
+import (
+	"blog-service/internal/db/postgres/models"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+/*
+StartUserDeletionJob records the job unless it exists, then loads its progress into job.
+A job claimed again after a crash keeps the outcome of the steps that already finished.
+*/
+func (db *Client) StartUserDeletionJob(ctx context.Context, job *models.UserDeletionJob) error {
+	const insert = `INSERT INTO user_deletion_jobs (ID, UserID, Mode) VALUES ($1, $2, $3)
+	          ON CONFLICT (ID) DO NOTHING`
+	const query = `SELECT Outcome, CreatedAt, CompletedAt FROM user_deletion_jobs WHERE ID = $1`
+
+	if _, err := db.ConnPool.Exec(ctx, insert, job.ID, job.UserID, job.Mode); err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	var outcome []byte
+	if err := db.ConnPool.QueryRow(ctx, query, job.ID).Scan(&outcome, &job.CreatedAt, &job.CompletedAt); err != nil {
+		return fmt.Errorf("failed to retrieve row: %w", err)
+	}
+
+	if err := json.Unmarshal(outcome, &job.Outcome); err != nil {
+		return fmt.Errorf("invalid outcome of user deletion %d: %w", job.ID, err)
+	}
+
+	return nil
+}
+
+// RecordUserDeletionStep records that step finished after changing count rows or documents.
+func (db *Client) RecordUserDeletionStep(ctx context.Context, id int64, step string, count int64) error {
+	const query = `UPDATE user_deletion_jobs SET Outcome = Outcome || jsonb_build_object($2::TEXT, $3::BIGINT)
+	          WHERE ID = $1`
+
+	if _, err := db.ConnPool.Exec(ctx, query, id, step, count); err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+// CompleteUserDeletionJob marks the job done with its user.deleted event, completing it again records nothing.
+func (db *Client) CompleteUserDeletionJob(ctx context.Context, job *models.UserDeletionJob) error {
+	const query = `UPDATE user_deletion_jobs SET CompletedAt = NOW()
+	          WHERE ID = $1 AND CompletedAt IS NULL`
+
+	return pgx.BeginFunc(ctx, db.ConnPool, func(tx pgx.Tx) error {
+		commandTag, err := tx.Exec(ctx, query, job.ID)
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+		if commandTag.RowsAffected() == 0 {
+			return nil
+		}
+
+		return insertEvent(ctx, tx, models.EventUserDeleted, models.UserEvent{Mode: job.Mode, UserID: job.UserID})
+	})
+}
+
+// DeleteUserLikes removes every like of a user, with their like.removed events.
+func (db *Client) DeleteUserLikes(ctx context.Context, userID int) (int64, error) {
+	const query = `DELETE FROM likes l USING comments c
+	          WHERE l.UserID = $1 AND c.ID = l.CommentID
+	          RETURNING l.CommentID, c.ArticleID, c.UserID`
+	// Likes of comments deleted meanwhile are left to the comment.deleted event
+	const orphans = `DELETE FROM likes WHERE UserID = $1`
+
+	var count int64
+	err := pgx.BeginFunc(ctx, db.ConnPool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, userID)
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+
+		event := models.LikeEvent{UserID: userID}
+		likes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.LikeEvent, error) {
+			err := row.Scan(&event.CommentID, &event.ArticleID, &event.CommentAuthorID)
+			return event, err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to retrieve row: %w", err)
+		}
+
+		for _, like := range likes {
+			if err := insertEvent(ctx, tx, models.EventLikeRemoved, like); err != nil {
+				return err
+			}
+		}
+
+		commandTag, err := tx.Exec(ctx, orphans, userID)
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+		count = int64(len(likes)) + commandTag.RowsAffected()
+
+		return nil
+	})
+
+	return count, err
+}
+
+// DeleteUserComments removes every comment of a user, with their comment.deleted events.
+func (db *Client) DeleteUserComments(ctx context.Context, userID int) (int64, error) {
+	const query = `DELETE FROM comments c WHERE c.UserID = $1
+	          RETURNING c.ID, c.ArticleID, (SELECT COUNT(*) FROM likes WHERE CommentID = c.ID), c.Status <> 'visible'`
+
+	var count int64
+	err := pgx.BeginFunc(ctx, db.ConnPool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, userID)
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+
+		event := models.CommentEvent{AuthorID: userID}
+		comments, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.CommentEvent, error) {
+			err := row.Scan(&event.CommentID, &event.ArticleID, &event.Likes, &event.Hidden)
+			return event, err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to retrieve row: %w", err)
+		}
+
+		for _, comment := range comments {
+			if err := insertEvent(ctx, tx, models.EventCommentDeleted, comment); err != nil {
+				return err
+			}
+		}
+		count = int64(len(comments))
+
+		return nil
+	})
+
+	return count, err
+}
+
+/*
+AnonymiseUserComments credits the comments of a user to replacementID, with the moderation
+items and the notifications about them.
+*/
+func (db *Client) AnonymiseUserComments(ctx context.Context, userID, replacementID int, replacementName string) (int64, error) {
+	queries := []struct {
+		query string
+		args  []any
+	}{
+		{`UPDATE comments SET UserID = $2, AuthorName = $3 WHERE UserID = $1`, []any{userID, replacementID, replacementName}},
+		{`UPDATE moderation_items SET AuthorID = $2 WHERE AuthorID = $1`, []any{userID, replacementID}},
+		{`UPDATE notifications SET ActorID = $2, ActorName = $3 WHERE ActorID = $1`, []any{userID, replacementID, replacementName}},
+	}
+
+	var count int64
+	err := pgx.BeginFunc(ctx, db.ConnPool, func(tx pgx.Tx) error {
+		for i, q := range queries {
+			commandTag, err := tx.Exec(ctx, q.query, q.args...)
+			if err != nil {
+				return fmt.Errorf("failed to execute query: %w", err)
+			}
+			// Only the comments are counted, the rest follows them
+			if i == 0 {
+				count = commandTag.RowsAffected()
+			}
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+/*
+DeleteUserRelations removes what only matters to the user: their bookmarks, follows in both
+directions and notifications, sent or received. Their reports stay for the moderation history.
+*/
+func (db *Client) DeleteUserRelations(ctx context.Context, userID int) (int64, error) {
+	queries := []string{
+		`DELETE FROM bookmarks WHERE UserID = $1`,
+		`DELETE FROM author_follows WHERE FollowerID = $1 OR AuthorID = $1`,
+		`DELETE FROM category_follows WHERE FollowerID = $1`,
+		`DELETE FROM notifications WHERE RecipientID = $1 OR ActorID = $1`,
+	}
+
+	var count int64
+	err := pgx.BeginFunc(ctx, db.ConnPool, func(tx pgx.Tx) error {
+		for _, query := range queries {
+			commandTag, err := tx.Exec(ctx, query, userID)
+			if err != nil {
+				return fmt.Errorf("failed to execute query: %w", err)
+			}
+			count += commandTag.RowsAffected()
+		}
+
+		return nil
+	})
+
+	return count, err
+}
@@ -123,6 +123,103 @@ func CreatePostgreSQLContainer(ctx context.Context) (testcontainers.Container, s
 	return postgresContainer, p.Port(), nil
 }
 
+// MinioConfig holds what is needed to connect to a test MinIO container.
+type MinioConfig struct {
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+}
+
+func CreateMinioContainer(ctx context.Context) (testcontainers.Container, *MinioConfig, error) {
+	const accessKey = "minioadmin"
+	const secretKey = "minioadmin"
+
+	req := testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "minio/minio",
+			ExposedPorts: []string{"9000/tcp"},
+			Env: map[string]string{
+				"MINIO_ROOT_USER":     accessKey,
+				"MINIO_ROOT_PASSWORD": secretKey,
+			},
+			Cmd:        []string{"server", "/data"},
+			WaitingFor: wait.ForHTTP("/minio/health/live").WithPort("9000/tcp"),
+		},
+		Started: true,
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, req)
+	if err != nil {
+		return container, nil, fmt.Errorf("failed to start minio container: %w", err)
+	}
+
+	p, err := container.MappedPort(ctx, "9000")
+	if err != nil {
+		return container, nil, fmt.Errorf("failed to get minio container external port: %w", err)
+	}
+
+	log.Printf("MinIO container up and running on port: %s\n", p.Port())
+
+	return container, &MinioConfig{
+		Endpoint:  "localhost:" + p.Port(),
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+	}, nil
+}
+
+// CreateRedisContainer starts a Redis container and returns its address.
+func CreateRedisContainer(ctx context.Context) (testcontainers.Container, string, error) {
+	req := testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "redis:7-alpine",
+			ExposedPorts: []string{"6379/tcp"},
+			WaitingFor:   wait.ForLog("Ready to accept connections"),
+		},
+		Started: true,
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, req)
+	if err != nil {
+		return container, "", fmt.Errorf("failed to start redis container: %w", err)
+	}
+
+	p, err := container.MappedPort(ctx, "6379")
+	if err != nil {
+		return container, "", fmt.Errorf("failed to get redis container external port: %w", err)
+	}
+
+	log.Printf("Redis container up and running on port: %s\n", p.Port())
+
+	return container, "localhost:" + p.Port(), nil
+}
+
+// CreateNATSContainer starts a NATS server with JetStream enabled and returns its URL.
+func CreateNATSContainer(ctx context.Context) (testcontainers.Container, string, error) {
+	req := testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "nats:2-alpine",
+			ExposedPorts: []string{"4222/tcp"},
+			Cmd:          []string{"-js"},
+			WaitingFor:   wait.ForLog("Server is ready"),
+		},
+		Started: true,
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, req)
+	if err != nil {
+		return container, "", fmt.Errorf("failed to start nats container: %w", err)
+	}
+
+	p, err := container.MappedPort(ctx, "4222")
+	if err != nil {
+		return container, "", fmt.Errorf("failed to get nats container external port: %w", err)
+	}
+
+	log.Printf("NATS container up and running on port: %s\n", p.Port())
+
+	return container, "nats://localhost:" + p.Port(), nil
+}
+
 func GenerateTestArticle() *mongomodels.ArticleDB {
 	const publisherIDRange = 100
 
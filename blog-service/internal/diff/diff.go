@@ -0,0 +1,124 @@
+package diff
+
+import "strings"
+
+// Operations a diff line can carry.
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+/*
+maxCells caps the size of the LCS table.
+Texts whose changed middle section is bigger than this are reported
+as a full replacement instead of a minimal diff.
+*/
+const maxCells = 4_000_000
+
+type Line struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+/*
+Lines computes a line based diff between two texts.
+@params
+from - the old text.
+to - the new text.
+@returns
+[]Line - the lines of both texts in order, each marked as equal, inserted or deleted.
+*/
+func Lines(from, to string) []Line {
+	a := splitLines(from)
+	b := splitLines(to)
+
+	// Trim the common prefix and suffix, edits are usually local
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	result := make([]Line, 0, len(a)+len(b))
+	for _, l := range a[:prefix] {
+		result = append(result, Line{Op: OpEqual, Text: l})
+	}
+
+	result = append(result, middle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+
+	for _, l := range a[len(a)-suffix:] {
+		result = append(result, Line{Op: OpEqual, Text: l})
+	}
+
+	return result
+}
+
+// middle diffs the part of both texts between the common prefix and suffix.
+func middle(a, b []string) []Line {
+	result := make([]Line, 0, len(a)+len(b))
+
+	if (len(a)+1)*(len(b)+1) > maxCells {
+		for _, l := range a {
+			result = append(result, Line{Op: OpDelete, Text: l})
+		}
+		for _, l := range b {
+			result = append(result, Line{Op: OpInsert, Text: l})
+		}
+		return result
+	}
+
+	// lcs[i][j] holds the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, Line{Op: OpEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, Line{Op: OpDelete, Text: a[i]})
+			i++
+		default:
+			result = append(result, Line{Op: OpInsert, Text: b[j]})
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		result = append(result, Line{Op: OpDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		result = append(result, Line{Op: OpInsert, Text: b[j]})
+	}
+
+	return result
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
@@ -0,0 +1,40 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	from := "first\nsecond\nthird\nfourth"
+	to := "first\nchanged\nthird\nfourth\nfifth"
+
+	want := []Line{
+		{Op: OpEqual, Text: "first"},
+		{Op: OpDelete, Text: "second"},
+		{Op: OpInsert, Text: "changed"},
+		{Op: OpEqual, Text: "third"},
+		{Op: OpEqual, Text: "fourth"},
+		{Op: OpInsert, Text: "fifth"},
+	}
+
+	got := Lines(from, to)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+}
+
+func TestLinesIdenticalAndEmpty(t *testing.T) {
+	got := Lines("same\ntext", "same\ntext")
+	for _, l := range got {
+		if l.Op != OpEqual {
+			t.Errorf("Identical texts produced a change: %v", got)
+		}
+	}
+
+	got = Lines("", "new")
+	want := []Line{{Op: OpInsert, Text: "new"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Package events defines the domain events blog-service publishes for other services, and their publishers.
+
+Every event travels in the same Event envelope, its Data follows the schema of its type and version.
+Adding an optional field keeps the version, any other change is a new version with its own payload type,
+published alongside the old one until its consumers moved on.
+*/
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendNATS   = "nats"
+
+	// Source names blog-service as the producer in the envelope
+	Source = "blog-service"
+	// subjectPrefix starts the subject of every event, e.g. blog.article.published.v1
+	subjectPrefix = "blog."
+)
+
+// Types of event.
+const (
+	TypeArticlePublished = "article.published"
+	TypeArticleUpdated   = "article.updated"
+	TypeArticleDeleted   = "article.deleted"
+	TypeCommentCreated   = "comment.created"
+	TypeCommentDeleted   = "comment.deleted"
+	TypeUserDeleted      = "user.deleted"
+)
+
+// Payload is the data of an event, each type and version has its own.
+type Payload interface {
+	EventType() string
+	EventVersion() int
+}
+
+/*
+Event is the envelope of every published event.
+ID is unique per event and stays the same when it is published again, consumers deduplicate on it.
+*/
+type Event struct {
+	OccurredAt time.Time       `json:"occurredAt"`
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Source     string          `json:"source"`
+	Data       json.RawMessage `json:"data"`
+	Version    int             `json:"version"`
+}
+
+// New wraps payload in an envelope.
+func New(id string, occurredAt time.Time, payload Payload) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to encode %s event: %w", payload.EventType(), err)
+	}
+
+	return Event{
+		OccurredAt: occurredAt.UTC(),
+		ID:         id,
+		Type:       payload.EventType(),
+		Source:     Source,
+		Data:       data,
+		Version:    payload.EventVersion(),
+	}, nil
+}
+
+// Subject is where the event is published, consumers subscribe to the versions they understand.
+func (e Event) Subject() string {
+	return fmt.Sprintf("%s%s.v%d", subjectPrefix, e.Type, e.Version)
+}
+
+/*
+Publisher sends events to the consumers.
+Publish returns once the event is stored by the broker, an event published twice with the same ID
+may reach consumers twice.
+*/
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+	Close() error
+}
+
+// Config selects the publisher, the NATS settings are only read by the nats backend.
+type Config struct {
+	Backend    string
+	NATSURL    string
+	NATSStream string
+}
+
+// NewPublisher creates the publisher selected by cfg.Backend, memory or nats.
+func NewPublisher(ctx context.Context, cfg Config) (Publisher, error) {
+	switch cfg.Backend {
+	case BackendMemory:
+		return NewMemoryPublisher(), nil
+	case BackendNATS:
+		return NewNATSPublisher(ctx, cfg.NATSURL, cfg.NATSStream)
+	default:
+		return nil, fmt.Errorf("unknown events backend %q", cfg.Backend)
+	}
+}
+
+// ArticlePublishedV1 is sent when an article becomes visible to readers.
+type ArticlePublishedV1 struct {
+	PublishedAt   time.Time `json:"publishedAt"`
+	ArticleID     string    `json:"articleId"`
+	Slug          string    `json:"slug"`
+	Title         string    `json:"title"`
+	Excerpt       string    `json:"excerpt"`
+	Category      string    `json:"category"`
+	PublisherName string    `json:"publisherName"`
+	PublisherID   int       `json:"publisherId"`
+}
+
+func (ArticlePublishedV1) EventType() string { return TypeArticlePublished }
+func (ArticlePublishedV1) EventVersion() int { return 1 }
+
+// ArticleUpdatedV1 is sent when a published article is edited, Revision is the number of the new revision.
+type ArticleUpdatedV1 struct {
+	UpdatedAt time.Time `json:"updatedAt"`
+	ArticleID string    `json:"articleId"`
+	Slug      string    `json:"slug"`
+	Title     string    `json:"title"`
+	Excerpt   string    `json:"excerpt"`
+	Category  string    `json:"category"`
+	Revision  int       `json:"revision"`
+}
+
+func (ArticleUpdatedV1) EventType() string { return TypeArticleUpdated }
+func (ArticleUpdatedV1) EventVersion() int { return 1 }
+
+// ArticleDeletedV1 is sent once an article and its comments are deleted.
+type ArticleDeletedV1 struct {
+	ArticleID string `json:"articleId"`
+}
+
+func (ArticleDeletedV1) EventType() string { return TypeArticleDeleted }
+func (ArticleDeletedV1) EventVersion() int { return 1 }
+
+// CommentCreatedV1 is sent when a comment becomes visible, a held one once a moderator restores it.
+type CommentCreatedV1 struct {
+	ArticleID  string `json:"articleId"`
+	AuthorName string `json:"authorName"`
+	CommentID  int    `json:"commentId"`
+	AuthorID   int    `json:"authorId"`
+}
+
+func (CommentCreatedV1) EventType() string { return TypeCommentCreated }
+func (CommentCreatedV1) EventVersion() int { return 1 }
+
+// CommentDeletedV1 is sent when a comment is deleted.
+type CommentDeletedV1 struct {
+	ArticleID string `json:"articleId"`
+	CommentID int    `json:"commentId"`
+}
+
+func (CommentDeletedV1) EventType() string { return TypeCommentDeleted }
+func (CommentDeletedV1) EventVersion() int { return 1 }
+
+// UserDeletedV1 is sent once the content of a deleted user has been handled, Mode is anonymise or purge.
+type UserDeletedV1 struct {
+	Mode   string `json:"mode"`
+	UserID int    `json:"userId"`
+}
+
+func (UserDeletedV1) EventType() string { return TypeUserDeleted }
+func (UserDeletedV1) EventVersion() int { return 1 }
@@ -0,0 +1,134 @@
+package events
+
+import (
+	"blog-service/internal/db/testutil"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestNewWrapsThePayload(t *testing.T) {
+	occurredAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	event, err := New("key-1", occurredAt, CommentCreatedV1{ArticleID: "a1", CommentID: 7, AuthorID: 3, AuthorName: "ana"})
+	if err != nil {
+		t.Fatalf("Failed to create event: %s", err)
+	}
+
+	if event.Subject() != "blog.comment.created.v1" || event.Source != Source || !event.OccurredAt.Equal(occurredAt) {
+		t.Errorf("Unexpected envelope: %+v", event)
+	}
+
+	var data CommentCreatedV1
+	if err := json.Unmarshal(event.Data, &data); err != nil || data.CommentID != 7 || data.AuthorName != "ana" {
+		t.Errorf("Payload was not kept, got %+v (%v)", data, err)
+	}
+}
+
+func TestMemoryPublisherDropsDuplicates(t *testing.T) {
+	publisher := NewMemoryPublisher()
+	ctx := context.Background()
+
+	deleted, _ := New("key-1", time.Now(), ArticleDeletedV1{ArticleID: "a1"})
+	user, _ := New("key-2", time.Now(), UserDeletedV1{UserID: 4})
+	for _, event := range []Event{deleted, user, deleted} {
+		if err := publisher.Publish(ctx, event); err != nil {
+			t.Fatalf("Failed to publish: %s", err)
+		}
+	}
+
+	got := publisher.Events()
+	if len(got) != 2 || got[0].ID != "key-1" || got[1].ID != "key-2" {
+		t.Errorf("Expected both events once in order, got %+v", got)
+	}
+}
+
+func TestMemoryPublisherKeepsTheNewestEvents(t *testing.T) {
+	publisher := newMemoryPublisher(2)
+	ctx := context.Background()
+
+	for _, id := range []string{"key-1", "key-2", "key-3", "key-2"} {
+		event, _ := New(id, time.Now(), ArticleDeletedV1{ArticleID: id})
+		if err := publisher.Publish(ctx, event); err != nil {
+			t.Fatalf("Failed to publish: %s", err)
+		}
+	}
+
+	got := publisher.Events()
+	if len(got) != 2 || got[0].ID != "key-2" || got[1].ID != "key-3" {
+		t.Errorf("Expected the two newest events in order, got %+v", got)
+	}
+
+	// The evicted event is no longer known, publishing it again keeps it
+	event, _ := New("key-1", time.Now(), ArticleDeletedV1{ArticleID: "key-1"})
+	if err := publisher.Publish(ctx, event); err != nil {
+		t.Fatalf("Failed to publish: %s", err)
+	}
+	if got := publisher.Events(); got[len(got)-1].ID != "key-1" {
+		t.Errorf("Expected the evicted event to be kept again, got %+v", got)
+	}
+}
+
+func TestNATSPublisher(t *testing.T) {
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	ctx := context.Background()
+
+	container, url, err := testutil.CreateNATSContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to setup nats container: %s", err)
+	}
+	defer func() {
+		if err := testcontainers.TerminateContainer(container); err != nil {
+			t.Logf("failed to terminate container: %s", err)
+		}
+	}()
+
+	publisher, err := NewPublisher(ctx, Config{Backend: BackendNATS, NATSURL: url})
+	if err != nil {
+		t.Fatalf("Failed to create publisher: %s", err)
+	}
+	defer publisher.Close()
+
+	event, _ := New("key-1", time.Now(), ArticleUpdatedV1{ArticleID: "a1", Revision: 2})
+	for range 2 {
+		if err := publisher.Publish(ctx, event); err != nil {
+			t.Fatalf("Failed to publish: %s", err)
+		}
+	}
+
+	conn, err := nats.Connect(url)
+	if err != nil {
+		t.Fatalf("Failed to connect: %s", err)
+	}
+	defer conn.Close()
+
+	js, _ := jetstream.New(conn)
+	consumer, err := js.OrderedConsumer(ctx, DefaultStream, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{"blog.article.updated.v1"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create consumer: %s", err)
+	}
+
+	batch, err := consumer.Fetch(2, jetstream.FetchMaxWait(time.Second))
+	if err != nil {
+		t.Fatalf("Failed to fetch: %s", err)
+	}
+
+	var received []Event
+	for msg := range batch.Messages() {
+		var e Event
+		if err := json.Unmarshal(msg.Data(), &e); err != nil {
+			t.Fatalf("Failed to decode event: %s", err)
+		}
+		received = append(received, e)
+	}
+
+	if len(received) != 1 || received[0].ID != "key-1" || received[0].Version != 1 {
+		t.Errorf("Expected the event once, got %+v", received)
+	}
+}
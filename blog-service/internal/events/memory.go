@@ -0,0 +1,65 @@
+package events
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// memoryCapacity is how many events MemoryPublisher keeps, the oldest go first.
+const memoryCapacity = 1024
+
+/*
+MemoryPublisher keeps the newest events in the process, for local development and tests.
+Events published again with the same ID are kept once while the first copy is still kept,
+like the broker does within its duplicate window.
+*/
+type MemoryPublisher struct {
+	mu sync.Mutex
+	// events is a ring once full, oldest holds the position of the oldest event
+	events   []Event
+	oldest   int
+	ids      map[string]struct{}
+	capacity int
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return newMemoryPublisher(memoryCapacity)
+}
+
+func newMemoryPublisher(capacity int) *MemoryPublisher {
+	return &MemoryPublisher{ids: map[string]struct{}{}, capacity: capacity}
+}
+
+func (p *MemoryPublisher) Publish(_ context.Context, event Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.ids[event.ID]; ok {
+		return nil
+	}
+	p.ids[event.ID] = struct{}{}
+
+	if len(p.events) < p.capacity {
+		p.events = append(p.events, event)
+		return nil
+	}
+
+	delete(p.ids, p.events[p.oldest].ID)
+	p.events[p.oldest] = event
+	p.oldest = (p.oldest + 1) % p.capacity
+
+	return nil
+}
+
+// Events returns the events kept, oldest first.
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append(slices.Clone(p.events[p.oldest:]), p.events[:p.oldest]...)
+}
+
+func (p *MemoryPublisher) Close() error {
+	return nil
+}
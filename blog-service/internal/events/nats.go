@@ -0,0 +1,85 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// DefaultStream is the JetStream stream holding the events when none is configured
+	DefaultStream = "BLOG_EVENTS"
+	// duplicateWindow is how long JetStream remembers event IDs, longer than the outbox retries an event
+	duplicateWindow = 24 * time.Hour
+	// retention keeps events for consumers that were down for a while
+	retention   = 7 * 24 * time.Hour
+	dialTimeout = 5 * time.Second
+)
+
+/*
+NATSPublisher publishes events to a JetStream stream, which keeps them for consumers that are not running.
+The ID of the event is the message ID, JetStream drops an event published again within the duplicate window.
+*/
+type NATSPublisher struct {
+	conn *nats.Conn
+	js   jetstream.JetStream
+}
+
+/*
+NewNATSPublisher connects to NATS and creates or updates the stream of the events.
+@params
+url - of the NATS server
+stream - name of the stream, DefaultStream when empty
+*/
+func NewNATSPublisher(ctx context.Context, url, stream string) (*NATSPublisher, error) {
+	if stream == "" {
+		stream = DefaultStream
+	}
+
+	conn, err := nats.Connect(url, nats.Name(Source), nats.Timeout(dialTimeout), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open jetstream: %w", err)
+	}
+
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       stream,
+		Subjects:   []string{subjectPrefix + ">"},
+		Storage:    jetstream.FileStorage,
+		MaxAge:     retention,
+		Duplicates: duplicateWindow,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create stream %s: %w", stream, err)
+	}
+
+	return &NATSPublisher{conn: conn, js: js}, nil
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", event.ID, err)
+	}
+
+	if _, err := p.js.Publish(ctx, event.Subject(), data, jetstream.WithMsgID(event.ID)); err != nil {
+		return fmt.Errorf("failed to publish event %s: %w", event.ID, err)
+	}
+
+	return nil
+}
+
+// Close waits for the pending messages then closes the connection.
+func (p *NATSPublisher) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,189 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// Content types of the generated documents.
+const (
+	RSSContentType  = "application/rss+xml; charset=utf-8"
+	AtomContentType = "application/atom+xml; charset=utf-8"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// Feed is the format independent description of a feed.
+type Feed struct {
+	Updated  time.Time
+	ID       string
+	Title    string
+	Subtitle string
+	Link     string
+	SelfLink string
+	Entries  []Entry
+}
+
+type Entry struct {
+	Published time.Time
+	Updated   time.Time
+	// ID is a globally unique, permanent identifier of the entry
+	ID       string
+	Title    string
+	Link     string
+	Summary  string
+	Content  string
+	Author   string
+	Category string
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	SelfLink      atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	Category    string  `xml:"category,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type atomDocument struct {
+	XMLName  xml.Name    `xml:"feed"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string        `xml:"id"`
+	Title     string        `xml:"title"`
+	Link      atomLink      `xml:"link"`
+	Published string        `xml:"published"`
+	Updated   string        `xml:"updated"`
+	Author    atomAuthor    `xml:"author"`
+	Category  *atomCategory `xml:"category,omitempty"`
+	Summary   string        `xml:"summary,omitempty"`
+	Content   atomContent   `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+func marshal(doc any) ([]byte, error) {
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode feed: %w", err)
+	}
+
+	return append([]byte(xml.Header), body...), nil
+}
+
+// RSS encodes the feed as an RSS 2.0 document. The HTML content goes into the escaped description.
+func RSS(f *Feed) ([]byte, error) {
+	doc := rssDocument{
+		Version: "2.0",
+		Atom:    atomNamespace,
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        f.Link,
+			Description: f.Subtitle,
+			SelfLink:    atomLink{Href: f.SelfLink, Rel: "self", Type: "application/rss+xml"},
+			Items:       make([]rssItem, 0, len(f.Entries)),
+		},
+	}
+
+	if !f.Updated.IsZero() {
+		doc.Channel.LastBuildDate = f.Updated.UTC().Format(time.RFC1123Z)
+	}
+
+	for _, e := range f.Entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			Description: e.Content,
+			Category:    e.Category,
+			GUID:        rssGUID{Value: e.ID},
+			PubDate:     e.Published.UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	return marshal(doc)
+}
+
+// Atom encodes the feed as an Atom 1.0 document.
+func Atom(f *Feed) ([]byte, error) {
+	doc := atomDocument{
+		Xmlns:    atomNamespace,
+		ID:       f.ID,
+		Title:    f.Title,
+		Subtitle: f.Subtitle,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+			{Href: f.SelfLink, Rel: "self", Type: "application/atom+xml"},
+		},
+		Entries: make([]atomEntry, 0, len(f.Entries)),
+	}
+
+	for _, e := range f.Entries {
+		entry := atomEntry{
+			ID:        e.ID,
+			Title:     e.Title,
+			Link:      atomLink{Href: e.Link, Rel: "alternate", Type: "text/html"},
+			Published: e.Published.UTC().Format(time.RFC3339),
+			Updated:   e.Updated.UTC().Format(time.RFC3339),
+			Author:    atomAuthor{Name: e.Author},
+			Summary:   e.Summary,
+			Content:   atomContent{Type: "html", Value: e.Content},
+		}
+
+		if e.Category != "" {
+			entry.Category = &atomCategory{Term: e.Category}
+		}
+
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return marshal(doc)
+}
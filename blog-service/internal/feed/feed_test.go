@@ -0,0 +1,69 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFeed() *Feed {
+	published := time.Date(2025, 9, 19, 12, 0, 0, 0, time.UTC)
+
+	return &Feed{
+		Updated:  published.Add(time.Hour),
+		ID:       "tag:blog.example.com,2025:feed",
+		Title:    "Blog",
+		Link:     "https://blog.example.com",
+		SelfLink: "https://blog.example.com/feed.atom",
+		Entries: []Entry{{
+			Published: published,
+			Updated:   published.Add(time.Hour),
+			ID:        "tag:blog.example.com,2025:article/1",
+			Title:     "Quantum & you",
+			Link:      "https://blog.example.com/article/1",
+			Content:   "<p>Hello <strong>world</strong></p>",
+			Author:    "Tech Today",
+			Category:  "Technology",
+		}},
+	}
+}
+
+func TestAtom(t *testing.T) {
+	body, err := Atom(testFeed())
+	if err != nil {
+		t.Fatalf("Failed to build atom feed: %s", err)
+	}
+
+	var doc atomDocument
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("Atom feed is not valid XML: %s", err)
+	}
+
+	if len(doc.Entries) != 1 || doc.Entries[0].Content.Value != "<p>Hello <strong>world</strong></p>" {
+		t.Errorf("Entry content did not survive encoding: %+v", doc.Entries)
+	}
+
+	if doc.Updated != "2025-09-19T13:00:00Z" || doc.Entries[0].Published != "2025-09-19T12:00:00Z" {
+		t.Errorf("Dates are not RFC 3339: %s %s", doc.Updated, doc.Entries[0].Published)
+	}
+}
+
+func TestRSS(t *testing.T) {
+	body, err := RSS(testFeed())
+	if err != nil {
+		t.Fatalf("Failed to build rss feed: %s", err)
+	}
+
+	if !strings.Contains(string(body), `<guid isPermaLink="false">tag:blog.example.com,2025:article/1</guid>`) {
+		t.Errorf("RSS item is missing its guid:\n%s", body)
+	}
+
+	if !strings.Contains(string(body), "&lt;strong&gt;") {
+		t.Errorf("RSS description is not escaped:\n%s", body)
+	}
+
+	if !strings.Contains(string(body), "<pubDate>Fri, 19 Sep 2025 12:00:00 +0000</pubDate>") {
+		t.Errorf("RSS pubDate is not RFC 1123:\n%s", body)
+	}
+}
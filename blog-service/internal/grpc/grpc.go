@@ -2,24 +2,57 @@ package grpc
 
 import (
 	pb "blog-service/internal/grpc/protobuf"
-	"os"
+	"blog-service/internal/logging"
+	"blog-service/internal/metrics"
+	"context"
+	"fmt"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 type AuthClient struct {
 	Client pb.AuthServiceClient
+	Conn   *grpc.ClientConn
 }
 
-func NewGRPCClient() (pb.AuthServiceClient, error) {
-	addr := os.Getenv("AUTH_URI")
-	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// NewGRPCClient connects to auth-service at addr.
+func NewGRPCClient(addr string) (*AuthClient, error) {
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(logging.UnaryClientInterceptor, metrics.UnaryClientInterceptor),
+		// Continues the trace of the incoming request in auth-service
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	client := pb.NewAuthServiceClient(conn)
+	return &AuthClient{
+		Client: pb.NewAuthServiceClient(conn),
+		Conn:   conn,
+	}, nil
+}
+
+/*
+Ping asks auth-service for its health, which covers its own database.
+The error names the connection state, telling an unreachable service from an unhealthy one.
+*/
+func (c *AuthClient) Ping(ctx context.Context) error {
+	resp, err := healthpb.NewHealthClient(c.Conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return fmt.Errorf("%w (connection %s)", err, c.Conn.GetState())
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("auth-service is %s", resp.GetStatus())
+	}
+
+	return nil
+}
 
-	return client, nil
+// Close tears down the connection to auth-service.
+func (c *AuthClient) Close() error {
+	return c.Conn.Close()
 }
@@ -0,0 +1,88 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultTimeout = 2 * time.Second
+
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// Check probes one dependency, a nil error means it is usable.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status    Status  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of every check, down as soon as one of them is.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+/*
+Checker runs the checks of every dependency of the service concurrently,
+each bounded by the same timeout so one hanging dependency cannot stall the report.
+*/
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: defaultTimeout}
+}
+
+// Run checks every dependency and waits for all of them.
+func (c *Checker) Run(ctx context.Context) Report {
+	results := make([]Result, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(c.checks))}
+	for i, check := range c.checks {
+		report.Checks[check.Name] = results[i]
+		if results[i].Status == StatusDown {
+			report.Status = StatusDown
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	latency := float64(time.Since(start).Microseconds()) / 1000
+
+	if err != nil {
+		return Result{Status: StatusDown, LatencyMs: latency, Error: err.Error()}
+	}
+
+	return Result{Status: StatusUp, LatencyMs: latency}
+}
@@ -0,0 +1,45 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunReportsEveryDependency(t *testing.T) {
+	checker := NewChecker(
+		Check{Name: "mongodb", Run: func(context.Context) error { return nil }},
+		Check{Name: "postgres", Run: func(context.Context) error { return errors.New("connection refused") }},
+	)
+
+	report := checker.Run(context.Background())
+
+	if report.Status != StatusDown {
+		t.Errorf("Report status = %s, want down when a dependency is down", report.Status)
+	}
+	if got := report.Checks["mongodb"]; got.Status != StatusUp || got.Error != "" {
+		t.Errorf("mongodb = %+v, want up", got)
+	}
+	if got := report.Checks["postgres"]; got.Status != StatusDown || got.Error != "connection refused" {
+		t.Errorf("postgres = %+v, want down with its error", got)
+	}
+}
+
+func TestRunBoundsHangingChecks(t *testing.T) {
+	checker := NewChecker(Check{Name: "auth-service", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	checker.timeout = 20 * time.Millisecond
+
+	start := time.Now()
+	report := checker.Run(context.Background())
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Run took %v despite the timeout", elapsed)
+	}
+	if report.Status != StatusDown {
+		t.Errorf("A timed out check must be down, got %+v", report)
+	}
+}
@@ -0,0 +1,119 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+/*
+Workers runs background goroutines under one context and stops them together.
+*/
+type Workers struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	running map[string]int
+}
+
+func NewWorkers() *Workers {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Workers{ctx: ctx, cancel: cancel, running: map[string]int{}}
+}
+
+// Go starts run in the background, it must return once its context is cancelled.
+func (w *Workers) Go(name string, run func(ctx context.Context)) {
+	w.mu.Lock()
+	w.running[name]++
+	w.mu.Unlock()
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer func() {
+			w.mu.Lock()
+			w.running[name]--
+			if w.running[name] == 0 {
+				delete(w.running, name)
+			}
+			w.mu.Unlock()
+		}()
+
+		run(w.ctx)
+	}()
+}
+
+/*
+Stop cancels the workers and waits for them to return.
+@returns
+error - naming the workers still running when ctx expired.
+*/
+func (w *Workers) Stop(ctx context.Context) error {
+	w.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		w.mu.Lock()
+		names := make([]string, 0, len(w.running))
+		for name := range w.running {
+			names = append(names, name)
+		}
+		w.mu.Unlock()
+		slices.Sort(names)
+
+		return fmt.Errorf("workers did not stop in time: %v", names)
+	}
+}
+
+type closer struct {
+	name  string
+	close func(ctx context.Context) error
+}
+
+/*
+Closers releases resources in the reverse order they were acquired,
+so nothing is closed while something opened later may still use it.
+*/
+type Closers struct {
+	closers []closer
+}
+
+// Add registers a resource to close.
+func (c *Closers) Add(name string, close func(ctx context.Context) error) {
+	c.closers = append(c.closers, closer{name: name, close: close})
+}
+
+/*
+Close closes every resource, newest first, even when some fail.
+@returns
+error - the joined errors of the resources that failed to close.
+*/
+func (c *Closers) Close(ctx context.Context) error {
+	var errs []error
+
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		closer := c.closers[i]
+		if err := closer.close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", closer.name, err))
+			continue
+		}
+		slog.Info("Closed " + closer.name)
+	}
+	c.closers = nil
+
+	return errors.Join(errs...)
+}
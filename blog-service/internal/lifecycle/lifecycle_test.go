@@ -0,0 +1,76 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkersStopCancelsAndWaits(t *testing.T) {
+	workers := NewWorkers()
+
+	stopped := make(chan struct{})
+	workers.Go("ticker", func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := workers.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	select {
+	case <-stopped:
+	default:
+		t.Error("Stop returned before the worker did")
+	}
+}
+
+func TestWorkersStopNamesStuckWorkers(t *testing.T) {
+	workers := NewWorkers()
+
+	release := make(chan struct{})
+	defer close(release)
+	workers.Go("stuck", func(context.Context) { <-release })
+	workers.Go("polite", func(ctx context.Context) { <-ctx.Done() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := workers.Stop(ctx)
+	if err == nil {
+		t.Fatal("Expected an error for a worker ignoring cancellation")
+	}
+	if !strings.Contains(err.Error(), "stuck") || strings.Contains(err.Error(), "polite") {
+		t.Errorf("Error should only name the stuck worker: %v", err)
+	}
+}
+
+func TestClosersCloseNewestFirst(t *testing.T) {
+	closers := &Closers{}
+
+	var order []string
+	for _, name := range []string{"mongo", "postgres", "redis"} {
+		closers.Add(name, func(context.Context) error {
+			order = append(order, name)
+			if name == "postgres" {
+				return errors.New("pool busy")
+			}
+			return nil
+		})
+	}
+
+	err := closers.Close(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "postgres: pool busy") {
+		t.Errorf("Expected the postgres failure to be reported, got %v", err)
+	}
+
+	if strings.Join(order, ",") != "redis,postgres,mongo" {
+		t.Errorf("Closed in order %v, want newest first and all of them", order)
+	}
+}
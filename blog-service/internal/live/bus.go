@@ -0,0 +1,175 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+
+	notifyChannel = "comment_events"
+	// eventSequence numbers the events of every replica, it is created by the Postgres migrations
+	eventSequence  = "comment_event_seq"
+	reconnectDelay = 2 * time.Second
+)
+
+/*
+Bus carries comment events to the streams of connected readers.
+In memory it only reaches the streams of this process. Backed by Postgres,
+events are sent with NOTIFY and every replica LISTENs, so a reader sees
+comments no matter which replica they were posted to.
+Notifications travel on it as well, on a stream per recipient in place of the article.
+*/
+type Bus struct {
+	hub  *hub
+	pool *pgxpool.Pool
+	seq  atomic.Int64
+}
+
+// NewMemoryBus creates a bus that only reaches subscribers of this process.
+func NewMemoryBus() *Bus {
+	b := &Bus{hub: newHub()}
+
+	// Starting from the clock keeps IDs growing across restarts, so old Last-Event-IDs are detected
+	start := time.Now().UnixMicro()
+	b.seq.Store(start)
+	b.hub.floor = start
+
+	return b
+}
+
+// NewPostgresBus creates a bus shared by every replica connected to the same database.
+func NewPostgresBus(pool *pgxpool.Pool) *Bus {
+	return &Bus{hub: newHub(), pool: pool}
+}
+
+/*
+NewBus creates the bus of the given backend, memory or postgres.
+@params
+pool - the Postgres pool used by the postgres backend.
+*/
+func NewBus(backend string, pool *pgxpool.Pool) (*Bus, error) {
+	switch backend {
+	case BackendMemory:
+		return NewMemoryBus(), nil
+	case BackendPostgres:
+		return NewPostgresBus(pool), nil
+	default:
+		return nil, fmt.Errorf("unknown pub/sub backend %q", backend)
+	}
+}
+
+/*
+Publish sends an event about the comments of an article.
+data is encoded as JSON. A nil Bus drops everything, which keeps streaming optional.
+*/
+func (b *Bus) Publish(ctx context.Context, articleID, eventType string, data any) error {
+	if b == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	event := Event{Type: eventType, ArticleID: articleID, Data: raw}
+
+	if b.pool == nil {
+		event.ID = b.seq.Add(1)
+		b.hub.deliver(event)
+		return nil
+	}
+
+	// Our own listener delivers the event as well, so it is not delivered here
+	const query = `SELECT pg_notify($1, json_build_object(
+		'id', nextval('` + eventSequence + `'), 'type', $2::text, 'articleId', $3::text, 'data', $4::json)::text)`
+
+	if _, err := b.pool.Exec(ctx, query, notifyChannel, event.Type, event.ArticleID, string(raw)); err != nil {
+		return fmt.Errorf("failed to notify event: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe starts receiving the events of an article, resuming after lastEventID when it is not 0.
+func (b *Bus) Subscribe(articleID string, lastEventID int64) *Subscription {
+	return b.hub.subscribe(articleID, lastEventID)
+}
+
+/*
+Run listens for events of other replicas until ctx is cancelled.
+It returns right away for the memory backend. A lost connection is reopened,
+clients are then asked to reload since events may have been missed meanwhile.
+*/
+func (b *Bus) Run(ctx context.Context) {
+	if b.pool == nil {
+		return
+	}
+
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.Warn("Comment event listener stopped, reconnecting", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (b *Bus) listen(ctx context.Context) error {
+	pooled, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The connection stays in LISTEN mode, so it is taken out of the pool and closed when done
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+
+	// Events numbered up to here were sent before we listened
+	var floor int64
+	err = conn.QueryRow(ctx, "SELECT CASE WHEN is_called THEN last_value ELSE 0 END FROM "+eventSequence).Scan(&floor)
+	if err != nil {
+		return err
+	}
+	b.hub.reset(floor)
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var event Event
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			slog.Warn("Ignoring malformed comment event", "error", err)
+			continue
+		}
+
+		if event.ID == 0 || event.ArticleID == "" {
+			slog.Warn("Ignoring comment event without id or article")
+			continue
+		}
+
+		b.hub.deliver(event)
+	}
+}
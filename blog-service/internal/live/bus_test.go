@@ -0,0 +1,72 @@
+package live
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryBusDeliversAndReplays(t *testing.T) {
+	ctx := context.Background()
+	bus := NewMemoryBus()
+
+	sub := bus.Subscribe("a", 0)
+	defer sub.Close()
+
+	if err := bus.Publish(ctx, "a", CommentCreated, map[string]int{"id": 1}); err != nil {
+		t.Fatalf("Failed to publish: %s", err)
+	}
+	if err := bus.Publish(ctx, "b", CommentCreated, map[string]int{"id": 2}); err != nil {
+		t.Fatalf("Failed to publish: %s", err)
+	}
+	if err := bus.Publish(ctx, "a", CommentDeleted, map[string]int{"id": 1}); err != nil {
+		t.Fatalf("Failed to publish: %s", err)
+	}
+
+	first := <-sub.Events
+	second := <-sub.Events
+	if first.Type != CommentCreated || second.Type != CommentDeleted || second.ID <= first.ID {
+		t.Fatalf("Unexpected events %+v, %+v", first, second)
+	}
+
+	resumed := bus.Subscribe("a", first.ID)
+	defer resumed.Close()
+
+	if resumed.Reset || len(resumed.Replay) != 1 || resumed.Replay[0].ID != second.ID {
+		t.Errorf("Resuming after %d replayed %+v (reset %v)", first.ID, resumed.Replay, resumed.Reset)
+	}
+}
+
+func TestResumeFromUnknownEventResets(t *testing.T) {
+	bus := NewMemoryBus()
+
+	// An ID from before this process started cannot be resumed from
+	sub := bus.Subscribe("a", 1)
+	defer sub.Close()
+
+	if !sub.Reset {
+		t.Errorf("Resuming from an unknown event did not ask the client to reload")
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	ctx := context.Background()
+	bus := NewMemoryBus()
+
+	sub := bus.Subscribe("a", 0)
+	defer sub.Close()
+
+	for i := range subscriberBuffer + 1 {
+		if err := bus.Publish(ctx, "a", CommentLikes, i); err != nil {
+			t.Fatalf("Failed to publish: %s", err)
+		}
+	}
+
+	received := 0
+	for range sub.Events {
+		received++
+	}
+
+	if received != subscriberBuffer {
+		t.Errorf("got %d events before the drop, wanted %d", received, subscriberBuffer)
+	}
+}
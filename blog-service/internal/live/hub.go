@@ -0,0 +1,184 @@
+package live
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+const (
+	// Events kept per article for clients resuming with Last-Event-ID
+	replaySize = 64
+	// Buffered events per subscriber, a subscriber that falls further behind is dropped
+	subscriberBuffer = 32
+	// Above this many buffered articles, buffers of articles nobody watches are dropped
+	maxIdleArticles = 1024
+)
+
+// Kinds of event.
+const (
+	CommentCreated = "comment.created"
+	CommentUpdated = "comment.updated"
+	CommentDeleted = "comment.deleted"
+	CommentLikes   = "comment.likes"
+	// NotificationCreated goes to the stream of the recipient rather than of an article
+	NotificationCreated = "notification.created"
+)
+
+/*
+Event is a change to the comments of an article.
+IDs only grow, which lets clients resume from the last event they saw.
+*/
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	ArticleID string          `json:"articleId"`
+	Data      json.RawMessage `json:"data"`
+}
+
+/*
+Subscription receives the events of one article.
+Replay holds the events missed since the Last-Event-ID the client resumed from.
+Reset is set when those events are no longer known, the client should then reload the comments.
+Events is closed when the subscriber fell too far behind, it should reconnect.
+*/
+type Subscription struct {
+	Events <-chan Event
+	Replay []Event
+	Reset  bool
+
+	close func()
+}
+
+func (s *Subscription) Close() {
+	s.close()
+}
+
+type article struct {
+	recent []Event
+	// evicted is the ID of the newest event that no longer is in recent
+	evicted     int64
+	subscribers map[chan Event]struct{}
+}
+
+// hub fans events out to the subscribers of this process.
+type hub struct {
+	mu       sync.Mutex
+	articles map[string]*article
+	// floor is the ID up to which events may have happened without this hub seeing them
+	floor int64
+}
+
+func newHub() *hub {
+	return &hub{articles: map[string]*article{}}
+}
+
+func (h *hub) article(articleID string) *article {
+	a, ok := h.articles[articleID]
+	if !ok {
+		if len(h.articles) >= maxIdleArticles {
+			h.pruneIdle()
+		}
+
+		a = &article{subscribers: map[chan Event]struct{}{}}
+		h.articles[articleID] = a
+	}
+
+	return a
+}
+
+func (h *hub) pruneIdle() {
+	for id, a := range h.articles {
+		if len(a.subscribers) == 0 {
+			delete(h.articles, id)
+			// Resuming on a dropped article can no longer be answered reliably
+			h.floor = max(h.floor, a.lastID())
+		}
+	}
+}
+
+func (a *article) lastID() int64 {
+	if len(a.recent) == 0 {
+		return a.evicted
+	}
+
+	return a.recent[len(a.recent)-1].ID
+}
+
+// deliver records an event and sends it to the subscribers of its article.
+func (h *hub) deliver(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	a := h.article(event.ArticleID)
+
+	if len(a.recent) == replaySize {
+		a.evicted = a.recent[0].ID
+		a.recent = append(a.recent[:0], a.recent[1:]...)
+	}
+	a.recent = append(a.recent, event)
+
+	for ch := range a.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Closing tells the client to reconnect, it then catches up from the replay buffer
+			delete(a.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (h *hub) subscribe(articleID string, lastEventID int64) *Subscription {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	a := h.article(articleID)
+	a.subscribers[ch] = struct{}{}
+
+	sub := &Subscription{Events: ch}
+
+	if lastEventID > 0 {
+		if lastEventID < max(h.floor, a.evicted) {
+			sub.Reset = true
+		} else {
+			for _, event := range a.recent {
+				if event.ID > lastEventID {
+					sub.Replay = append(sub.Replay, event)
+				}
+			}
+		}
+	}
+
+	sub.close = func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if _, ok := a.subscribers[ch]; ok {
+			delete(a.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return sub
+}
+
+/*
+reset forgets every buffered event up to floor and disconnects all subscribers.
+It is used when events may have been missed, reconnecting clients are then told to reload.
+*/
+func (h *hub) reset(floor int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.floor = max(h.floor, floor)
+
+	for id, a := range h.articles {
+		for ch := range a.subscribers {
+			delete(a.subscribers, ch)
+			close(ch)
+		}
+		delete(h.articles, id)
+	}
+}
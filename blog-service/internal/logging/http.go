@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDHeader is the HTTP header carrying the request ID, set on every response.
+const RequestIDHeader = "X-Request-ID"
+
+/*
+Middleware continues the X-Request-ID sent by the caller, or starts a new one,
+echoes it on the response and writes an access log line once the request is handled.
+It wraps the router, so the route is the pattern that matched, or "unmatched".
+*/
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !ValidRequestID(requestID) {
+			requestID = NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		// The router records the matched pattern on this request
+		r = r.WithContext(WithRequestID(r.Context(), requestID))
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+
+		next.ServeHTTP(recorder, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+
+		level := slog.LevelInfo
+		if recorder.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		slog.LogAttrs(r.Context(), level, "http request",
+			slog.String("method", r.Method),
+			slog.String("route", route),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", recorder.status),
+			slog.Int64("bytes", recorder.bytes),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("remote_addr", r.RemoteAddr),
+		)
+	})
+}
+
+// statusRecorder remembers the status code and size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+
+	return n, err
+}
+
+// Unwrap exposes the wrapped writer, so logged event streams can still be flushed.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// UnaryClientInterceptor forwards the request ID to the called service, so its logs can be matched with ours.
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, RequestIDMetadata, requestID)
+	}
+
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareLogsRouteRequestAndUser(t *testing.T) {
+	buf := captureLogs(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/article/{id}", func(w http.ResponseWriter, r *http.Request) {
+		SetUserID(r.Context(), 7)
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/article/42", nil)
+	req.Header.Set(RequestIDHeader, "caller-id-1")
+	recorder := httptest.NewRecorder()
+	Middleware(mux).ServeHTTP(recorder, req)
+
+	if got := recorder.Header().Get(RequestIDHeader); got != "caller-id-1" {
+		t.Errorf("Response request ID = %q, want the caller's", got)
+	}
+
+	record := lastRecord(t, buf)
+	want := map[string]any{
+		"msg":        "http request",
+		"route":      "/article/{id}",
+		"status":     float64(http.StatusNotFound),
+		"request_id": "caller-id-1",
+		"user_id":    float64(7),
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, want %v", key, record[key], value)
+		}
+	}
+}
+
+func TestMiddlewareReplacesInvalidRequestID(t *testing.T) {
+	captureLogs(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "bad id\nwith newline")
+	recorder := httptest.NewRecorder()
+	Middleware(http.NotFoundHandler()).ServeHTTP(recorder, req)
+
+	got := recorder.Header().Get(RequestIDHeader)
+	if got == "" || strings.Contains(got, " ") {
+		t.Errorf("Request ID %q was not replaced by a generated one", got)
+	}
+}
@@ -0,0 +1,76 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	gifExtension  = 0x21
+	gifImage      = 0x2C
+	gifTrailer    = 0x3B
+	gifHeaderSize = 13
+)
+
+var errMalformedGIF = errors.New("malformed gif")
+
+/*
+gifFramePixels adds up the pixels of every frame of a GIF file, without decoding any of them.
+gif.DecodeAll allocates every frame at once, so a small file with thousands of frames
+would take gigabytes even though its logical screen is within maxPixels.
+*/
+func gifFramePixels(data []byte) (int64, error) {
+	if len(data) < gifHeaderSize {
+		return 0, errMalformedGIF
+	}
+
+	i := gifHeaderSize + colorTableSize(data[10])
+
+	var pixels int64
+	for i < len(data) {
+		switch data[i] {
+		case gifTrailer:
+			return pixels, nil
+		case gifExtension:
+			i = skipSubBlocks(data, i+2)
+		case gifImage:
+			if i+10 > len(data) {
+				return 0, errMalformedGIF
+			}
+			width := binary.LittleEndian.Uint16(data[i+5 : i+7])
+			height := binary.LittleEndian.Uint16(data[i+7 : i+9])
+			pixels += int64(width) * int64(height)
+
+			// The descriptor, the local color table and the LZW minimum code size come before the image data
+			i = skipSubBlocks(data, i+10+colorTableSize(data[i+9])+1)
+		default:
+			return 0, errMalformedGIF
+		}
+	}
+
+	// A truncated file is refused by the decoder afterwards
+	return pixels, nil
+}
+
+// colorTableSize returns the length of the color table announced by the packed field of a descriptor.
+func colorTableSize(packed byte) int {
+	if packed&0x80 == 0 {
+		return 0
+	}
+
+	return 3 << ((packed & 0x07) + 1)
+}
+
+// skipSubBlocks returns the position following the data sub-blocks starting at i.
+func skipSubBlocks(data []byte, i int) int {
+	for i < len(data) {
+		size := int(data[i])
+		i++
+		if size == 0 {
+			break
+		}
+		i += size
+	}
+
+	return i
+}
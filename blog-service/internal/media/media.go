@@ -0,0 +1,189 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register the webp decoder
+)
+
+const (
+	// MaxUploadSize is the largest file accepted by the upload endpoint.
+	MaxUploadSize = 10 << 20
+
+	// Images above this many pixels, over all frames for animations, are rejected before being decoded, to avoid decompression bombs
+	maxPixels = 40_000_000
+
+	thumbnailSize = 400
+	jpegQuality   = 90
+)
+
+var ErrUnsupportedType = errors.New("unsupported file type")
+
+// Content types accepted for upload, detected from the file content rather than the client header.
+var allowedTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// Processed is an upload that passed validation and is ready to be stored.
+type Processed struct {
+	ContentType          string
+	Ext                  string
+	ThumbnailContentType string
+	Data                 []byte
+	Thumbnail            []byte
+	Width                int
+	Height               int
+}
+
+func (p *Processed) IsImage() bool {
+	return p.Thumbnail != nil
+}
+
+/*
+Process validates an uploaded file and prepares it for storage.
+Images are decoded and encoded again, which drops EXIF and every other
+metadata block (after applying the EXIF orientation), and get a thumbnail.
+Other allowed files are stored as uploaded.
+@params
+data - the uploaded file.
+@returns
+*Processed - the file to store.
+error - ErrUnsupportedType when the content is not an allowed type.
+*/
+func Process(data []byte) (*Processed, error) {
+	contentType := http.DetectContentType(data)
+
+	ext, ok := allowedTypes[contentType]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	if contentType == "application/pdf" {
+		return &Processed{ContentType: contentType, Ext: ext, Data: data}, nil
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large", cfg.Width, cfg.Height)
+	}
+
+	if contentType == "image/gif" {
+		return processGIF(data)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+
+	if contentType == "image/jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	processed := &Processed{
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+	}
+
+	var buf bytes.Buffer
+
+	// Webp cannot be encoded by the standard library, it is stored as png instead
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+		processed.ContentType, processed.Ext = contentType, ext
+	} else {
+		err = png.Encode(&buf, img)
+		processed.ContentType, processed.Ext = "image/png", ".png"
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	processed.Data = buf.Bytes()
+
+	processed.Thumbnail, processed.ThumbnailContentType, err = thumbnail(img)
+	if err != nil {
+		return nil, err
+	}
+
+	return processed, nil
+}
+
+// processGIF re-encodes every frame, keeping animations while dropping comments and extensions.
+func processGIF(data []byte) (*Processed, error) {
+	pixels, err := gifFramePixels(data)
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if pixels > maxPixels {
+		return nil, fmt.Errorf("animation of %d pixels over all frames is too large", pixels)
+	}
+
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil || len(anim.Image) == 0 {
+		return nil, ErrUnsupportedType
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	first := anim.Image[0]
+
+	thumb, thumbType, err := thumbnail(first)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Processed{
+		ContentType:          "image/gif",
+		Ext:                  ".gif",
+		ThumbnailContentType: thumbType,
+		Data:                 buf.Bytes(),
+		Thumbnail:            thumb,
+		Width:                anim.Config.Width,
+		Height:               anim.Config.Height,
+	}, nil
+}
+
+// thumbnail scales an image down to fit in a thumbnailSize square, keeping the aspect ratio.
+func thumbnail(img image.Image) ([]byte, string, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width > thumbnailSize || height > thumbnailSize {
+		if width >= height {
+			height = max(1, height*thumbnailSize/width)
+			width = thumbnailSize
+		} else {
+			width = max(1, width*thumbnailSize/height)
+			height = thumbnailSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, "", fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	return buf.Bytes(), "image/jpeg", nil
+}
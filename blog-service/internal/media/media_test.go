@@ -0,0 +1,127 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"testing"
+)
+
+// exifSegment builds an APP1 segment holding only an orientation tag.
+func exifSegment(orientation byte) []byte {
+	tiff := []byte{
+		'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08, // big endian header, IFD0 at offset 8
+		0x00, 0x01, // one entry
+		0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, orientation, 0x00, 0x00, // orientation, SHORT, count 1
+		0x00, 0x00, 0x00, 0x00, // no next IFD
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	length := len(payload) + 2
+
+	return append([]byte{0xFF, 0xE1, byte(length >> 8), byte(length)}, payload...)
+}
+
+func testJPEG(t *testing.T, width, height int, orientation byte) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("Failed to encode test image: %s", err)
+	}
+
+	// Insert the EXIF segment right after the SOI marker
+	data := buf.Bytes()
+	withExif := append([]byte{}, data[:2]...)
+	withExif = append(withExif, exifSegment(orientation)...)
+
+	return append(withExif, data[2:]...)
+}
+
+func TestProcessStripsExifAndRotates(t *testing.T) {
+	const width, height = 800, 200
+
+	data := testJPEG(t, width, height, 6)
+	if jpegOrientation(data) != 6 {
+		t.Fatalf("Test image has no readable orientation")
+	}
+
+	processed, err := Process(data)
+	if err != nil {
+		t.Fatalf("Failed to process image: %s", err)
+	}
+
+	if bytes.Contains(processed.Data, []byte("Exif")) {
+		t.Errorf("Processed image still contains EXIF data")
+	}
+
+	if processed.Width != height || processed.Height != width {
+		t.Errorf("got %dx%d, wanted the rotated %dx%d", processed.Width, processed.Height, height, width)
+	}
+
+	thumb, _, err := image.DecodeConfig(bytes.NewReader(processed.Thumbnail))
+	if err != nil {
+		t.Fatalf("Failed to decode thumbnail: %s", err)
+	}
+
+	if thumb.Width > thumbnailSize || thumb.Height > thumbnailSize {
+		t.Errorf("Thumbnail of %dx%d is bigger than %d", thumb.Width, thumb.Height, thumbnailSize)
+	}
+}
+
+func TestProcessRejectsUnsupportedTypes(t *testing.T) {
+	_, err := Process([]byte("<html><script>alert(1)</script></html>"))
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Expected ErrUnsupportedType, got: %v", err)
+	}
+}
+
+func testGIF(t *testing.T, width, height, frames int) []byte {
+	frame := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{color.Black, color.White})
+
+	anim := &gif.GIF{}
+	for range frames {
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 10)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatalf("Failed to encode test animation: %s", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestGIFFramePixels(t *testing.T) {
+	pixels, err := gifFramePixels(testGIF(t, 30, 20, 3))
+	if err != nil {
+		t.Fatalf("Failed to read animation: %s", err)
+	}
+
+	if pixels != 3*30*20 {
+		t.Errorf("got %d pixels, wanted %d", pixels, 3*30*20)
+	}
+}
+
+func TestProcessRejectsLargeAnimations(t *testing.T) {
+	// Every frame is within the limit, all of them together are not
+	data := testGIF(t, 2000, 2000, 11)
+
+	if _, err := Process(data); err == nil {
+		t.Errorf("Animation of 11 frames of 2000x2000 was accepted")
+	}
+
+	processed, err := Process(testGIF(t, 2000, 2000, 2))
+	if err != nil {
+		t.Fatalf("Failed to process animation: %s", err)
+	}
+	if processed.ContentType != "image/gif" {
+		t.Errorf("got %s, wanted image/gif", processed.ContentType)
+	}
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+var (
+	mongoCommands = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "mongo_commands_total",
+		Help:      "Commands sent to MongoDB, by command name and outcome.",
+	}, []string{"command", "outcome"})
+
+	mongoDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mongo_command_duration_seconds",
+		Help:      "Time taken by MongoDB commands, by command name.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"command"})
+)
+
+// MongoMonitor observes every command the MongoDB driver sends, to be set on the client options.
+func MongoMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			mongoDuration.WithLabelValues(e.CommandName).Observe(e.Duration.Seconds())
+			mongoCommands.WithLabelValues(e.CommandName, "success").Inc()
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			mongoDuration.WithLabelValues(e.CommandName).Observe(e.Duration.Seconds())
+			mongoCommands.WithLabelValues(e.CommandName, "failure").Inc()
+		},
+	}
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	grpcClientCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_client_calls_total",
+		Help:      "Calls made to auth-service, by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcClientDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_client_call_duration_seconds",
+		Help:      "Time taken by calls to auth-service, by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+// UnaryClientInterceptor counts the calls made over a gRPC connection and how long they take.
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	grpcClientDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	grpcClientCalls.WithLabelValues(method, status.Code(err).String()).Inc()
+
+	return err
+}
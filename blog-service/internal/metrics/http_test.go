@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestInstrumentHandlerLabelsByPattern(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/article/{id}", InstrumentHandler("/article/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("ok"))
+	})))
+
+	for _, path := range []string{"/article/1", "/article/2", "/article/missing"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues("/article/{id}", http.MethodGet, "200")); got != 2 {
+		t.Errorf("200 responses = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues("/article/{id}", http.MethodGet, "404")); got != 1 {
+		t.Errorf("404 responses = %v, want 1", got)
+	}
+}
+
+func TestInstrumentHandlerKeepsFlushing(t *testing.T) {
+	handler := InstrumentHandler("/stream", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush through the recorder failed: %v", err)
+		}
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	if !recorder.Flushed {
+		t.Error("Response was not flushed")
+	}
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace   = "blog"
+	readTimeout = 5 * time.Second
+)
+
+// Registry holds every metric of the service, the Go runtime and process ones included.
+var Registry = prometheus.NewRegistry()
+
+// Business counters, incremented by the handlers once the change is stored.
+var (
+	ArticlesCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "articles_created_total",
+		Help:      "Articles created, drafts included.",
+	})
+
+	CommentsPosted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "comments_posted_total",
+		Help:      "Comments stored, by whether they were published or held for moderation.",
+	}, []string{"outcome"})
+
+	LoginsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_failed_total",
+		Help:      "Login attempts that did not return a token, by reason.",
+	}, []string{"reason"})
+
+	OutboxEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_events_total",
+		Help:      "Outbox event deliveries, by event type and whether they were delivered, retried or given up.",
+	}, []string{"type", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		ArticlesCreated,
+		CommentsPosted,
+		LoginsFailed,
+		OutboxEvents,
+		httpRequests,
+		httpDuration,
+		grpcClientCalls,
+		grpcClientDuration,
+		mongoCommands,
+		mongoDuration,
+	)
+}
+
+/*
+NewServer creates the listener exposing /metrics on addr.
+It is kept apart from the API so the metrics are never reachable from the public port.
+*/
+func NewServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry}))
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: readTimeout,
+	}
+}
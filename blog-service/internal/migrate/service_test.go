@@ -0,0 +1,20 @@
+package migrate
+
+import (
+	pg "blog-service/internal/db/postgres"
+	"testing"
+)
+
+func TestServiceMigrationsAreValid(t *testing.T) {
+	migrations, err := Parse(pg.Migrations())
+	if err != nil {
+		t.Fatalf("Migrations of the service are invalid: %v", err)
+	}
+
+	// Gaps usually mean two branches picked the same number and one was renumbered badly
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("Migration %s has version %d, want %d", migration.Name, migration.Version, i+1)
+		}
+	}
+}
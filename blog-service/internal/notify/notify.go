@@ -0,0 +1,164 @@
+package notify
+
+import (
+	"blog-service/internal/db/postgres/models"
+	"blog-service/internal/live"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	queueSize          = 1024
+	subscriberBuffer   = 16
+	storeTimeout       = 5 * time.Second
+	defaultWorkerCount = 2
+)
+
+// Store persists notifications, *postgres.Client implements it.
+type Store interface {
+	InsertNotification(ctx context.Context, notification *models.Notification) error
+}
+
+/*
+Dispatcher delivers notifications in the background.
+Request handlers only hand a notification over with Publish, storing it
+and pushing it to connected clients happens on the workers started by Run,
+so a slow store never delays the request that caused the notification.
+Pushes travel over the live bus, clients connected to any replica receive them
+when the bus is backed by Postgres.
+*/
+type Dispatcher struct {
+	store   Store
+	bus     *live.Bus
+	queue   chan *models.Notification
+	workers int
+}
+
+func NewDispatcher(store Store, bus *live.Bus) *Dispatcher {
+	return &Dispatcher{
+		store:   store,
+		bus:     bus,
+		queue:   make(chan *models.Notification, queueSize),
+		workers: defaultWorkerCount,
+	}
+}
+
+// userStream is the bus stream carrying the notifications of a user.
+func userStream(userID int) string {
+	return "user:" + strconv.Itoa(userID)
+}
+
+/*
+Publish queues a notification for delivery without waiting for it.
+Users are never notified about their own actions. When the queue is full the
+notification is dropped, they are informative and not worth blocking a request for.
+A nil Dispatcher ignores everything, which keeps notifications optional.
+*/
+func (d *Dispatcher) Publish(notification *models.Notification) {
+	if d == nil || notification.RecipientID == notification.ActorID {
+		return
+	}
+
+	select {
+	case d.queue <- notification:
+	default:
+		slog.Warn("Notification queue is full, dropping notification",
+			"type", notification.Type, "recipient", notification.RecipientID)
+	}
+}
+
+// Run delivers queued notifications until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for range d.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.work(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (d *Dispatcher) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-d.queue:
+			d.deliver(ctx, notification)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, notification *models.Notification) {
+	ctx, cancel := context.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+
+	if err := d.Deliver(ctx, notification); err != nil {
+		slog.ErrorContext(ctx, "Failed to store notification",
+			"type", notification.Type, "recipient", notification.RecipientID, "error", err)
+	}
+}
+
+/*
+Deliver stores a notification and pushes it right away, for callers retrying until it is stored.
+Like Publish, it skips the users' own actions.
+*/
+func (d *Dispatcher) Deliver(ctx context.Context, notification *models.Notification) error {
+	if notification.RecipientID == notification.ActorID {
+		return nil
+	}
+
+	if err := d.store.InsertNotification(ctx, notification); err != nil {
+		return err
+	}
+
+	d.push(ctx, notification)
+
+	return nil
+}
+
+/*
+Subscribe returns a channel receiving the notifications of userID as they are stored.
+The channel is closed when the subscriber fell behind or the bus lost events, the client should reconnect.
+The returned function must be called once the subscriber is gone.
+*/
+func (d *Dispatcher) Subscribe(userID int) (<-chan *models.Notification, func()) {
+	sub := d.bus.Subscribe(userStream(userID), 0)
+	ch := make(chan *models.Notification, subscriberBuffer)
+
+	go func() {
+		defer close(ch)
+
+		for event := range sub.Events {
+			notification := &models.Notification{}
+			if err := json.Unmarshal(event.Data, notification); err != nil {
+				slog.Warn("Ignoring malformed notification event", "error", err)
+				continue
+			}
+
+			// A client that does not keep up misses the push, the notification is still stored
+			select {
+			case ch <- notification:
+			default:
+			}
+		}
+	}()
+
+	return ch, sub.Close
+}
+
+// push sends a stored notification to the connected clients of its recipient, on every replica.
+func (d *Dispatcher) push(ctx context.Context, notification *models.Notification) {
+	if err := d.bus.Publish(ctx, userStream(notification.RecipientID), live.NotificationCreated, notification); err != nil {
+		slog.WarnContext(ctx, "Failed to push notification",
+			"type", notification.Type, "recipient", notification.RecipientID, "error", err)
+	}
+}
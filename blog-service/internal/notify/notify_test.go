@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"blog-service/internal/db/postgres/models"
+	"blog-service/internal/live"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	mu     sync.Mutex
+	nextID int
+	stored []models.Notification
+}
+
+func (s *memoryStore) InsertNotification(_ context.Context, notification *models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	notification.ID = s.nextID
+	s.stored = append(s.stored, *notification)
+
+	return nil
+}
+
+func TestDispatcherStoresAndPushes(t *testing.T) {
+	store := &memoryStore{}
+	dispatcher := NewDispatcher(store, live.NewMemoryBus())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Run(ctx)
+
+	updates, unsubscribe := dispatcher.Subscribe(2)
+	defer unsubscribe()
+
+	dispatcher.Publish(&models.Notification{Type: models.NotificationLike, RecipientID: 2, ActorID: 1})
+
+	select {
+	case got := <-updates:
+		if got.ID == 0 || got.Type != models.NotificationLike {
+			t.Errorf("Pushed notification was not stored first: %+v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Notification was not pushed to the subscriber")
+	}
+}
+
+func TestPublishSkipsOwnActions(t *testing.T) {
+	dispatcher := NewDispatcher(&memoryStore{}, live.NewMemoryBus())
+
+	dispatcher.Publish(&models.Notification{Type: models.NotificationComment, RecipientID: 3, ActorID: 3})
+
+	if len(dispatcher.queue) != 0 {
+		t.Errorf("Notification about the user's own action was queued")
+	}
+
+	// A nil dispatcher must be safe to publish to
+	var disabled *Dispatcher
+	disabled.Publish(&models.Notification{RecipientID: 1, ActorID: 2})
+}
+
+func TestSubscribersOnlyReceiveTheirNotifications(t *testing.T) {
+	dispatcher := NewDispatcher(&memoryStore{}, live.NewMemoryBus())
+
+	mine, unsubscribe := dispatcher.Subscribe(2)
+	defer unsubscribe()
+	others, unsubscribeOthers := dispatcher.Subscribe(5)
+	defer unsubscribeOthers()
+
+	if err := dispatcher.Deliver(context.Background(), &models.Notification{Type: models.NotificationFollow, RecipientID: 2, ActorID: 1}); err != nil {
+		t.Fatalf("Failed to deliver notification: %s", err)
+	}
+
+	select {
+	case got := <-mine:
+		if got.RecipientID != 2 || got.Type != models.NotificationFollow {
+			t.Errorf("Pushed notification = %+v, want the follow of user 2", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Notification was not pushed to its recipient")
+	}
+
+	select {
+	case got := <-others:
+		t.Errorf("Notification of user 2 was pushed to user 5: %+v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// The channel is closed once unsubscribed, ending the stream
+	unsubscribe()
+	if _, ok := <-mine; ok {
+		t.Errorf("Subscription channel is still open after unsubscribing")
+	}
+}
@@ -0,0 +1,216 @@
+package outbox
+
+import (
+	"blog-service/internal/db/postgres/models"
+	"blog-service/internal/metrics"
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+const (
+	defaultInterval    = time.Second
+	defaultBatchSize   = 100
+	defaultLease       = time.Minute
+	defaultMaxAttempts = 12
+	handlerTimeout     = 10 * time.Second
+	baseDelay          = time.Second
+	maxDelay           = 15 * time.Minute
+)
+
+// Store holds the outbox, *postgres.Client implements it.
+type Store interface {
+	ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error)
+	ExtendEventLease(ctx context.Context, id int64, attempts int, lease time.Duration) (bool, error)
+	MarkEventHandled(ctx context.Context, id int64, handler string) error
+	MarkEventDelivered(ctx context.Context, id int64) error
+	MarkEventFailed(ctx context.Context, id int64, cause string, retryAt *time.Time) error
+}
+
+/*
+HandlerFunc reacts to an event. Delivery is at least once: a handler can see the same event
+again when the relay stops between running it and recording it, event.Key tells them apart.
+*/
+type HandlerFunc func(ctx context.Context, event *models.OutboxEvent) error
+
+type handler struct {
+	name string
+	fn   HandlerFunc
+}
+
+/*
+ImportFunc moves into the outbox the events another store recorded with its own writes.
+It must be safe to repeat: an event imported twice has to be recorded once.
+*/
+type ImportFunc func(ctx context.Context) error
+
+type importer struct {
+	name string
+	fn   ImportFunc
+}
+
+/*
+Relay delivers the events of the outbox to the handlers registered for their type.
+An event is delivered once all its handlers succeeded, a failing handler is retried
+with an exponential backoff while the ones that succeeded are not run again.
+Events are not ordered: a failing event does not hold back the next ones.
+A batch can take longer than the lease it was claimed with, so the lease of each event is
+extended before its handlers run, and an event claimed again by another relay meanwhile is skipped.
+*/
+type Relay struct {
+	store       Store
+	handlers    map[string][]handler
+	importers   []importer
+	interval    time.Duration
+	batchSize   int
+	lease       time.Duration
+	maxAttempts int
+}
+
+func NewRelay(store Store) *Relay {
+	return &Relay{
+		store:       store,
+		handlers:    map[string][]handler{},
+		interval:    defaultInterval,
+		batchSize:   defaultBatchSize,
+		lease:       defaultLease,
+		maxAttempts: defaultMaxAttempts,
+	}
+}
+
+/*
+Handle registers fn for the events of eventType. The name is recorded once fn succeeded,
+it must stay the same across releases or the events pending during an upgrade run fn again.
+*/
+func (r *Relay) Handle(eventType, name string, fn HandlerFunc) {
+	r.handlers[eventType] = append(r.handlers[eventType], handler{name: name, fn: fn})
+}
+
+// Import registers fn, it runs before every batch so the events it imports are delivered with it.
+func (r *Relay) Import(name string, fn ImportFunc) {
+	r.importers = append(r.importers, importer{name: name, fn: fn})
+}
+
+// Run delivers the pending events immediately and then on every tick until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		// A full batch means more are waiting, go on without waiting for the tick
+		if r.deliverBatch(ctx) == r.batchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverBatch delivers the events that are due and returns how many were claimed.
+func (r *Relay) deliverBatch(ctx context.Context) int {
+	for _, imp := range r.importers {
+		if err := r.runImport(ctx, imp); err != nil {
+			// The events stay where they are and are imported on the next batch
+			slog.ErrorContext(ctx, "Failed to import events into the outbox", "source", imp.name, "error", err)
+		}
+	}
+
+	events, err := r.store.ClaimEvents(ctx, r.batchSize, r.lease)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to claim outbox events", "error", err)
+		return 0
+	}
+
+	for i := range events {
+		r.deliver(ctx, &events[i])
+	}
+
+	return len(events)
+}
+
+func (r *Relay) deliver(ctx context.Context, event *models.OutboxEvent) {
+	handlers := r.handlers[event.Type]
+
+	// Every handler may take handlerTimeout, recording the outcome gets one more
+	lease := max(r.lease, time.Duration(len(handlers)+1)*handlerTimeout)
+	owned, err := r.store.ExtendEventLease(ctx, event.ID, event.Attempts, lease)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to extend outbox event lease", "event", event.Key, "error", err)
+		return
+	}
+	if !owned {
+		return
+	}
+
+	for _, h := range handlers {
+		if slices.Contains(event.Handled, h.name) {
+			continue
+		}
+
+		if err := r.run(ctx, h, event); err != nil {
+			r.fail(ctx, event, h.name, err)
+			return
+		}
+
+		if err := r.store.MarkEventHandled(ctx, event.ID, h.name); err != nil {
+			r.fail(ctx, event, h.name, err)
+			return
+		}
+	}
+
+	if err := r.store.MarkEventDelivered(ctx, event.ID); err != nil {
+		// The lease runs out and the event is delivered again, its handlers are skipped
+		slog.ErrorContext(ctx, "Failed to mark outbox event delivered", "event", event.Key, "error", err)
+		return
+	}
+
+	metrics.OutboxEvents.WithLabelValues(event.Type, "delivered").Inc()
+}
+
+func (r *Relay) run(ctx context.Context, h handler, event *models.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, handlerTimeout)
+	defer cancel()
+
+	return h.fn(ctx, event)
+}
+
+func (r *Relay) runImport(ctx context.Context, imp importer) error {
+	ctx, cancel := context.WithTimeout(ctx, handlerTimeout)
+	defer cancel()
+
+	return imp.fn(ctx)
+}
+
+// fail schedules the next attempt at the event, or gives up on it after maxAttempts.
+func (r *Relay) fail(ctx context.Context, event *models.OutboxEvent, handlerName string, cause error) {
+	var retryAt *time.Time
+	outcome := "failed"
+	if event.Attempts < r.maxAttempts {
+		at := time.Now().Add(backoff(event.Attempts))
+		retryAt = &at
+		outcome = "retried"
+	}
+
+	slog.WarnContext(ctx, "Outbox event handler failed", "event", event.Key, "type", event.Type,
+		"handler", handlerName, "attempts", event.Attempts, "retry_at", retryAt, "error", cause)
+	metrics.OutboxEvents.WithLabelValues(event.Type, outcome).Inc()
+
+	if err := r.store.MarkEventFailed(ctx, event.ID, handlerName+": "+cause.Error(), retryAt); err != nil {
+		slog.ErrorContext(ctx, "Failed to record outbox event failure", "event", event.Key, "error", err)
+	}
+}
+
+// backoff is the delay before the next attempt after the given number of attempts, doubling up to maxDelay.
+func backoff(attempts int) time.Duration {
+	delay := baseDelay
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxDelay)
+}
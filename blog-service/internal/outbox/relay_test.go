@@ -0,0 +1,205 @@
+package outbox
+
+import (
+	"blog-service/internal/db/postgres/models"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	events []*models.OutboxEvent
+	// reclaimed are the events another relay claimed again after their lease ran out
+	reclaimed map[int64]bool
+	delivered map[int64]bool
+	retryAt   map[int64]*time.Time
+	failed    map[int64]bool
+}
+
+func newMemoryStore(events ...*models.OutboxEvent) *memoryStore {
+	return &memoryStore{
+		events:    events,
+		reclaimed: map[int64]bool{},
+		delivered: map[int64]bool{},
+		retryAt:   map[int64]*time.Time{},
+		failed:    map[int64]bool{},
+	}
+}
+
+// ClaimEvents ignores the retry times, tests deliver again right away.
+func (s *memoryStore) ClaimEvents(_ context.Context, limit int, _ time.Duration) ([]models.OutboxEvent, error) {
+	claimed := []models.OutboxEvent{}
+	for _, event := range s.events {
+		if len(claimed) == limit || s.delivered[event.ID] || s.failed[event.ID] {
+			continue
+		}
+		event.Attempts++
+		claimed = append(claimed, *event)
+	}
+
+	return claimed, nil
+}
+
+func (s *memoryStore) ExtendEventLease(_ context.Context, id int64, _ int, _ time.Duration) (bool, error) {
+	return !s.reclaimed[id] && !s.delivered[id] && !s.failed[id], nil
+}
+
+func (s *memoryStore) find(id int64) *models.OutboxEvent {
+	for _, event := range s.events {
+		if event.ID == id {
+			return event
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStore) MarkEventHandled(_ context.Context, id int64, handler string) error {
+	event := s.find(id)
+	if !slices.Contains(event.Handled, handler) {
+		event.Handled = append(event.Handled, handler)
+	}
+
+	return nil
+}
+
+func (s *memoryStore) MarkEventDelivered(_ context.Context, id int64) error {
+	s.delivered[id] = true
+	return nil
+}
+
+func (s *memoryStore) MarkEventFailed(_ context.Context, id int64, _ string, retryAt *time.Time) error {
+	s.retryAt[id] = retryAt
+	s.failed[id] = retryAt == nil
+	return nil
+}
+
+func TestRelayRetriesOnlyFailedHandlers(t *testing.T) {
+	store := newMemoryStore(&models.OutboxEvent{ID: 1, Key: "a", Type: models.EventCommentCreated})
+	relay := NewRelay(store)
+
+	counted, notified := 0, 0
+	relay.Handle(models.EventCommentCreated, "count", func(context.Context, *models.OutboxEvent) error {
+		counted++
+		return nil
+	})
+	relay.Handle(models.EventCommentCreated, "notify", func(context.Context, *models.OutboxEvent) error {
+		notified++
+		if notified == 1 {
+			return errors.New("store unavailable")
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+	relay.deliverBatch(ctx)
+
+	if store.delivered[1] || store.retryAt[1] == nil {
+		t.Fatalf("Failed event should be scheduled again, delivered = %v", store.delivered[1])
+	}
+
+	relay.deliverBatch(ctx)
+
+	if !store.delivered[1] {
+		t.Fatal("Event was not delivered once every handler succeeded")
+	}
+	if counted != 1 || notified != 2 {
+		t.Errorf("Handlers ran %d and %d times, want the successful one once and the failing one twice", counted, notified)
+	}
+}
+
+func TestRelayGivesUpAfterMaxAttempts(t *testing.T) {
+	store := newMemoryStore(&models.OutboxEvent{ID: 1, Key: "a", Type: models.EventArticleDeleted})
+	relay := NewRelay(store)
+	relay.maxAttempts = 3
+
+	runs := 0
+	relay.Handle(models.EventArticleDeleted, "cascade", func(context.Context, *models.OutboxEvent) error {
+		runs++
+		return errors.New("article still exists")
+	})
+
+	for range 5 {
+		relay.deliverBatch(context.Background())
+	}
+
+	if !store.failed[1] || runs != 3 {
+		t.Errorf("failed = %v after %d runs, want the event given up after 3", store.failed[1], runs)
+	}
+}
+
+func TestRelayDeliversEventsWithoutHandlers(t *testing.T) {
+	store := newMemoryStore(&models.OutboxEvent{ID: 1, Key: "a", Type: "unknown"})
+
+	NewRelay(store).deliverBatch(context.Background())
+
+	if !store.delivered[1] {
+		t.Error("Event nobody handles should be delivered")
+	}
+}
+
+func TestRelayImportsEventsBeforeDelivering(t *testing.T) {
+	store := newMemoryStore()
+	relay := NewRelay(store)
+
+	runs := 0
+	relay.Handle(models.EventArticlePublished, "broker", func(context.Context, *models.OutboxEvent) error {
+		runs++
+		return nil
+	})
+	relay.Import("failing", func(context.Context) error {
+		return errors.New("store unavailable")
+	})
+	relay.Import("articles", func(context.Context) error {
+		store.events = append(store.events, &models.OutboxEvent{ID: 1, Key: "a", Type: models.EventArticlePublished})
+		return nil
+	})
+
+	relay.deliverBatch(context.Background())
+
+	if !store.delivered[1] || runs != 1 {
+		t.Errorf("delivered = %v after %d runs, want the imported event delivered in the same batch", store.delivered[1], runs)
+	}
+}
+
+func TestBackoffDoublesUpToTheMaximum(t *testing.T) {
+	tests := map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		5:  16 * time.Second,
+		30: maxDelay,
+	}
+
+	for attempts, want := range tests {
+		if got := backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestRelaySkipsEventsClaimedAgain(t *testing.T) {
+	store := newMemoryStore(
+		&models.OutboxEvent{ID: 1, Key: "a", Type: models.EventLikeAdded},
+		&models.OutboxEvent{ID: 2, Key: "b", Type: models.EventLikeAdded},
+	)
+	relay := NewRelay(store)
+
+	handled := []string{}
+	relay.Handle(models.EventLikeAdded, "count", func(_ context.Context, event *models.OutboxEvent) error {
+		handled = append(handled, event.Key)
+		// The first event took so long that the lease of the second one ran out
+		store.reclaimed[2] = true
+		return nil
+	})
+
+	relay.deliverBatch(context.Background())
+
+	if !slices.Equal(handled, []string{"a"}) {
+		t.Errorf("Handled %v, want only the event still owned", handled)
+	}
+	if store.delivered[2] {
+		t.Errorf("Event claimed by another relay was marked delivered")
+	}
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pruneEvery is how many requests pass between sweeps of buckets that filled up again.
+const pruneEvery = 1024
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+/*
+MemoryStore keeps the buckets of a single instance in memory.
+Every replica counts on its own, use RedisStore when running more than one.
+*/
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	s.calls++
+	if s.calls%pruneEvery == 0 {
+		s.prune(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+
+	// Refill for the time passed since the last request
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = min(float64(limit.Burst), b.tokens+float64(elapsed)/float64(limit.interval()))
+		b.updated = now
+	}
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	decision := decide(limit, b.tokens, allowed)
+	b.full = now.Add(decision.Reset)
+
+	return decision, nil
+}
+
+// prune forgets buckets that are full again, they are the same as a bucket that was never used.
+func (s *MemoryStore) prune(now time.Time) {
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
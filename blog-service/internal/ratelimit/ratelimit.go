@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Limit describes a token bucket: it holds at most Burst tokens and refills completely over Period.
+Every request takes one token, so a client can send Burst requests at once
+and then keeps a steady Burst per Period.
+*/
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// Per creates a limit of n requests per period.
+func Per(n int, period time.Duration) Limit {
+	return Limit{Burst: n, Period: period}
+}
+
+// interval is how long the bucket takes to refill one token.
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Burst)
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Burst, l.Period)
+}
+
+/*
+ParseLimit reads a limit written as "requests/period", e.g. "10/1m" or "100/1h".
+*/
+func ParseLimit(value string) (Limit, error) {
+	count, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected requests/period", value)
+	}
+
+	burst, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || burst <= 0 {
+		return Limit{}, fmt.Errorf("invalid request count in rate limit %q", value)
+	}
+
+	duration, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || duration <= 0 {
+		return Limit{}, fmt.Errorf("invalid period in rate limit %q", value)
+	}
+
+	return Per(burst, duration), nil
+}
+
+// Decision is the outcome of taking a token.
+type Decision struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until the next token, only set when the request was refused
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+/*
+Store keeps the token buckets.
+Take removes a token from the bucket under key, creating a full bucket the first time a key is seen.
+*/
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Decision, error)
+}
+
+// decide builds the decision from the tokens left in a bucket after a request.
+func decide(limit Limit, tokens float64, allowed bool) Decision {
+	interval := limit.interval()
+
+	decision := Decision{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit.Burst) - tokens) * float64(interval)),
+	}
+
+	if !allowed {
+		decision.RetryAfter = time.Duration((1 - tokens) * float64(interval))
+	}
+
+	return decision
+}
@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"blog-service/internal/db/testutil"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("10/1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit != Per(10, time.Minute) {
+		t.Errorf("got %v, want 10/1m0s", limit)
+	}
+
+	for _, value := range []string{"", "10", "0/1m", "ten/1m", "10/soon", "10/-1m"} {
+		if _, err := ParseLimit(value); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
+
+func TestMemoryStoreRefills(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(0, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	limit := Per(3, 3*time.Second)
+
+	for i := 2; i >= 0; i-- {
+		decision, err := store.Take(ctx, "client", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decision.Allowed || decision.Remaining != i {
+			t.Fatalf("request %d: got %+v, want allowed with %d remaining", 3-i, decision, i)
+		}
+	}
+
+	decision, _ := store.Take(ctx, "client", limit)
+	if decision.Allowed {
+		t.Fatal("expected the empty bucket to refuse")
+	}
+	if decision.RetryAfter != time.Second {
+		t.Errorf("retry after %s, want 1s", decision.RetryAfter)
+	}
+	if decision.Reset != 3*time.Second {
+		t.Errorf("reset after %s, want 3s", decision.Reset)
+	}
+
+	if other, _ := store.Take(ctx, "other", limit); !other.Allowed {
+		t.Error("buckets should be separate per key")
+	}
+
+	now = now.Add(time.Second)
+	if decision, _ := store.Take(ctx, "client", limit); !decision.Allowed || decision.Remaining != 0 {
+		t.Errorf("after one refill: got %+v, want allowed with 0 remaining", decision)
+	}
+
+	now = now.Add(time.Hour)
+	store.prune(now)
+	if len(store.buckets) != 0 {
+		t.Errorf("expected full buckets to be pruned, %d left", len(store.buckets))
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	ctx := context.Background()
+
+	container, addr, err := testutil.CreateRedisContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to setup redis container: %s", err)
+	}
+	defer func() {
+		if err := testcontainers.TerminateContainer(container); err != nil {
+			t.Logf("failed to terminate container: %s", err)
+		}
+	}()
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+
+	store := NewRedisStore(client)
+	limit := Per(2, time.Minute)
+
+	for i := 1; i >= 0; i-- {
+		decision, err := store.Take(ctx, "client", limit)
+		if err != nil {
+			t.Fatalf("Failed to take token: %s", err)
+		}
+		if !decision.Allowed || decision.Remaining != i {
+			t.Fatalf("got %+v, want allowed with %d remaining", decision, i)
+		}
+	}
+
+	decision, err := store.Take(ctx, "client", limit)
+	if err != nil {
+		t.Fatalf("Failed to take token: %s", err)
+	}
+	if decision.Allowed || decision.RetryAfter <= 0 || decision.RetryAfter > 30*time.Second {
+		t.Errorf("got %+v, want refused with a retry within 30s", decision)
+	}
+
+	ttl, err := client.PTTL(ctx, keyPrefix+"client").Result()
+	if err != nil {
+		t.Fatalf("Failed to read expiry: %s", err)
+	}
+	if ttl <= 0 || ttl > time.Minute {
+		t.Errorf("bucket expires in %s, want within a minute", ttl)
+	}
+}
@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+const keyPrefix = "ratelimit:"
+
+/*
+takeScript refills and takes from a bucket in one step, so replicas sharing Redis never race.
+It uses the Redis clock, the clocks of the replicas may disagree.
+The bucket expires once it would be full again, an absent key is a full bucket.
+KEYS[1] - the bucket
+ARGV[1] - burst
+ARGV[2] - microseconds to refill one token
+@returns
+{allowed, tokens left}, tokens as a string because Redis truncates numbers returned from Lua
+*/
+var takeScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1])
+local updated = tonumber(state[2])
+if tokens == nil or updated == nil then
+	tokens = burst
+	updated = now
+end
+
+if now > updated then
+	tokens = math.min(burst, tokens + (now - updated) / interval)
+	updated = now
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(updated))
+redis.call('PEXPIRE', KEYS[1], math.max(1, math.ceil((burst - tokens) * interval / 1000)))
+
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore keeps the buckets in Redis, shared by every replica.
+type RedisStore struct {
+	client redis.UniversalClient
+}
+
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Ping checks that Redis can be reached.
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// Close closes the connections to Redis.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit) (Decision, error) {
+	interval := limit.interval().Microseconds()
+	if interval < 1 {
+		interval = 1
+	}
+
+	res, err := takeScript.Run(ctx, s.client, []string{keyPrefix + key}, limit.Burst, interval).Slice()
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	if len(res) != 2 {
+		return Decision{}, fmt.Errorf("unexpected rate limit script result %v", res)
+	}
+
+	allowed, _ := res[0].(int64)
+	tokens, err := strconv.ParseFloat(fmt.Sprint(res[1]), 64)
+	if err != nil {
+		return Decision{}, fmt.Errorf("invalid token count %v: %w", res[1], err)
+	}
+
+	return decide(limit, tokens, allowed == 1), nil
+}
+
+// Config selects the store of the buckets, the Redis settings are only read by the redis backend.
+type Config struct {
+	Backend       string
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+}
+
+// NewStore creates the store selected by cfg.Backend, memory or redis.
+func NewStore(ctx context.Context, cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case BackendMemory:
+		return NewMemoryStore(), nil
+	case BackendRedis:
+		return newRedisStore(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", cfg.Backend)
+	}
+}
+
+func newRedisStore(ctx context.Context, cfg Config) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(pingCtx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	return NewRedisStore(client), nil
+}
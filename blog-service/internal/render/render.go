@@ -0,0 +1,127 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Source formats an article body can be written in.
+const (
+	FormatMarkdown = "markdown"
+	FormatPlain    = "plain"
+)
+
+// Output formats that can be requested when reading an article.
+const (
+	OutputRaw  = "raw"
+	OutputHTML = "html"
+)
+
+/*
+Version identifies the renderer and sanitizer configuration.
+Cached HTML stored with a different version is stale and must be rendered again.
+Bump it whenever the markdown extensions or the allow-list policy change.
+*/
+const Version = 1
+
+const excerptLength = 200
+
+var (
+	markdown = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+	)
+
+	// Allow-list applied to every piece of rendered HTML before it leaves the service
+	policy = newPolicy()
+
+	// Strips every tag, used for building plain-text excerpts
+	stripPolicy = bluemonday.StrictPolicy()
+
+	whitespaceRe = regexp.MustCompile(`\s+`)
+	blockEndRe   = regexp.MustCompile(`(?i)(</(p|h[1-6]|li|pre|blockquote|div|tr|td|th)>|<br\s*/?>)`)
+)
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+
+	// Keep the language hint on fenced code blocks (```go -> class="language-go")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[a-zA-Z0-9_+\-]+$`)).OnElements("code")
+
+	// GFM task lists
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+
+	p.RequireNoReferrerOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+
+	return p
+}
+
+// IsValidFormat reports whether format is a supported source format.
+func IsValidFormat(format string) bool {
+	return format == FormatMarkdown || format == FormatPlain
+}
+
+/*
+ToHTML renders content written in the given source format into sanitised HTML.
+Unknown or empty formats are treated as plain text, which is how articles
+created before formats existed are stored.
+@params
+format - the source format of the content.
+content - the raw article body.
+@returns
+string - sanitised HTML, safe to embed in a page.
+error - for checking the successful execution of the function.
+*/
+func ToHTML(format, content string) (string, error) {
+	var buf bytes.Buffer
+
+	switch format {
+	case FormatMarkdown:
+		if err := markdown.Convert([]byte(content), &buf); err != nil {
+			return "", fmt.Errorf("failed to render markdown: %w", err)
+		}
+	default:
+		for _, paragraph := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n\n") {
+			paragraph = strings.TrimSpace(paragraph)
+			if paragraph == "" {
+				continue
+			}
+			buf.WriteString("<p>")
+			buf.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>"))
+			buf.WriteString("</p>\n")
+		}
+	}
+
+	return policy.Sanitize(buf.String()), nil
+}
+
+// Excerpt builds a short plain-text summary of rendered HTML for feed cards.
+func Excerpt(renderedHTML string) string {
+	// Keep block boundaries as spaces, otherwise "<p>a</p><p>b</p>" collapses into "ab"
+	spaced := blockEndRe.ReplaceAllString(renderedHTML, "$1 ")
+
+	text := html.UnescapeString(stripPolicy.Sanitize(spaced))
+	text = strings.TrimSpace(whitespaceRe.ReplaceAllString(text, " "))
+
+	if utf8.RuneCountInString(text) <= excerptLength {
+		return text
+	}
+
+	runes := []rune(text)[:excerptLength]
+
+	// Cut at the last word boundary so the excerpt does not end mid-word
+	if i := strings.LastIndexByte(string(runes), ' '); i > 0 {
+		return string(runes)[:i] + "…"
+	}
+
+	return string(runes) + "…"
+}
@@ -0,0 +1,60 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownToHTML(t *testing.T) {
+	content := "# Title\n\nSome **bold** text and a [link](https://example.com).\n\n```go\nfmt.Println(\"hi\")\n```\n"
+
+	got, err := ToHTML(FormatMarkdown, content)
+	if err != nil {
+		t.Fatalf("Failed to render markdown: %s", err)
+	}
+
+	for _, want := range []string{
+		"<h1>Title</h1>",
+		"<strong>bold</strong>",
+		`href="https://example.com"`,
+		`rel="nofollow noreferrer noopener"`,
+		`<code class="language-go">`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Rendered HTML is missing %q\nGot: %s", want, got)
+		}
+	}
+}
+
+func TestSanitizeRemovesScripts(t *testing.T) {
+	content := "Hello <script>alert(1)</script>\n\n<img src=x onerror=alert(1)>\n\n[click](javascript:alert(1))"
+
+	for _, format := range []string{FormatMarkdown, FormatPlain} {
+		got, err := ToHTML(format, content)
+		if err != nil {
+			t.Fatalf("Failed to render %s: %s", format, err)
+		}
+
+		for _, forbidden := range []string{"<script", "<img", `href="javascript`} {
+			if strings.Contains(got, forbidden) {
+				t.Errorf("Rendered %s output contains %q\nGot: %s", format, forbidden, got)
+			}
+		}
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	got := Excerpt("<h1>Title</h1><p>First &amp; second</p>")
+	want := "Title First & second"
+
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+
+	long := "<p>" + strings.Repeat("word ", 100) + "</p>"
+	got = Excerpt(long)
+
+	if !strings.HasSuffix(got, "…") || strings.HasSuffix(got, " …") {
+		t.Errorf("Long excerpt was not cut at a word boundary: %q", got)
+	}
+}
@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"blog-service/internal/db/mongo"
+	"blog-service/internal/db/postgres/models"
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultInterval = 30 * time.Second
+	publishTimeout  = 10 * time.Second
+)
+
+/*
+Scheduler periodically publishes scheduled articles whose publish time has passed.
+The schedule itself lives in Mongo, so nothing is lost when the service restarts:
+the first tick after startup publishes everything that became due while it was down.
+Every published article gets article.published in the same update, the outbox relay delivers it.
+*/
+type Scheduler struct {
+	mongo    *mongo.Client
+	interval time.Duration
+}
+
+func NewScheduler(mongoClient *mongo.Client) *Scheduler {
+	return &Scheduler{
+		mongo:    mongoClient,
+		interval: defaultInterval,
+	}
+}
+
+// Run publishes due articles immediately and then on every tick until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.publishDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) publishDue(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	published, err := s.mongo.PublishDueArticles(ctx, time.Now(), models.EventArticlePublished)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to publish scheduled articles", "error", err)
+	}
+
+	if len(published) > 0 {
+		slog.InfoContext(ctx, "Published scheduled articles", "count", len(published))
+	}
+}
@@ -3,17 +3,23 @@ package handlers
 import (
 	"blog-service/internal/db/mongo"
 	"blog-service/internal/db/postgres"
+	"blog-service/internal/live"
+	"blog-service/internal/metrics"
 	"blog-service/internal/server/models"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"regexp"
 	"strconv"
+	"time"
 )
 
 type ArticleHandler struct {
 	MongoDB    *mongo.Client
 	PostgresDB *postgres.Client
+	Bus        *live.Bus
 }
 
 const commentsPerPage = 10
@@ -21,6 +27,10 @@ const commentsPerPage = 10
 var (
 	ArticleIDRe        = regexp.MustCompile(`/article/[a-f0-9]{24}/$`)
 	ArticleIDNoSlashRe = regexp.MustCompile(`/article/[a-f0-9]{24}$`)
+	ArticleStatusRe    = regexp.MustCompile(`/article/[a-f0-9]{24}/status/?$`)
+	ArticleBySlugRe    = regexp.MustCompile(`/article/by-slug/[a-z0-9\-]+/?$`)
+	ArticleRevisionsRe = regexp.MustCompile(`/article/[a-f0-9]{24}/revisions(/|$)`)
+	ArticleStreamRe    = regexp.MustCompile(`/article/[a-f0-9]{24}/comments/stream/?$`)
 )
 
 func (h *ArticleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -29,6 +39,27 @@ func (h *ArticleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The revision routes share the /article/{id}/ prefix with the slug routes, so they are dispatched here
+	if ArticleRevisionsRe.MatchString(r.URL.Path) {
+		(&RevisionHandler{MongoDB: h.MongoDB}).ServeHTTP(w, r)
+		return
+	}
+
+	if r.Method == http.MethodGet && ArticleStreamRe.MatchString(r.URL.Path) {
+		h.ArticleCommentsStream(w, r)
+		return
+	}
+
+	if r.Method == http.MethodGet && ArticleBySlugRe.MatchString(r.URL.Path) {
+		h.ArticleGetBySlug(w, r)
+		return
+	}
+
+	if r.Method == http.MethodPut && ArticleStatusRe.MatchString(r.URL.Path) {
+		h.ArticleStatusUpdate(w, r)
+		return
+	}
+
 	if ArticleIDRe.MatchString(r.URL.Path) || ArticleIDNoSlashRe.MatchString(r.URL.Path) {
 		switch r.Method {
 		case http.MethodGet:
@@ -46,7 +77,37 @@ func (h *ArticleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ArticleHandler) ArticleGet(w http.ResponseWriter, r *http.Request) {
-	articleID := r.PathValue("id")
+	h.writeArticle(w, r, r.PathValue("id"))
+}
+
+// ArticleGetBySlug serves an article by its slug and redirects old slugs to the current one.
+func (h *ArticleHandler) ArticleGetBySlug(w http.ResponseWriter, r *http.Request) {
+	var invalidArticleErr *models.InvalidArticleError
+
+	resolved, err := models.ResolveSlug(r.Context(), h.MongoDB, r.PathValue("slug"))
+	switch {
+	case errors.As(err, &invalidArticleErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if resolved.Moved {
+		location := "/article/by-slug/" + resolved.Slug
+		if r.URL.RawQuery != "" {
+			location += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, location, http.StatusMovedPermanently)
+		return
+	}
+
+	h.writeArticle(w, r, resolved.ID)
+}
+
+// writeArticle responds with the article and a page of its comments.
+func (h *ArticleHandler) writeArticle(w http.ResponseWriter, r *http.Request, articleID string) {
 	pageParam := r.URL.Query().Get("page")
 	var page int
 	var err error
@@ -60,7 +121,13 @@ func (h *ArticleHandler) ArticleGet(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	article, err := models.GetArticleByID(r.Context(), h.MongoDB, articleID)
+	var paramErr *models.ParamError
+
+	article, err := models.GetArticleByID(r.Context(), h.MongoDB, articleID, r.URL.Query().Get("format"))
+	if errors.As(err, &paramErr) {
+		http.Error(w, "invalid 'format' query parameter, must be raw or html", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		return
@@ -74,6 +141,12 @@ func (h *ArticleHandler) ArticleGet(w http.ResponseWriter, r *http.Request) {
 
 	article.Comments = comments
 
+	// The flag is cosmetic, the article is still served when it cannot be read
+	article.Bookmarked, err = models.IsBookmarked(r.Context(), h.PostgresDB, articleID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error checking bookmark of article", "article", articleID, "error", err)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(article)
 	if err != nil {
@@ -83,7 +156,73 @@ func (h *ArticleHandler) ArticleGet(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ArticleHandler) ArticleUpdate(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusNotImplemented)
+	var updateDTO models.ArticleUpdateDTO
+	var paramErr *models.ParamError
+	var invalidArticleErr *models.InvalidArticleError
+	var unauthorizedErr *models.UnauthorizedError
+	var forbiddenErr *models.ForbiddenError
+
+	err := json.NewDecoder(r.Body).Decode(&updateDTO)
+	if err != nil {
+		http.Error(w, paramErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	revision, err := models.UpdateArticle(r.Context(), h.MongoDB, r.PathValue("id"), &updateDTO)
+	switch {
+	case errors.As(err, &paramErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.As(err, &invalidArticleErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.As(err, &unauthorizedErr):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case errors.As(err, &forbiddenErr):
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case err != nil:
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	err = json.NewEncoder(w).Encode(models.ArticleUpdateResponse{Revision: revision})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (h *ArticleHandler) ArticleStatusUpdate(w http.ResponseWriter, r *http.Request) {
+	var statusDTO models.ArticleStatusUpdateDTO
+	var paramErr *models.ParamError
+	var invalidArticleErr *models.InvalidArticleError
+	var unauthorizedErr *models.UnauthorizedError
+	var forbiddenErr *models.ForbiddenError
+
+	err := json.NewDecoder(r.Body).Decode(&statusDTO)
+	if err != nil {
+		http.Error(w, paramErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = models.UpdateArticleStatus(r.Context(), h.MongoDB, r.PathValue("id"), &statusDTO)
+	switch {
+	case errors.As(err, &paramErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &invalidArticleErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.As(err, &unauthorizedErr):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.As(err, &forbiddenErr):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case err != nil:
+		w.WriteHeader(http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
 }
 
 func (h *ArticleHandler) ArticleDelete(w http.ResponseWriter, r *http.Request) {
@@ -101,7 +240,7 @@ func (h *ArticleHandler) ArticleCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := models.CreateArticle(r.Context(), h.MongoDB, &article)
+	created, err := models.CreateArticle(r.Context(), h.MongoDB, &article)
 	switch {
 	case errors.As(err, &paramError):
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -113,11 +252,103 @@ func (h *ArticleHandler) ArticleCreate(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	metrics.ArticlesCreated.Inc()
 
 	w.Header().Set("Content-Type", "application/json")
 
-	err = json.NewEncoder(w).Encode(models.ArticleCreateResponse{ID: id})
+	err = json.NewEncoder(w).Encode(created)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
+
+/*
+ArticleCommentsStream pushes changes to the comments of an article as server-sent events.
+Clients reconnecting with Last-Event-ID first receive what they missed, or a reset event
+asking them to reload the comments when the missed events are no longer known.
+*/
+func (h *ArticleHandler) ArticleCommentsStream(w http.ResponseWriter, r *http.Request) {
+	var invalidArticleErr *models.InvalidArticleError
+
+	id := r.PathValue("id")
+
+	err := models.CheckArticleVisible(r.Context(), h.MongoDB, id)
+	if errors.As(err, &invalidArticleErr) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error checking article of comment stream", "error", err)
+		http.Error(w, "Failed to stream comments", http.StatusInternalServerError)
+		return
+	}
+
+	if h.Bus == nil {
+		http.Error(w, "Comment streaming is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	// EventSource sends the header on reconnects, the query parameter allows resuming a fresh connection
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	resumeFrom, _ := strconv.ParseInt(lastEventID, 10, 64)
+
+	sub := h.Bus.Subscribe(id, resumeFrom)
+	defer sub.Close()
+
+	rc, err := startEventStream(w)
+	if err != nil {
+		return
+	}
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds()); err != nil {
+		return
+	}
+
+	if sub.Reset {
+		if _, err := fmt.Fprint(w, "event: reset\ndata: {}\n\n"); err != nil {
+			return
+		}
+	}
+
+	for _, event := range sub.Replay {
+		if err := writeLiveEvent(w, event); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-streamShutdown(r.Context()):
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-sub.Events:
+			// The subscription was dropped, the client reconnects and resumes
+			if !ok {
+				return
+			}
+			if err := writeLiveEvent(w, event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeLiveEvent(w http.ResponseWriter, event live.Event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	return err
+}
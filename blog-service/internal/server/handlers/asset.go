@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"blog-service/internal/blob"
+	"blog-service/internal/db/mongo"
+	"blog-service/internal/media"
+	"blog-service/internal/server/models"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Room for the multipart boundaries and headers around the file itself.
+const multipartOverhead = 1 << 20
+
+type AssetHandler struct {
+	MongoDB *mongo.Client
+	Store   blob.BlobStore
+}
+
+var (
+	UploadRe   = regexp.MustCompile(`/upload/?$`)
+	AssetGetRe = regexp.MustCompile(`/assets/[a-f0-9]{24}(_thumb)?\.[a-z]+$`)
+)
+
+func (h *AssetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && UploadRe.MatchString(r.URL.Path):
+		h.AssetUpload(w, r)
+		return
+	case r.Method == http.MethodGet && AssetGetRe.MatchString(r.URL.Path):
+		h.AssetGet(w, r)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// AssetUpload stores the file sent in the 'file' field of a multipart form.
+func (h *AssetHandler) AssetUpload(w http.ResponseWriter, r *http.Request) {
+	var paramErr *models.ParamError
+	var unauthorizedErr *models.UnauthorizedError
+	var unsupportedErr *models.UnsupportedMediaError
+
+	if models.GetClaimsFromContext(r.Context()) == nil {
+		http.Error(w, (&models.UnauthorizedError{}).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, media.MaxUploadSize+multipartOverhead)
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "file too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "missing 'file' form field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, media.MaxUploadSize+1))
+	if err != nil {
+		http.Error(w, "failed to read file", http.StatusBadRequest)
+		return
+	}
+
+	if len(data) > media.MaxUploadSize {
+		http.Error(w, "file too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	asset, err := models.UploadAsset(r.Context(), h.MongoDB, h.Store, header.Filename, data)
+	switch {
+	case errors.As(err, &unsupportedErr):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	case errors.As(err, &paramErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.As(err, &unauthorizedErr):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Error storing upload", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, asset)
+}
+
+// AssetGet streams a stored blob. Keys are never reused, so responses can be cached forever.
+func (h *AssetHandler) AssetGet(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, models.AssetURLPrefix)
+
+	body, info, err := h.Store.Get(r.Context(), key)
+	if errors.Is(err, blob.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading blob", "key", key, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", info.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if _, err := io.Copy(w, body); err != nil {
+		slog.ErrorContext(r.Context(), "Error sending blob", "key", key, "error", err)
+	}
+}
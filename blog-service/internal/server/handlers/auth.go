@@ -2,6 +2,8 @@ package handlers
 
 import (
 	pb "blog-service/internal/grpc/protobuf"
+	"blog-service/internal/logging"
+	"blog-service/internal/metrics"
 	"blog-service/internal/server/models"
 	"context"
 	"encoding/json"
@@ -47,6 +49,9 @@ func AuthMiddleware(h http.Handler, authClient pb.AuthServiceClient) http.Handle
 
 		tokenString := headerAccess[len(bearerSchema):]
 		userClaims = models.VerifyAuthToken(r.Context(), authClient, tokenString)
+		if userClaims != nil {
+			logging.SetUserID(r.Context(), int64(userClaims.ID))
+		}
 	})
 }
 
@@ -79,12 +84,15 @@ func (h *AuthHandler) AuthLogin(w http.ResponseWriter, r *http.Request) {
 
 	switch {
 	case errors.As(err, &ParamErr):
+		metrics.LoginsFailed.WithLabelValues("invalid_request").Inc()
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	case errors.As(err, &InvalidLoginErr):
+		metrics.LoginsFailed.WithLabelValues("invalid_credentials").Inc()
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	case err != nil:
+		metrics.LoginsFailed.WithLabelValues("error").Inc()
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -3,7 +3,7 @@ package handlers
 import (
 	"blog-service/internal/db/mongo"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -53,14 +53,14 @@ func (h *BlogHandler) getBlogPage(w http.ResponseWriter, r *http.Request) {
 
 	articles, err := h.Mongo.GetArticlesByEngagement(page, limit)
 	if err != nil {
-		log.Printf("Error retrieving articles: %v", err)
+		slog.ErrorContext(r.Context(), "Error retrieving articles", "error", err)
 		http.Error(w, "Failed to retrieve articles", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(articles); err != nil {
-		log.Printf("Error encoding response: %v", err)
+		slog.ErrorContext(r.Context(), "Error encoding response", "error", err)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 	}
 }
@@ -81,13 +81,13 @@ func (h *BlogHandler) getArticlesByPublisher(w http.ResponseWriter, r *http.Requ
 
 	articles, err := h.Mongo.FindArticlesByPublisherID(r.Context(), publisherID)
 	if err != nil {
-		log.Printf("Error retrieving articles by publisher ID %d: %v", publisherID, err)
+		slog.ErrorContext(r.Context(), "Error retrieving articles by publisher", "publisher", publisherID, "error", err)
 		http.Error(w, "Failed to retrieve articles", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(articles); err != nil {
-		log.Printf("Error encoding response: %v", err)
+		slog.ErrorContext(r.Context(), "Error encoding response", "error", err)
 	}
 }
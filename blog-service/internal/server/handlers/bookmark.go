@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"blog-service/internal/db/mongo"
+	"blog-service/internal/db/postgres"
+	"blog-service/internal/server/models"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+// BookmarkHandler serves the reading list of the authenticated user.
+type BookmarkHandler struct {
+	MongoDB    *mongo.Client
+	PostgresDB *postgres.Client
+}
+
+var (
+	BookmarksRe = regexp.MustCompile(`^/bookmarks/?$`)
+	BookmarkRe  = regexp.MustCompile(`^/bookmarks/([a-f0-9]{24})/?$`)
+)
+
+func (h *BookmarkHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && BookmarksRe.MatchString(r.URL.Path):
+		h.BookmarkList(w, r)
+	case r.Method == http.MethodPut && BookmarkRe.MatchString(r.URL.Path):
+		articleID := BookmarkRe.FindStringSubmatch(r.URL.Path)[1]
+		writeBookmarkResult(w, r, models.AddBookmark(r.Context(), h.PostgresDB, h.MongoDB, articleID))
+	case r.Method == http.MethodDelete && BookmarkRe.MatchString(r.URL.Path):
+		articleID := BookmarkRe.FindStringSubmatch(r.URL.Path)[1]
+		writeBookmarkResult(w, r, models.RemoveBookmark(r.Context(), h.PostgresDB, articleID))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *BookmarkHandler) BookmarkList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	beforeID := 0
+	if before := query.Get("before"); before != "" {
+		var err error
+		if beforeID, err = strconv.Atoi(before); err != nil {
+			http.Error(w, "Invalid 'before' query parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	bookmarks, err := models.GetBookmarks(r.Context(), h.PostgresDB, h.MongoDB, beforeID, limit)
+	if err != nil {
+		writeBookmarkError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, bookmarks)
+}
+
+func writeBookmarkResult(w http.ResponseWriter, r *http.Request, err error) {
+	if err != nil {
+		writeBookmarkError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeBookmarkError(w http.ResponseWriter, r *http.Request, err error) {
+	var paramErr *models.ParamError
+	var invalidArticleErr *models.InvalidArticleError
+	var unauthorizedErr *models.UnauthorizedError
+
+	switch {
+	case errors.As(err, &paramErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &invalidArticleErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.As(err, &unauthorizedErr):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	default:
+		slog.ErrorContext(r.Context(), "Error handling bookmarks", "error", err)
+		http.Error(w, "Failed to process bookmarks", http.StatusInternalServerError)
+	}
+}
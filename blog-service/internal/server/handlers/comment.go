@@ -1,29 +1,43 @@
 package handlers
 
 import (
+	"blog-service/internal/contentfilter"
 	"blog-service/internal/db/mongo"
 	"blog-service/internal/db/postgres"
+	"blog-service/internal/live"
+	"blog-service/internal/metrics"
 	"blog-service/internal/server/models"
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 	"regexp"
+	"strconv"
 )
 
 type CommentHandler struct {
 	MongoDB    *mongo.Client
 	PostgresDB *postgres.Client
+	Bus        *live.Bus
+	Filter     contentfilter.ContentFilter
 }
 
 var (
-	CommentDeleteRe = regexp.MustCompile(`/comment/\d+`)
+	CommentIDRe   = regexp.MustCompile(`/comment/(\d+)/?$`)
+	CommentLikeRe = regexp.MustCompile(`/comment/(\d+)/like/?$`)
 )
 
 func (h *CommentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch {
-	case r.Method == http.MethodDelete && CommentDeleteRe.MatchString(r.URL.Path):
+	case (r.Method == http.MethodPut || r.Method == http.MethodDelete) && CommentLikeRe.MatchString(r.URL.Path):
+		h.CommentLike(w, r)
+		return
+	case r.Method == http.MethodDelete && CommentIDRe.MatchString(r.URL.Path):
 		h.CommentDelete(w, r)
 		return
+	case r.Method == http.MethodPut && CommentIDRe.MatchString(r.URL.Path):
+		h.CommentUpdate(w, r)
+		return
 	case r.Method == http.MethodPost:
 		h.CommentCreate(w, r)
 		return
@@ -31,7 +45,68 @@ func (h *CommentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CommentHandler) CommentDelete(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusNotImplemented)
+	commentID, err := strconv.Atoi(CommentIDRe.FindStringSubmatch(r.URL.Path)[1])
+	if err != nil {
+		http.Error(w, (&models.ParamError{}).Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = models.DeleteComment(r.Context(), h.PostgresDB, h.MongoDB, h.Bus, commentID)
+	writeCommentChangeResult(w, r, err, commentID)
+}
+
+func (h *CommentHandler) CommentUpdate(w http.ResponseWriter, r *http.Request) {
+	var update models.CommentUpdateDTO
+
+	commentID, err := strconv.Atoi(CommentIDRe.FindStringSubmatch(r.URL.Path)[1])
+	if err != nil {
+		http.Error(w, (&models.ParamError{}).Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, (&models.ParamError{}).Error(), http.StatusBadRequest)
+		return
+	}
+
+	if update.Content == "" {
+		http.Error(w, (&models.ParamError{}).Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := models.UpdateComment(r.Context(), h.PostgresDB, h.Bus, h.Filter, commentID, &update)
+
+	var rejectedErr *models.RejectedContentError
+	switch {
+	case errors.As(err, &rejectedErr):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case err == nil && updated.Held:
+		// Like a new held comment, stored but waiting for a moderator
+		writeJSON(w, http.StatusAccepted, updated)
+	default:
+		writeCommentChangeResult(w, r, err, commentID)
+	}
+}
+
+// writeCommentChangeResult answers requests changing an existing comment.
+func writeCommentChangeResult(w http.ResponseWriter, r *http.Request, err error, commentID int) {
+	var paramErr *models.ParamError
+	var unauthorizedErr *models.UnauthorizedError
+	var forbiddenErr *models.ForbiddenError
+
+	switch {
+	case errors.As(err, &paramErr):
+		http.Error(w, "comment not found", http.StatusNotFound)
+	case errors.As(err, &unauthorizedErr):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.As(err, &forbiddenErr):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Error changing comment", "comment", commentID, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
 }
 
 func (h *CommentHandler) CommentCreate(w http.ResponseWriter, r *http.Request) {
@@ -39,6 +114,7 @@ func (h *CommentHandler) CommentCreate(w http.ResponseWriter, r *http.Request) {
 	var invalidArticleErr *models.InvalidArticleError
 	var paramErr *models.ParamError
 	var unauthorizedErr *models.UnauthorizedError
+	var rejectedErr *models.RejectedContentError
 
 	err := json.NewDecoder(r.Body).Decode(&comment)
 	if err != nil {
@@ -46,7 +122,7 @@ func (h *CommentHandler) CommentCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = models.CreateComment(r.Context(), h.PostgresDB, h.MongoDB, &comment)
+	created, err := models.CreateComment(r.Context(), h.PostgresDB, h.MongoDB, h.Bus, h.Filter, &comment)
 	if err != nil {
 		switch {
 		case errors.As(err, &invalidArticleErr):
@@ -57,9 +133,36 @@ func (h *CommentHandler) CommentCreate(w http.ResponseWriter, r *http.Request) {
 			return
 		case errors.As(err, &unauthorizedErr):
 			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		case errors.As(err, &rejectedErr):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
 		default:
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 	}
+
+	// Held comments are stored but wait for a moderator before anyone else sees them
+	status := http.StatusOK
+	outcome := "published"
+	if created.Held {
+		status = http.StatusAccepted
+		outcome = "held"
+	}
+	metrics.CommentsPosted.WithLabelValues(outcome).Inc()
+
+	writeJSON(w, status, created)
+}
+
+// CommentLike likes a comment on PUT and removes the like on DELETE.
+func (h *CommentHandler) CommentLike(w http.ResponseWriter, r *http.Request) {
+	commentID, err := strconv.Atoi(CommentLikeRe.FindStringSubmatch(r.URL.Path)[1])
+	if err != nil {
+		http.Error(w, (&models.ParamError{}).Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = models.LikeComment(r.Context(), h.PostgresDB, h.Bus, commentID, r.Method == http.MethodPut)
+	writeCommentChangeResult(w, r, err, commentID)
 }
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"blog-service/internal/db/mongo"
+	"blog-service/internal/feed"
+	"blog-service/internal/render"
+	"blog-service/internal/server/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	formatRSS  = "rss"
+	formatAtom = "atom"
+
+	// Bounds the memory used by the cache, category feeds are keyed by user supplied names
+	maxCachedFeeds = 256
+)
+
+// FeedHandler serves RSS and Atom feeds with conditional GET support.
+type FeedHandler struct {
+	MongoDB *mongo.Client
+	// SiteURL is the public address of the frontend, used for links in the feeds
+	SiteURL string
+
+	mu    sync.Mutex
+	cache map[string]cachedFeed
+}
+
+// cachedFeed is the last document built for a feed, reused as long as its ETag still matches.
+type cachedFeed struct {
+	etag string
+	body []byte
+}
+
+var (
+	FeedRSSRe      = regexp.MustCompile(`^/feed\.rss$`)
+	FeedAtomRe     = regexp.MustCompile(`^/feed\.atom$`)
+	AuthorFeedRe   = regexp.MustCompile(`^/authors/\d+/feed\.atom$`)
+	CategoryFeedRe = regexp.MustCompile(`^/categories/[^/]+/feed\.atom$`)
+)
+
+func (h *FeedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch {
+	case FeedRSSRe.MatchString(r.URL.Path):
+		h.serveFeed(w, r, formatRSS, &models.FeedRequest{Title: "Blog", SiteURL: h.SiteURL, SelfLink: "/feed.rss"})
+	case FeedAtomRe.MatchString(r.URL.Path):
+		h.serveFeed(w, r, formatAtom, &models.FeedRequest{Title: "Blog", SiteURL: h.SiteURL, SelfLink: "/feed.atom"})
+	case AuthorFeedRe.MatchString(r.URL.Path):
+		publisherID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || publisherID <= 0 {
+			http.NotFound(w, r)
+			return
+		}
+		h.serveFeed(w, r, formatAtom, models.AuthorFeedRequest(h.SiteURL, publisherID))
+	case CategoryFeedRe.MatchString(r.URL.Path):
+		h.serveFeed(w, r, formatAtom, models.CategoryFeedRequest(h.SiteURL, r.PathValue("name")))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// feedETag changes whenever the articles of the feed, one of them, the renderer or the format changes.
+func feedETag(format string, req *models.FeedRequest, state *mongo.FeedState) string {
+	data := fmt.Appendf(nil, "%s|%s|%d|%d", format, req.SelfLink, state.LastModified.UnixNano(), render.Version)
+	for _, id := range state.IDs {
+		data = append(data, id[:]...)
+	}
+	sum := sha256.Sum256(data)
+
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified checks the conditional request headers against the current state of the feed.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	// An empty feed has no date to compare
+	if lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+
+	return err == nil && !lastModified.Truncate(time.Second).After(since)
+}
+
+/*
+serveFeed answers with a 304 when the client is up to date, otherwise with the feed.
+Only the cheap state query runs for unchanged feeds, the articles are loaded
+when the feed changed and its document is not cached yet.
+*/
+func (h *FeedHandler) serveFeed(w http.ResponseWriter, r *http.Request, format string, req *models.FeedRequest) {
+	state, err := models.GetFeedState(r.Context(), h.MongoDB, req.Filter)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error retrieving feed state", "error", err)
+		http.Error(w, "Failed to retrieve feed", http.StatusInternalServerError)
+		return
+	}
+
+	etag := feedETag(format, req, state)
+	cacheKey := format + req.SelfLink
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if !state.LastModified.IsZero() {
+		w.Header().Set("Last-Modified", state.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	contentType := feed.AtomContentType
+	if format == formatRSS {
+		contentType = feed.RSSContentType
+	}
+	w.Header().Set("Content-Type", contentType)
+
+	if notModified(r, etag, state.LastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, ok := h.cached(cacheKey, etag)
+	if !ok {
+		body, err = h.build(r, format, req)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error building feed", "feed", req.SelfLink, "error", err)
+			http.Error(w, "Failed to build feed", http.StatusInternalServerError)
+			return
+		}
+		if len(state.IDs) > 0 {
+			h.store(cacheKey, cachedFeed{etag: etag, body: body})
+		}
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	if _, err := w.Write(body); err != nil {
+		slog.ErrorContext(r.Context(), "Error writing feed", "error", err)
+	}
+}
+
+func (h *FeedHandler) build(r *http.Request, format string, req *models.FeedRequest) ([]byte, error) {
+	f, err := models.BuildFeed(r.Context(), h.MongoDB, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == formatRSS {
+		return feed.RSS(f)
+	}
+
+	return feed.Atom(f)
+}
+
+func (h *FeedHandler) cached(key, etag string) ([]byte, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, ok := h.cache[key]
+	if !ok || entry.etag != etag {
+		return nil, false
+	}
+
+	return entry.body, true
+}
+
+func (h *FeedHandler) store(key string, entry cachedFeed) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cache == nil || len(h.cache) >= maxCachedFeeds {
+		h.cache = map[string]cachedFeed{}
+	}
+	h.cache[key] = entry
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"blog-service/internal/db/mongo"
+	"blog-service/internal/server/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2025, 9, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		header       string
+		value        string
+		lastModified time.Time
+		want         bool
+	}{
+		{"matching etag", "If-None-Match", `W/"abc", "def"`, modified, true},
+		{"other etag", "If-None-Match", `"xyz"`, modified, false},
+		{"unchanged since", "If-Modified-Since", modified.Format(http.TimeFormat), modified, true},
+		{"changed since", "If-Modified-Since", modified.Add(-time.Minute).Format(http.TimeFormat), modified, false},
+		{"empty feed", "If-Modified-Since", modified.Format(http.TimeFormat), time.Time{}, false},
+		{"no condition", "", "", modified, false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/feed.atom", nil)
+		if tt.header != "" {
+			r.Header.Set(tt.header, tt.value)
+		}
+
+		if got := notModified(r, `"def"`, tt.lastModified); got != tt.want {
+			t.Errorf("%s: notModified = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFeedETagChangesWithTheListedArticles(t *testing.T) {
+	modified := time.Date(2025, 9, 19, 12, 0, 0, 0, time.UTC)
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	req := &models.FeedRequest{SelfLink: "/feed.atom"}
+
+	etag := feedETag(formatAtom, req, &mongo.FeedState{LastModified: modified, IDs: []primitive.ObjectID{a, b}})
+
+	// One article unpublished and another published, same count and same newest date
+	swapped := feedETag(formatAtom, req, &mongo.FeedState{LastModified: modified, IDs: []primitive.ObjectID{a, c}})
+	if swapped == etag {
+		t.Errorf("ETag did not change when an article of the feed was swapped for another")
+	}
+
+	if again := feedETag(formatAtom, req, &mongo.FeedState{LastModified: modified, IDs: []primitive.ObjectID{a, b}}); again != etag {
+		t.Errorf("ETag changed for the same feed: %s, then %s", etag, again)
+	}
+}
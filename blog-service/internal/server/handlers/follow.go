@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"blog-service/internal/db/mongo"
+	"blog-service/internal/db/postgres"
+	"blog-service/internal/notify"
+	"blog-service/internal/server/models"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+// FollowHandler serves following authors and categories, profiles and the personalised feed.
+type FollowHandler struct {
+	MongoDB    *mongo.Client
+	PostgresDB *postgres.Client
+	Notifier   *notify.Dispatcher
+}
+
+var (
+	FollowAuthorRe   = regexp.MustCompile(`^/follows/authors/(\d+)/?$`)
+	FollowCategoryRe = regexp.MustCompile(`^/follows/categories/([^/]+)/?$`)
+	FollowingRe      = regexp.MustCompile(`^/me/following/?$`)
+	ProfileRe        = regexp.MustCompile(`^/authors/(\d+)/?$`)
+	FollowingFeedRe  = regexp.MustCompile(`^/feed/?$`)
+)
+
+func (h *FollowHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	follow := r.Method == http.MethodPut || r.Method == http.MethodPost
+
+	switch {
+	case FollowAuthorRe.MatchString(r.URL.Path) && (follow || r.Method == http.MethodDelete):
+		authorID, err := strconv.Atoi(FollowAuthorRe.FindStringSubmatch(r.URL.Path)[1])
+		if err != nil {
+			http.Error(w, "Invalid author id", http.StatusBadRequest)
+			return
+		}
+		writeFollowResult(w, r, models.FollowAuthor(r.Context(), h.PostgresDB, h.Notifier, authorID, follow))
+
+	case FollowCategoryRe.MatchString(r.URL.Path) && (follow || r.Method == http.MethodDelete):
+		// PathValue is already unescaped, the regex only checked the shape
+		category := r.PathValue("name")
+		writeFollowResult(w, r, models.FollowCategory(r.Context(), h.PostgresDB, category, follow))
+
+	case r.Method == http.MethodGet && FollowingRe.MatchString(r.URL.Path):
+		following, err := models.GetFollowing(r.Context(), h.PostgresDB)
+		h.write(w, r, following, err, "following")
+
+	case r.Method == http.MethodGet && ProfileRe.MatchString(r.URL.Path):
+		userID, err := strconv.Atoi(ProfileRe.FindStringSubmatch(r.URL.Path)[1])
+		if err != nil {
+			http.Error(w, "Invalid author id", http.StatusBadRequest)
+			return
+		}
+		profile, err := models.GetProfile(r.Context(), h.PostgresDB, userID)
+		h.write(w, r, profile, err, "profile")
+
+	case r.Method == http.MethodGet && FollowingFeedRe.MatchString(r.URL.Path):
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		page, err := models.GetFollowingFeed(r.Context(), h.PostgresDB, h.MongoDB, r.URL.Query().Get("cursor"), limit)
+		h.write(w, r, page, err, "feed")
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *FollowHandler) write(w http.ResponseWriter, r *http.Request, v any, err error, what string) {
+	if err != nil {
+		writeFollowError(w, r, err, what)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, v)
+}
+
+func writeFollowResult(w http.ResponseWriter, r *http.Request, err error) {
+	if err != nil {
+		writeFollowError(w, r, err, "follow")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeFollowError(w http.ResponseWriter, r *http.Request, err error, what string) {
+	var paramErr *models.ParamError
+	var unauthorizedErr *models.UnauthorizedError
+
+	switch {
+	case errors.As(err, &paramErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &unauthorizedErr):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	default:
+		slog.ErrorContext(r.Context(), "Error handling "+what+" request", "error", err)
+		http.Error(w, "Failed to process "+what+" request", http.StatusInternalServerError)
+	}
+}
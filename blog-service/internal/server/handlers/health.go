@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"blog-service/internal/health"
+	"net/http"
+)
+
+/*
+HealthHandler reports the state of every dependency of the service.
+/healthz always answers 200 while the process serves, a restart would not fix a database outage.
+/readyz answers 503 as long as a dependency is down, so no traffic is routed here meanwhile.
+*/
+type HealthHandler struct {
+	Checker *health.Checker
+}
+
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report := h.Checker.Run(r.Context())
+
+	// Probes must see the current state, not a cached one
+	w.Header().Set("Cache-Control", "no-store")
+
+	status := http.StatusOK
+	if r.URL.Path == "/readyz" && report.Status == health.StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, status, report)
+}
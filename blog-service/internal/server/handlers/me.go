@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"blog-service/internal/db/mongo"
+	"blog-service/internal/server/models"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+)
+
+// MeHandler serves resources that belong to the authenticated user.
+type MeHandler struct {
+	MongoDB *mongo.Client
+}
+
+var (
+	MeDraftsRe = regexp.MustCompile(`/me/drafts/?$`)
+)
+
+func (h *MeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && MeDraftsRe.MatchString(r.URL.Path) {
+		h.getDrafts(w, r)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// getDrafts lists the draft and scheduled articles of the caller.
+func (h *MeHandler) getDrafts(w http.ResponseWriter, r *http.Request) {
+	var unauthorizedErr *models.UnauthorizedError
+
+	articles, err := models.GetDrafts(r.Context(), h.MongoDB)
+	switch {
+	case errors.As(err, &unauthorizedErr):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Error retrieving drafts", "error", err)
+		http.Error(w, "Failed to retrieve drafts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(articles); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", "error", err)
+	}
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMeHandlerDrafts(t *testing.T) {
+	h := &MeHandler{}
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/me/drafts", http.StatusUnauthorized},
+		{http.MethodGet, "/me/drafts/", http.StatusUnauthorized},
+		{http.MethodPost, "/me/drafts", http.StatusNotFound},
+		{http.MethodGet, "/me/articles", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+		if rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+}
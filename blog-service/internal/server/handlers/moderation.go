@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"blog-service/internal/db/mongo"
+	"blog-service/internal/db/postgres"
+	pgmodels "blog-service/internal/db/postgres/models"
+	"blog-service/internal/live"
+	"blog-service/internal/server/models"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+// ModerationHandler serves content reports and the moderation tools.
+type ModerationHandler struct {
+	MongoDB    *mongo.Client
+	PostgresDB *postgres.Client
+	Bus        *live.Bus
+	// AutoHideThreshold is how many users have to report an item before it is hidden automatically
+	AutoHideThreshold int
+}
+
+var (
+	ReportsRe           = regexp.MustCompile(`^/reports/?$`)
+	ReportReasonsRe     = regexp.MustCompile(`^/reports/reasons/?$`)
+	ModerationQueueRe   = regexp.MustCompile(`^/moderation/queue/?$`)
+	ModerationAuditRe   = regexp.MustCompile(`^/moderation/actions/?$`)
+	ModerationActionsRe = regexp.MustCompile(`^/moderation/(article|comment)/([a-zA-Z0-9]+)/actions/?$`)
+)
+
+func (h *ModerationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && ReportsRe.MatchString(r.URL.Path):
+		h.ReportCreate(w, r)
+	case r.Method == http.MethodGet && ReportReasonsRe.MatchString(r.URL.Path):
+		writeJSON(w, http.StatusOK, models.ReportReasons)
+	case r.Method == http.MethodGet && ModerationQueueRe.MatchString(r.URL.Path):
+		h.ModerationQueue(w, r)
+	case r.Method == http.MethodGet && ModerationAuditRe.MatchString(r.URL.Path):
+		h.ModerationActionList(w, r, "", "")
+	case ModerationActionsRe.MatchString(r.URL.Path):
+		match := ModerationActionsRe.FindStringSubmatch(r.URL.Path)
+		switch r.Method {
+		case http.MethodGet:
+			h.ModerationActionList(w, r, match[1], match[2])
+		case http.MethodPost:
+			h.ModerationActionCreate(w, r, match[1], match[2])
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *ModerationHandler) ReportCreate(w http.ResponseWriter, r *http.Request) {
+	var report models.ReportCreateDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		http.Error(w, (&models.ParamError{}).Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := models.ReportContent(r.Context(), h.PostgresDB, h.MongoDB, h.Bus, &report, h.AutoHideThreshold)
+	if err != nil {
+		writeModerationError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ModerationHandler) ModerationQueue(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+
+	filter := pgmodels.ModerationFilter{
+		Status:     query.Get("status"),
+		TargetType: query.Get("type"),
+		Reason:     query.Get("reason"),
+	}
+
+	items, err := models.GetModerationQueue(r.Context(), h.PostgresDB, filter, page)
+	if err != nil {
+		writeModerationError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (h *ModerationHandler) ModerationActionList(w http.ResponseWriter, r *http.Request, targetType, targetID string) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+	actions, err := models.GetModerationActions(r.Context(), h.PostgresDB, targetType, targetID, page)
+	if err != nil {
+		writeModerationError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, actions)
+}
+
+func (h *ModerationHandler) ModerationActionCreate(w http.ResponseWriter, r *http.Request, targetType, targetID string) {
+	var action models.ModerationActionDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&action); err != nil {
+		http.Error(w, (&models.ParamError{}).Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := models.ModerateContent(r.Context(), h.PostgresDB, h.MongoDB, h.Bus, targetType, targetID, &action)
+	if err != nil {
+		writeModerationError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeModerationError(w http.ResponseWriter, r *http.Request, err error) {
+	var paramErr *models.ParamError
+	var invalidArticleErr *models.InvalidArticleError
+	var unauthorizedErr *models.UnauthorizedError
+	var forbiddenErr *models.ForbiddenError
+
+	switch {
+	case errors.As(err, &paramErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &invalidArticleErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.As(err, &unauthorizedErr):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.As(err, &forbiddenErr):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		slog.ErrorContext(r.Context(), "Error handling moderation request", "error", err)
+		http.Error(w, "Failed to process moderation request", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"blog-service/internal/db/postgres"
+	"blog-service/internal/notify"
+	"blog-service/internal/server/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// NotificationHandler serves the notifications of the authenticated user.
+type NotificationHandler struct {
+	PostgresDB *postgres.Client
+	Notifier   *notify.Dispatcher
+}
+
+var (
+	NotificationsRe      = regexp.MustCompile(`^/notifications/?$`)
+	NotificationsReadRe  = regexp.MustCompile(`^/notifications/read/?$`)
+	NotificationStreamRe = regexp.MustCompile(`^/notifications/stream/?$`)
+)
+
+func (h *NotificationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && NotificationsRe.MatchString(r.URL.Path):
+		h.NotificationList(w, r)
+	case r.Method == http.MethodPost && NotificationsReadRe.MatchString(r.URL.Path):
+		h.NotificationMarkRead(w, r)
+	case r.Method == http.MethodGet && NotificationStreamRe.MatchString(r.URL.Path):
+		h.NotificationStream(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *NotificationHandler) NotificationList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	beforeID := 0
+	if before := query.Get("before"); before != "" {
+		var err error
+		if beforeID, err = strconv.Atoi(before); err != nil {
+			http.Error(w, "Invalid 'before' query parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	notifications, err := models.GetNotifications(r.Context(), h.PostgresDB, beforeID, limit, query.Get("unread") == "true")
+	if err != nil {
+		writeNotificationError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, notifications)
+}
+
+func (h *NotificationHandler) NotificationMarkRead(w http.ResponseWriter, r *http.Request) {
+	var dto models.NotificationsReadDTO
+
+	// An empty body marks everything as read
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			http.Error(w, (&models.ParamError{}).Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := models.MarkNotificationsRead(r.Context(), h.PostgresDB, &dto); err != nil {
+		writeNotificationError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/*
+NotificationStream pushes new notifications to the client as server-sent events
+for as long as the connection stays open.
+*/
+func (h *NotificationHandler) NotificationStream(w http.ResponseWriter, r *http.Request) {
+	userClaims := models.GetClaimsFromContext(r.Context())
+	if userClaims == nil {
+		http.Error(w, (&models.UnauthorizedError{}).Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if h.Notifier == nil {
+		http.Error(w, "Notification streaming is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	updates, unsubscribe := h.Notifier.Subscribe(userClaims.ID)
+	defer unsubscribe()
+
+	rc, err := startEventStream(w)
+	if err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-streamShutdown(r.Context()):
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case notification, ok := <-updates:
+			if !ok {
+				// Fell behind or events were lost, the client reconnects and reloads
+				return
+			}
+			data, err := json.Marshal(notification)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Error encoding notification", "error", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: notification\ndata: %s\n\n", notification.ID, data); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeNotificationError(w http.ResponseWriter, r *http.Request, err error) {
+	var paramErr *models.ParamError
+	var unauthorizedErr *models.UnauthorizedError
+
+	switch {
+	case errors.As(err, &paramErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &unauthorizedErr):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	default:
+		slog.ErrorContext(r.Context(), "Error handling notifications", "error", err)
+		http.Error(w, "Failed to process notifications", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"blog-service/internal/ratelimit"
+	"blog-service/internal/server/models"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitRule limits the requests a client sends to a route with one of Methods, no methods means all.
+type RateLimitRule struct {
+	Name    string
+	Methods []string
+	Limit   ratelimit.Limit
+}
+
+/*
+RateLimiter throttles clients with token buckets,
+keyed by user when the request is authenticated and by client address otherwise.
+*/
+type RateLimiter struct {
+	Store ratelimit.Store
+	// TrustProxy takes the client address from X-Forwarded-For, only safe behind a proxy that sets it
+	TrustProxy bool
+}
+
+/*
+Middleware applies the first rule matching the request method.
+It has to run inside AuthMiddleware to see the user.
+*/
+func (l *RateLimiter) Middleware(h http.Handler, rules ...RateLimitRule) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rule, ok := matchRateLimitRule(rules, r.Method)
+		if !ok || l == nil || l.Store == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		decision, err := l.Store.Take(r.Context(), rule.Name+":"+l.clientKey(r), rule.Limit)
+		if err != nil {
+			// Losing the limiter must not take the API down with it
+			slog.ErrorContext(r.Context(), "Rate limiter failed, letting request through", "error", err)
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", rule.Limit.Burst, seconds(rule.Limit.Period)))
+		header.Set("RateLimit-Limit", strconv.Itoa(rule.Limit.Burst))
+		header.Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(seconds(decision.Reset)))
+
+		if !decision.Allowed {
+			header.Set("Retry-After", strconv.Itoa(max(1, seconds(decision.RetryAfter))))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+func matchRateLimitRule(rules []RateLimitRule, method string) (RateLimitRule, bool) {
+	for _, rule := range rules {
+		if len(rule.Methods) == 0 || slices.Contains(rule.Methods, method) {
+			return rule, true
+		}
+	}
+
+	return RateLimitRule{}, false
+}
+
+func (l *RateLimiter) clientKey(r *http.Request) string {
+	if userClaims := models.GetClaimsFromContext(r.Context()); userClaims != nil {
+		return "user:" + strconv.Itoa(userClaims.ID)
+	}
+
+	return "ip:" + l.clientIP(r)
+}
+
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	if l.TrustProxy {
+		// The proxy appends the address it saw, earlier entries come from the client and can be forged
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			hops := strings.Split(forwarded, ",")
+			return strings.TrimSpace(hops[len(hops)-1])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// seconds rounds a duration up to whole seconds, as the rate limit headers expect.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
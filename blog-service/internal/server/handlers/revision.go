@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"blog-service/internal/db/mongo"
+	"blog-service/internal/server/models"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+// RevisionHandler serves the edit history of an article.
+type RevisionHandler struct {
+	MongoDB *mongo.Client
+}
+
+var (
+	RevisionListRe    = regexp.MustCompile(`/article/[a-f0-9]{24}/revisions/?$`)
+	RevisionDiffRe    = regexp.MustCompile(`/article/[a-f0-9]{24}/revisions/diff/?$`)
+	RevisionGetRe     = regexp.MustCompile(`/article/[a-f0-9]{24}/revisions/(\d+)/?$`)
+	RevisionRestoreRe = regexp.MustCompile(`/article/[a-f0-9]{24}/revisions/(\d+)/restore/?$`)
+)
+
+func (h *RevisionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && RevisionListRe.MatchString(r.URL.Path):
+		h.RevisionList(w, r)
+		return
+	case r.Method == http.MethodGet && RevisionDiffRe.MatchString(r.URL.Path):
+		h.RevisionDiff(w, r)
+		return
+	case r.Method == http.MethodGet && RevisionGetRe.MatchString(r.URL.Path):
+		h.RevisionGet(w, r, revisionNumber(RevisionGetRe, r.URL.Path))
+		return
+	case r.Method == http.MethodPost && RevisionRestoreRe.MatchString(r.URL.Path):
+		h.RevisionRestore(w, r, revisionNumber(RevisionRestoreRe, r.URL.Path))
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// revisionNumber extracts the revision number captured by re from the path.
+func revisionNumber(re *regexp.Regexp, path string) int {
+	number, err := strconv.Atoi(re.FindStringSubmatch(path)[1])
+	if err != nil {
+		return 0
+	}
+
+	return number
+}
+
+// writeRevisionError maps the errors returned by the revision models to a status code.
+func writeRevisionError(w http.ResponseWriter, r *http.Request, err error) {
+	var paramErr *models.ParamError
+	var invalidArticleErr *models.InvalidArticleError
+	var invalidRevisionErr *models.InvalidRevisionError
+	var unauthorizedErr *models.UnauthorizedError
+	var forbiddenErr *models.ForbiddenError
+
+	switch {
+	case errors.As(err, &paramErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &invalidArticleErr), errors.As(err, &invalidRevisionErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.As(err, &unauthorizedErr):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.As(err, &forbiddenErr):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		slog.ErrorContext(r.Context(), "Error handling revision request", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Error encoding response", "error", err)
+	}
+}
+
+func (h *RevisionHandler) RevisionList(w http.ResponseWriter, r *http.Request) {
+	revisions, err := models.GetRevisions(r.Context(), h.MongoDB, r.PathValue("id"))
+	if err != nil {
+		writeRevisionError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, revisions)
+}
+
+func (h *RevisionHandler) RevisionGet(w http.ResponseWriter, r *http.Request, number int) {
+	revision, err := models.GetRevision(r.Context(), h.MongoDB, r.PathValue("id"), number)
+	if err != nil {
+		writeRevisionError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, revision)
+}
+
+// RevisionDiff compares the revisions given by the 'from' and 'to' query parameters.
+func (h *RevisionHandler) RevisionDiff(w http.ResponseWriter, r *http.Request) {
+	from, fromErr := strconv.Atoi(r.URL.Query().Get("from"))
+	to, toErr := strconv.Atoi(r.URL.Query().Get("to"))
+
+	if fromErr != nil || toErr != nil {
+		http.Error(w, "Invalid 'from' or 'to' query parameter, must be a revision number", http.StatusBadRequest)
+		return
+	}
+
+	revisionDiff, err := models.DiffRevisions(r.Context(), h.MongoDB, r.PathValue("id"), from, to)
+	if err != nil {
+		writeRevisionError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, revisionDiff)
+}
+
+func (h *RevisionHandler) RevisionRestore(w http.ResponseWriter, r *http.Request, number int) {
+	restored, err := models.RestoreRevision(r.Context(), h.MongoDB, r.PathValue("id"), number)
+	if err != nil {
+		writeRevisionError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.RevisionRestoreResponse{Number: restored})
+}
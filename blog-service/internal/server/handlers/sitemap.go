@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"blog-service/internal/db/mongo"
+	"blog-service/internal/server/models"
+	"blog-service/internal/sitemap"
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// How long a generated sitemap is served before it is generated again.
+const sitemapTTL = 5 * time.Minute
+
+// SitemapHandler serves /sitemap.xml, switching to a sitemap index once there are too many articles.
+type SitemapHandler struct {
+	MongoDB *mongo.Client
+	// SiteURL is the public address of the frontend the sitemap links to
+	SiteURL string
+	// PublicURL is the public address of blog-service, where the pages of a split sitemap are served
+	PublicURL string
+
+	mu    sync.Mutex
+	cache map[string]cachedSitemap
+	// pages is the page count, cached like the sitemaps so unknown pages are rejected cheaply
+	pages cachedPages
+}
+
+type cachedSitemap struct {
+	expires time.Time
+	body    []byte
+}
+
+type cachedPages struct {
+	expires time.Time
+	count   int
+}
+
+var (
+	SitemapRe     = regexp.MustCompile(`^/sitemap\.xml$`)
+	SitemapPageRe = regexp.MustCompile(`^/sitemaps/(\d+)\.xml$`)
+)
+
+func (h *SitemapHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch {
+	case SitemapRe.MatchString(r.URL.Path):
+		h.serve(w, r, r.URL.Path, h.writeRoot)
+	case SitemapPageRe.MatchString(r.URL.Path):
+		page, err := strconv.Atoi(SitemapPageRe.FindStringSubmatch(r.URL.Path)[1])
+		if err != nil || page < 1 {
+			http.NotFound(w, r)
+			return
+		}
+
+		// Only existing pages are generated, so the cache holds at most one entry per page
+		pages, err := h.pageCount(r)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error counting sitemap pages", "error", err)
+			http.Error(w, "Failed to generate sitemap", http.StatusInternalServerError)
+			return
+		}
+		if page > pages {
+			http.NotFound(w, r)
+			return
+		}
+		h.serve(w, r, r.URL.Path, func(w io.Writer, r *http.Request) error {
+			return models.WriteSitemap(r.Context(), h.MongoDB, w, h.SiteURL, page)
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// writeRoot writes the whole sitemap when it fits in one document and an index otherwise.
+func (h *SitemapHandler) writeRoot(w io.Writer, r *http.Request) error {
+	pages, err := h.pageCount(r)
+	if err != nil {
+		return err
+	}
+
+	if pages == 1 {
+		return models.WriteSitemap(r.Context(), h.MongoDB, w, h.SiteURL, 1)
+	}
+
+	return models.WriteSitemapIndex(w, h.PublicURL, pages)
+}
+
+// pageCount returns how many pages the sitemap has, counted again once the cached count expired.
+func (h *SitemapHandler) pageCount(r *http.Request) (int, error) {
+	h.mu.Lock()
+	cached := h.pages
+	h.mu.Unlock()
+
+	if time.Now().Before(cached.expires) {
+		return cached.count, nil
+	}
+
+	count, err := models.SitemapPages(r.Context(), h.MongoDB)
+	if err != nil {
+		return 0, err
+	}
+
+	h.mu.Lock()
+	h.pages = cachedPages{expires: time.Now().Add(sitemapTTL), count: count}
+	h.mu.Unlock()
+
+	return count, nil
+}
+
+/*
+serve answers from the cache while it is fresh. Otherwise the sitemap is streamed
+to the client and copied into the cache at the same time.
+*/
+func (h *SitemapHandler) serve(w http.ResponseWriter, r *http.Request, key string, generate func(io.Writer, *http.Request) error) {
+	w.Header().Set("Content-Type", sitemap.ContentType)
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(sitemapTTL.Seconds())))
+
+	if body, ok := h.cached(key); ok {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		if r.Method == http.MethodGet {
+			if _, err := w.Write(body); err != nil {
+				slog.ErrorContext(r.Context(), "Error writing sitemap", "error", err)
+			}
+		}
+		return
+	}
+
+	var buf bytes.Buffer
+	out := io.Writer(&buf)
+	if r.Method == http.MethodGet {
+		out = io.MultiWriter(w, &buf)
+	}
+
+	// Once streaming started the status can no longer change, a failure only skips caching
+	if err := generate(out, r); err != nil {
+		slog.ErrorContext(r.Context(), "Error generating sitemap", "sitemap", key, "error", err)
+		if buf.Len() == 0 {
+			http.Error(w, "Failed to generate sitemap", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.store(key, buf.Bytes())
+}
+
+func (h *SitemapHandler) cached(key string) ([]byte, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, ok := h.cache[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+
+	return entry.body, true
+}
+
+func (h *SitemapHandler) store(key string, body []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cache == nil {
+		h.cache = map[string]cachedSitemap{}
+	}
+
+	// Drop expired pages so pages that no longer exist do not stay around
+	now := time.Now()
+	for k, entry := range h.cache {
+		if now.After(entry.expires) {
+			delete(h.cache, k)
+		}
+	}
+
+	h.cache[key] = cachedSitemap{expires: now.Add(sitemapTTL), body: body}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSitemapHandlerRejectsUnknownPages(t *testing.T) {
+	h := &SitemapHandler{SiteURL: "https://blog.example.com", PublicURL: "https://api.example.com"}
+	h.pages = cachedPages{expires: time.Now().Add(time.Minute), count: 2}
+	h.store("/sitemaps/2.xml", []byte("<urlset/>"))
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/sitemaps/2.xml", http.StatusOK},
+		{http.MethodGet, "/sitemaps/3.xml", http.StatusNotFound},
+		{http.MethodGet, "/sitemaps/0.xml", http.StatusNotFound},
+		{http.MethodGet, "/sitemaps/99999999999999999999.xml", http.StatusNotFound},
+		{http.MethodGet, "/sitemaps/page.xml", http.StatusNotFound},
+		{http.MethodPost, "/sitemap.xml", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+		if rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+
+	if len(h.cache) != 1 {
+		t.Errorf("Rejected pages were cached: %v", h.cache)
+	}
+}
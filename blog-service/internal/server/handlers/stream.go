@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Interval of the comments sent on idle streams so proxies keep them open.
+const streamHeartbeat = 25 * time.Second
+
+// How long clients wait before reconnecting to a dropped stream.
+const streamRetry = 3 * time.Second
+
+/*
+startEventStream sends the headers of a server-sent event stream.
+The server write timeout is meant for regular responses, a stream lives until
+the client leaves, so the deadline is lifted for this response only.
+*/
+func startEventStream(w http.ResponseWriter) (*http.ResponseController, error) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		slog.Error("Failed to clear write deadline of event stream", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	return rc, rc.Flush()
+}
+
+type streamShutdownKey struct{}
+
+/*
+WithStreamShutdown attaches a channel closed when the server shuts down.
+Event streams never go idle, so they end themselves on it instead of holding up the shutdown;
+clients reconnect to another instance.
+*/
+func WithStreamShutdown(ctx context.Context, shutdown <-chan struct{}) context.Context {
+	return context.WithValue(ctx, streamShutdownKey{}, shutdown)
+}
+
+// streamShutdown returns the channel set by WithStreamShutdown, nil never fires.
+func streamShutdown(ctx context.Context) <-chan struct{} {
+	shutdown, _ := ctx.Value(streamShutdownKey{}).(<-chan struct{})
+	return shutdown
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"blog-service/internal/blob"
+	"blog-service/internal/db/mongo"
+	mongomodels "blog-service/internal/db/mongo/models"
+	"blog-service/internal/media"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AssetURLPrefix is the path under which stored blobs are served.
+const AssetURLPrefix = "/assets/"
+
+type AssetDTO struct {
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl,omitempty"`
+	ContentType  string `json:"contentType"`
+	Filename     string `json:"filename"`
+	Size         int64  `json:"size"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+}
+
+func assetURL(key string) string {
+	if key == "" {
+		return ""
+	}
+
+	return AssetURLPrefix + key
+}
+
+/*
+UploadAsset validates an uploaded file, strips its metadata and stores it
+together with a thumbnail for images.
+@params
+filename - the name of the file on the client, kept for display only.
+data - the uploaded file.
+@returns
+*AssetDTO - the stored asset and the urls it is served from.
+error - UnsupportedMediaError when the file type is not allowed.
+*/
+func UploadAsset(ctx context.Context, db *mongo.Client, store blob.BlobStore, filename string, data []byte) (*AssetDTO, error) {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return nil, &UnauthorizedError{}
+	}
+
+	processed, err := media.Process(data)
+	if errors.Is(err, media.ErrUnsupportedType) {
+		return nil, &UnsupportedMediaError{}
+	}
+	if err != nil {
+		return nil, &ParamError{}
+	}
+
+	oid := primitive.NewObjectID()
+	asset := mongomodels.AssetDB{
+		CreatedAt:   time.Now(),
+		Filename:    filename,
+		ContentType: processed.ContentType,
+		Key:         oid.Hex() + processed.Ext,
+		ID:          oid,
+		Size:        int64(len(processed.Data)),
+		OwnerID:     userClaims.ID,
+		Width:       processed.Width,
+		Height:      processed.Height,
+	}
+
+	err = store.Put(ctx, asset.Key, bytes.NewReader(processed.Data), asset.Size, asset.ContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	if processed.IsImage() {
+		asset.ThumbnailKey = oid.Hex() + "_thumb.jpg"
+
+		err = store.Put(ctx, asset.ThumbnailKey, bytes.NewReader(processed.Thumbnail),
+			int64(len(processed.Thumbnail)), processed.ThumbnailContentType)
+		if err != nil {
+			removeBlobs(ctx, store, asset.Key)
+			return nil, err
+		}
+	}
+
+	if _, err := db.InsertAsset(ctx, &asset); err != nil {
+		removeBlobs(ctx, store, asset.Key, asset.ThumbnailKey)
+		return nil, err
+	}
+
+	return &AssetDTO{
+		ID:           oid.Hex(),
+		URL:          assetURL(asset.Key),
+		ThumbnailURL: assetURL(asset.ThumbnailKey),
+		ContentType:  asset.ContentType,
+		Filename:     asset.Filename,
+		Size:         asset.Size,
+		Width:        asset.Width,
+		Height:       asset.Height,
+	}, nil
+}
+
+// removeBlobs cleans up blobs of an upload that could not be completed.
+func removeBlobs(ctx context.Context, store blob.BlobStore, keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := store.Delete(ctx, key); err != nil {
+			slog.ErrorContext(ctx, "Failed to remove blob", "key", key, "error", err)
+		}
+	}
+}
+
+/*
+resolveAssets checks that every referenced asset exists and belongs to the user
+and builds the cover image of the article.
+@params
+assetIDs - ids of the assets the article references.
+coverImageID - id of the cover image, empty for none. Must be an image.
+@returns
+*mongomodels.CoverImage - the cover to store on the article, nil for none.
+[]string - the normalised asset ids, including the cover.
+error - ParamError when an asset is missing, foreign, or the cover is not an image.
+*/
+func resolveAssets(ctx context.Context, db *mongo.Client, userClaims *UserClaims,
+	assetIDs []string, coverImageID string) (*mongomodels.CoverImage, []string, error) {
+	ids := make([]string, 0, len(assetIDs)+1)
+	seen := map[string]bool{}
+
+	for _, id := range append(assetIDs, coverImageID) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, nil, nil
+	}
+
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, nil, &ParamError{}
+		}
+		oids = append(oids, oid)
+	}
+
+	assets, err := db.FindAssetsByIDs(ctx, oids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load assets: %w", err)
+	}
+
+	if len(assets) != len(oids) {
+		return nil, nil, &ParamError{}
+	}
+
+	var cover *mongomodels.CoverImage
+
+	for _, asset := range assets {
+		if !userClaims.CanManage(asset.OwnerID) {
+			return nil, nil, &ParamError{}
+		}
+
+		if asset.ID.Hex() == coverImageID {
+			if asset.ThumbnailKey == "" {
+				return nil, nil, &ParamError{}
+			}
+			cover = &mongomodels.CoverImage{
+				AssetID:      coverImageID,
+				URL:          assetURL(asset.Key),
+				ThumbnailURL: assetURL(asset.ThumbnailKey),
+			}
+		}
+	}
+
+	return cover, ids, nil
+}
@@ -33,6 +33,25 @@ type UserClaims struct {
 
 const ClaimsKey ContextKey = "jwtClaims"
 
+const (
+	AdminRole     = "Admin"
+	ModeratorRole = "Moderator"
+)
+
+func (c *UserClaims) IsAdmin() bool {
+	return c.Role == AdminRole
+}
+
+// CanModerate reports whether the user may work the moderation queue.
+func (c *UserClaims) CanModerate() bool {
+	return c.IsAdmin() || c.Role == ModeratorRole
+}
+
+// CanManage reports whether the user may manage content owned by publisherID.
+func (c *UserClaims) CanManage(publisherID int) bool {
+	return c.ID == publisherID || c.IsAdmin()
+}
+
 func GetClaimsFromContext(ctx context.Context) *UserClaims {
 	claims, ok := ctx.Value(ClaimsKey).(*UserClaims)
 	if !ok {
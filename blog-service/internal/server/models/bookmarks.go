@@ -0,0 +1,149 @@
+package models
+
+import (
+	"blog-service/internal/db/mongo"
+	mongomodels "blog-service/internal/db/mongo/models"
+	"blog-service/internal/db/postgres"
+	"context"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DefaultBookmarkLimit = 20
+	MaxBookmarkLimit     = 100
+)
+
+/*
+BookmarkDTO is an entry of the reading list.
+Article is left out and Available is false while the article is unpublished,
+the bookmark is kept in case it is published again.
+*/
+type BookmarkDTO struct {
+	CreatedAt time.Time              `json:"createdAt"`
+	Article   *mongomodels.ArticleDB `json:"article,omitempty"`
+	ArticleID string                 `json:"articleId"`
+	Available bool                   `json:"available"`
+}
+
+/*
+BookmarksDTO is a page of the reading list.
+NextBefore is passed back as ?before= to get older bookmarks, it is 0 on the last page.
+*/
+type BookmarksDTO struct {
+	Bookmarks  []BookmarkDTO `json:"bookmarks"`
+	NextBefore int           `json:"nextBefore,omitempty"`
+}
+
+// AddBookmark saves a readable article to the reading list of the caller.
+func AddBookmark(ctx context.Context, pgdb *postgres.Client, mdb *mongo.Client, articleID string) error {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return &UnauthorizedError{}
+	}
+
+	if err := CheckArticleVisible(ctx, mdb, articleID); err != nil {
+		return err
+	}
+
+	_, err := pgdb.AddBookmark(ctx, userClaims.ID, articleID)
+
+	return err
+}
+
+// RemoveBookmark removes an article from the reading list of the caller, whether the article still exists or not.
+func RemoveBookmark(ctx context.Context, db *postgres.Client, articleID string) error {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return &UnauthorizedError{}
+	}
+
+	_, err := db.RemoveBookmarks(ctx, userClaims.ID, []string{articleID})
+
+	return err
+}
+
+/*
+GetBookmarks lists the reading list of the caller, most recently saved first.
+Bookmarks of deleted articles are removed on the way, so they disappear from the list.
+*/
+func GetBookmarks(ctx context.Context, pgdb *postgres.Client, mdb *mongo.Client, beforeID, limit int) (*BookmarksDTO, error) {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return nil, &UnauthorizedError{}
+	}
+
+	if beforeID < 0 {
+		return nil, &ParamError{}
+	}
+
+	if limit <= 0 {
+		limit = DefaultBookmarkLimit
+	}
+	limit = min(limit, MaxBookmarkLimit)
+
+	bookmarks, err := pgdb.GetBookmarks(ctx, userClaims.ID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	oids := make([]primitive.ObjectID, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		if oid, err := primitive.ObjectIDFromHex(bookmark.ArticleID); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+
+	articles, err := mdb.FindArticleSummaries(ctx, oids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*mongomodels.ArticleDB, len(articles))
+	for i := range articles {
+		byID[articles[i].ID.Hex()] = &articles[i]
+	}
+
+	res := &BookmarksDTO{Bookmarks: make([]BookmarkDTO, 0, len(bookmarks))}
+	deleted := []string{}
+
+	for _, bookmark := range bookmarks {
+		article, ok := byID[bookmark.ArticleID]
+		if !ok {
+			deleted = append(deleted, bookmark.ArticleID)
+			continue
+		}
+
+		entry := BookmarkDTO{CreatedAt: bookmark.CreatedAt, ArticleID: bookmark.ArticleID}
+		if canView(ctx, article) && article.IsPublished() {
+			entry.Article = article
+			entry.Available = true
+		}
+		res.Bookmarks = append(res.Bookmarks, entry)
+	}
+
+	if len(bookmarks) == limit {
+		res.NextBefore = bookmarks[len(bookmarks)-1].ID
+	}
+
+	if len(deleted) > 0 {
+		// The list is already answered correctly, a failed cleanup is retried on the next read
+		if _, err := pgdb.RemoveBookmarks(ctx, userClaims.ID, deleted); err != nil {
+			slog.ErrorContext(ctx, "Failed to remove bookmarks of deleted articles", "error", err)
+		}
+	}
+
+	return res, nil
+}
+
+// IsBookmarked reports whether the caller bookmarked the article, anonymous callers never did.
+func IsBookmarked(ctx context.Context, db *postgres.Client, articleID string) (bool, error) {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return false, nil
+	}
+
+	return db.IsBookmarked(ctx, userClaims.ID, articleID)
+}
@@ -0,0 +1,219 @@
+package models
+
+import (
+	"blog-service/internal/contentfilter"
+	"blog-service/internal/db/mongo"
+	"blog-service/internal/db/postgres"
+	pgmodels "blog-service/internal/db/postgres/models"
+	"blog-service/internal/live"
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CommentUpdateDTO struct {
+	Content string `json:"content"`
+}
+
+// CommentUpdateResponse tells the author whether the edited comment waits for review.
+type CommentUpdateResponse struct {
+	Held bool `json:"held"`
+}
+
+// CommentLikesDTO is the payload of like count changes pushed to comment streams.
+type CommentLikesDTO struct {
+	CommentID int `json:"commentId"`
+	Likes     int `json:"likes"`
+}
+
+// CommentDeletedDTO is the payload of deletions pushed to comment streams.
+type CommentDeletedDTO struct {
+	ID int `json:"id"`
+}
+
+/*
+publishCommentEvent pushes a change to readers streaming the article.
+The change is already stored, so a failed push is only logged.
+*/
+func publishCommentEvent(ctx context.Context, bus *live.Bus, eventType string, comment CommentsGetDTO) {
+	var data any = comment
+	switch eventType {
+	case live.CommentDeleted:
+		data = CommentDeletedDTO{ID: comment.ID}
+	case live.CommentLikes:
+		data = CommentLikesDTO{CommentID: comment.ID, Likes: comment.Likes}
+	}
+
+	if err := bus.Publish(ctx, comment.ArticleID, eventType, data); err != nil {
+		slog.ErrorContext(ctx, "Failed to publish comment event", "event", eventType, "comment", comment.ID, "error", err)
+	}
+}
+
+// findComment returns the comment with the given id or a ParamError when there is none.
+func findComment(ctx context.Context, db *postgres.Client, commentID int) (*pgmodels.Comment, error) {
+	comment, err := db.GetComment(ctx, commentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, &ParamError{}
+	}
+
+	return comment, err
+}
+
+// checkCommentOpen returns a ParamError for hidden comments, only moderators still act on them.
+func checkCommentOpen(userClaims *UserClaims, comment *pgmodels.Comment) error {
+	if comment.Status == pgmodels.CommentHidden && !userClaims.CanModerate() {
+		return &ParamError{}
+	}
+
+	return nil
+}
+
+/*
+UpdateComment changes the content of a comment, only its author may do so.
+The new content goes through the content filter like a new comment: rejected content
+is a RejectedContentError, held content takes the comment back into the moderation queue.
+Readers streaming the article only see the change when the comment stays visible.
+*/
+func UpdateComment(ctx context.Context, db *postgres.Client, bus *live.Bus, filter contentfilter.ContentFilter,
+	commentID int, update *CommentUpdateDTO) (*CommentUpdateResponse, error) {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return nil, &UnauthorizedError{}
+	}
+
+	if update.Content == "" {
+		return nil, &ParamError{}
+	}
+
+	comment, err := findComment(ctx, db, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkCommentOpen(userClaims, comment); err != nil {
+		return nil, err
+	}
+
+	if comment.UserID != userClaims.ID {
+		return nil, &ForbiddenError{}
+	}
+
+	// Unchanged content would only be flagged as a duplicate of itself
+	if update.Content == comment.Content {
+		return &CommentUpdateResponse{Held: comment.Held}, nil
+	}
+
+	verdict := contentfilter.Result{Verdict: contentfilter.Accept}
+	if filter != nil {
+		verdict, err = filter.Check(ctx, &contentfilter.Comment{
+			Content:   update.Content,
+			ArticleID: comment.ArticleID,
+			UserID:    userClaims.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if verdict.Verdict == contentfilter.Reject {
+		return nil, &RejectedContentError{Reason: verdict.Reason}
+	}
+
+	// Held comments stay held whatever the verdict, a moderator releases them
+	hold := verdict.Verdict == contentfilter.Hold && comment.Status == pgmodels.CommentVisible
+	if _, err := db.UpdateComment(ctx, commentID, update.Content, hold); err != nil {
+		return nil, err
+	}
+
+	if hold {
+		if err := holdComment(ctx, db, comment, verdict.Reason); err != nil {
+			return nil, err
+		}
+		publishCommentEvent(ctx, bus, live.CommentDeleted, CommentsGetDTO{Comment: *comment})
+
+		return &CommentUpdateResponse{Held: true}, nil
+	}
+
+	if comment.Status != pgmodels.CommentVisible {
+		return &CommentUpdateResponse{Held: comment.Held}, nil
+	}
+
+	likes, err := db.GetCommentLikeCount(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment.Content = update.Content
+	publishCommentEvent(ctx, bus, live.CommentUpdated, CommentsGetDTO{*comment, likes})
+
+	return &CommentUpdateResponse{}, nil
+}
+
+/*
+DeleteComment removes a comment.
+Its author, the publisher of the article and admins may delete it.
+*/
+func DeleteComment(ctx context.Context, pgdb *postgres.Client, mdb *mongo.Client, bus *live.Bus, commentID int) error {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return &UnauthorizedError{}
+	}
+
+	comment, err := findComment(ctx, pgdb, commentID)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserID != userClaims.ID && !canManageArticle(ctx, mdb, userClaims, comment.ArticleID) {
+		return &ForbiddenError{}
+	}
+
+	if _, err := pgdb.DeleteComment(ctx, commentID); err != nil {
+		return err
+	}
+
+	// Readers never saw it, or already got its removal when it was hidden
+	if comment.Status != pgmodels.CommentVisible {
+		return nil
+	}
+
+	publishCommentEvent(ctx, bus, live.CommentDeleted, CommentsGetDTO{Comment: *comment})
+
+	return nil
+}
+
+func canManageArticle(ctx context.Context, db *mongo.Client, userClaims *UserClaims, articleID string) bool {
+	if userClaims.IsAdmin() {
+		return true
+	}
+
+	articleOID, err := primitive.ObjectIDFromHex(articleID)
+	if err != nil {
+		return false
+	}
+
+	article, err := db.FindArticleByID(ctx, &articleOID)
+	if err != nil {
+		return false
+	}
+
+	return userClaims.CanManage(article.PublisherID)
+}
+
+// CheckArticleVisible returns an InvalidArticleError unless the caller may read the article.
+func CheckArticleVisible(ctx context.Context, db *mongo.Client, id string) error {
+	articleOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &InvalidArticleError{}
+	}
+
+	article, err := db.FindArticleByID(ctx, &articleOID)
+	if err != nil || !canView(ctx, article) {
+		return &InvalidArticleError{}
+	}
+
+	return nil
+}
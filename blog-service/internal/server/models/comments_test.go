@@ -0,0 +1,29 @@
+package models
+
+import (
+	pgmodels "blog-service/internal/db/postgres/models"
+	"testing"
+)
+
+func TestCheckCommentOpen(t *testing.T) {
+	reader := &UserClaims{ID: 1}
+	moderator := &UserClaims{ID: 2, Role: ModeratorRole}
+
+	tests := []struct {
+		claims *UserClaims
+		status string
+		open   bool
+	}{
+		{reader, pgmodels.CommentVisible, true},
+		{reader, pgmodels.CommentHeld, true},
+		{reader, pgmodels.CommentHidden, false},
+		{moderator, pgmodels.CommentHidden, true},
+	}
+
+	for _, tt := range tests {
+		err := checkCommentOpen(tt.claims, &pgmodels.Comment{Status: tt.status, UserID: 1})
+		if (err == nil) != tt.open {
+			t.Errorf("%s comment for role %q: err = %v, want open %t", tt.status, tt.claims.Role, err, tt.open)
+		}
+	}
+}
@@ -6,6 +6,14 @@ type EmailOrUserTakenError struct{}
 type InvalidLoginError struct{}
 type InvalidTokenError struct{}
 type UnauthorizedError struct{}
+type ForbiddenError struct{}
+type InvalidRevisionError struct{}
+type UnsupportedMediaError struct{}
+
+// RejectedContentError is returned when a content filter refuses a comment.
+type RejectedContentError struct {
+	Reason string
+}
 
 func (e *ParamError) Error() string {
 	return "some request parameters are invalid or missing"
@@ -30,3 +38,19 @@ func (e *InvalidTokenError) Error() string {
 func (e *UnauthorizedError) Error() string {
 	return "unauthorized"
 }
+
+func (e *ForbiddenError) Error() string {
+	return "forbidden"
+}
+
+func (e *InvalidRevisionError) Error() string {
+	return "the revision does not exist"
+}
+
+func (e *UnsupportedMediaError) Error() string {
+	return "unsupported file type"
+}
+
+func (e *RejectedContentError) Error() string {
+	return "comment rejected: " + e.Reason
+}
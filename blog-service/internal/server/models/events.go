@@ -0,0 +1,331 @@
+package models
+
+import (
+	"blog-service/internal/db/mongo"
+	mongomodels "blog-service/internal/db/mongo/models"
+	"blog-service/internal/db/postgres"
+	pgmodels "blog-service/internal/db/postgres/models"
+	"blog-service/internal/events"
+	"blog-service/internal/notify"
+	"blog-service/internal/outbox"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// importBatchSize is how many articles with pending events are imported before each batch of the relay.
+const importBatchSize = 100
+
+// errArticleStillExists postpones the cascade of an article deletion until Mongo shows it.
+var errArticleStillExists = errors.New("article still exists")
+
+/*
+RegisterEventHandlers subscribes the reactions to comments, likes, articles, moderator warnings and deleted users to relay:
+the engagement counters, the cascades that keep both stores consistent, the notifications
+and the domain events sent to other services through publisher, once the other handlers succeeded.
+Handlers may see an event twice, the cascades and the recounted engagement are idempotent, the
+published events keep the key of the outbox event as their ID, and a notification is only repeated
+when the relay stops right after running it. The article events written with the articles in Mongo are imported
+into the outbox before each batch.
+*/
+func RegisterEventHandlers(relay *outbox.Relay, pgdb *postgres.Client, mdb *mongo.Client,
+	notifier *notify.Dispatcher, publisher events.Publisher) {
+	relay.Import("article events", importArticleEvents(mdb, pgdb))
+
+	relay.Handle(pgmodels.EventCommentCreated, "engagement", onComment(func(ctx context.Context, e *pgmodels.CommentEvent) error {
+		// Only visible comments count, a held one is counted once a moderator restores it
+		if e.Held {
+			return nil
+		}
+		return recountEngagement(ctx, pgdb, mdb, e.ArticleID)
+	}))
+	relay.Handle(pgmodels.EventCommentCreated, "orphan-check", onComment(func(ctx context.Context, e *pgmodels.CommentEvent) error {
+		// The article was checked before the comment was stored, but may have been deleted in between
+		exists, err := mdb.ArticleExists(ctx, e.ArticleID)
+		if err != nil || exists {
+			return err
+		}
+		_, err = pgdb.DeleteComment(ctx, e.CommentID)
+		return err
+	}))
+	relay.Handle(pgmodels.EventCommentCreated, "notify-publisher", onComment(func(ctx context.Context, e *pgmodels.CommentEvent) error {
+		// Held comments wait for a moderator, the publisher learns about them once they are visible
+		if e.Held {
+			return nil
+		}
+		return notifier.Deliver(ctx, &pgmodels.Notification{
+			Type:        pgmodels.NotificationComment,
+			RecipientID: e.PublisherID,
+			ActorID:     e.AuthorID,
+			ActorName:   e.AuthorName,
+			ArticleID:   e.ArticleID,
+			CommentID:   e.CommentID,
+		})
+	}))
+	relay.Handle(pgmodels.EventCommentCreated, "broker", func(ctx context.Context, event *pgmodels.OutboxEvent) error {
+		return onComment(func(ctx context.Context, e *pgmodels.CommentEvent) error {
+			if e.Held {
+				return nil
+			}
+			return publish(ctx, publisher, event, events.CommentCreatedV1{
+				ArticleID:  e.ArticleID,
+				AuthorName: e.AuthorName,
+				CommentID:  e.CommentID,
+				AuthorID:   e.AuthorID,
+			})
+		})(ctx, event)
+	})
+
+	relay.Handle(pgmodels.EventCommentDeleted, "likes-cascade", onComment(func(ctx context.Context, e *pgmodels.CommentEvent) error {
+		return pgdb.DeleteCommentLikes(ctx, e.CommentID)
+	}))
+	relay.Handle(pgmodels.EventCommentDeleted, "engagement", onComment(func(ctx context.Context, e *pgmodels.CommentEvent) error {
+		// A hidden comment without likes no longer counted
+		if e.Hidden && e.Likes == 0 {
+			return nil
+		}
+		return recountEngagement(ctx, pgdb, mdb, e.ArticleID)
+	}))
+	relay.Handle(pgmodels.EventCommentDeleted, "broker", func(ctx context.Context, event *pgmodels.OutboxEvent) error {
+		return onComment(func(ctx context.Context, e *pgmodels.CommentEvent) error {
+			return publish(ctx, publisher, event, events.CommentDeletedV1{ArticleID: e.ArticleID, CommentID: e.CommentID})
+		})(ctx, event)
+	})
+
+	relay.Handle(pgmodels.EventCommentHidden, "engagement", onComment(func(ctx context.Context, e *pgmodels.CommentEvent) error {
+		return recountEngagement(ctx, pgdb, mdb, e.ArticleID)
+	}))
+	relay.Handle(pgmodels.EventCommentRestored, "engagement", onComment(func(ctx context.Context, e *pgmodels.CommentEvent) error {
+		return recountEngagement(ctx, pgdb, mdb, e.ArticleID)
+	}))
+	// A held comment was never announced, a comment hidden by a moderator already was
+	relay.Handle(pgmodels.EventCommentRestored, "notify-publisher", onComment(func(ctx context.Context, e *pgmodels.CommentEvent) error {
+		if !e.Held {
+			return nil
+		}
+		article, err := findEventArticle(ctx, mdb, e.ArticleID)
+		if err != nil || article == nil {
+			return err
+		}
+		return notifier.Deliver(ctx, &pgmodels.Notification{
+			Type:        pgmodels.NotificationComment,
+			RecipientID: article.PublisherID,
+			ActorID:     e.AuthorID,
+			ActorName:   e.AuthorName,
+			ArticleID:   e.ArticleID,
+			CommentID:   e.CommentID,
+		})
+	}))
+	relay.Handle(pgmodels.EventCommentRestored, "broker", func(ctx context.Context, event *pgmodels.OutboxEvent) error {
+		return onComment(func(ctx context.Context, e *pgmodels.CommentEvent) error {
+			if !e.Held {
+				return nil
+			}
+			return publish(ctx, publisher, event, events.CommentCreatedV1{
+				ArticleID:  e.ArticleID,
+				AuthorName: e.AuthorName,
+				CommentID:  e.CommentID,
+				AuthorID:   e.AuthorID,
+			})
+		})(ctx, event)
+	})
+
+	relay.Handle(pgmodels.EventLikeAdded, "engagement", onLike(func(ctx context.Context, e *pgmodels.LikeEvent) error {
+		return recountEngagement(ctx, pgdb, mdb, e.ArticleID)
+	}))
+	relay.Handle(pgmodels.EventLikeAdded, "notify-author", onLike(func(ctx context.Context, e *pgmodels.LikeEvent) error {
+		return notifier.Deliver(ctx, &pgmodels.Notification{
+			Type:        pgmodels.NotificationLike,
+			RecipientID: e.CommentAuthorID,
+			ActorID:     e.UserID,
+			ActorName:   e.UserName,
+			ArticleID:   e.ArticleID,
+			CommentID:   e.CommentID,
+		})
+	}))
+
+	relay.Handle(pgmodels.EventLikeRemoved, "engagement", onLike(func(ctx context.Context, e *pgmodels.LikeEvent) error {
+		return recountEngagement(ctx, pgdb, mdb, e.ArticleID)
+	}))
+
+	relay.Handle(pgmodels.EventArticlePublished, "broker", onArticle(func(ctx context.Context, event *pgmodels.OutboxEvent, e *pgmodels.ArticleEvent) error {
+		article, err := findEventArticle(ctx, mdb, e.ArticleID)
+		// Articles deleted or unpublished since are not announced
+		if err != nil || article == nil || !article.IsPublished() {
+			return err
+		}
+
+		publishedAt := article.PublishAt
+		if publishedAt.IsZero() {
+			publishedAt = event.CreatedAt
+		}
+
+		return publish(ctx, publisher, event, events.ArticlePublishedV1{
+			PublishedAt:   publishedAt,
+			ArticleID:     e.ArticleID,
+			Slug:          article.Slug,
+			Title:         article.Title,
+			Excerpt:       article.Excerpt,
+			Category:      article.Category,
+			PublisherName: article.PublisherName,
+			PublisherID:   article.PublisherID,
+		})
+	}))
+
+	relay.Handle(pgmodels.EventArticleUpdated, "broker", onArticle(func(ctx context.Context, event *pgmodels.OutboxEvent, e *pgmodels.ArticleEvent) error {
+		// The current state is sent, a later edit may already show in it
+		article, err := findEventArticle(ctx, mdb, e.ArticleID)
+		if err != nil || article == nil || !article.IsPublished() {
+			return err
+		}
+
+		return publish(ctx, publisher, event, events.ArticleUpdatedV1{
+			UpdatedAt: article.UpdatedAt,
+			ArticleID: e.ArticleID,
+			Slug:      article.Slug,
+			Title:     article.Title,
+			Excerpt:   article.Excerpt,
+			Category:  article.Category,
+			Revision:  e.Revision,
+		})
+	}))
+
+	relay.Handle(pgmodels.EventArticleDeleted, "content-cascade", onArticle(func(ctx context.Context, _ *pgmodels.OutboxEvent, e *pgmodels.ArticleEvent) error {
+		// The event is recorded before the article is deleted, wait until it is gone
+		exists, err := mdb.ArticleExists(ctx, e.ArticleID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errArticleStillExists
+		}
+
+		return pgdb.DeleteArticleContent(ctx, e.ArticleID)
+	}))
+	relay.Handle(pgmodels.EventArticleDeleted, "broker", onArticle(func(ctx context.Context, event *pgmodels.OutboxEvent, e *pgmodels.ArticleEvent) error {
+		return publish(ctx, publisher, event, events.ArticleDeletedV1{ArticleID: e.ArticleID})
+	}))
+
+	relay.Handle(pgmodels.EventModerationWarning, "notify-author", func(ctx context.Context, event *pgmodels.OutboxEvent) error {
+		var warning pgmodels.Notification
+		if err := json.Unmarshal(event.Payload, &warning); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", event.Type, err)
+		}
+
+		return notifier.Deliver(ctx, &warning)
+	})
+
+	relay.Handle(pgmodels.EventUserDeleted, "broker", func(ctx context.Context, event *pgmodels.OutboxEvent) error {
+		var e pgmodels.UserEvent
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", event.Type, err)
+		}
+
+		return publish(ctx, publisher, event, events.UserDeletedV1{Mode: e.Mode, UserID: e.UserID})
+	})
+}
+
+/*
+importArticleEvents moves the events written with the articles in Mongo to the outbox.
+An event is only removed from its article once it is recorded, under the key it was written with,
+so one imported again after a failed removal is recorded once.
+*/
+func importArticleEvents(mdb *mongo.Client, pgdb *postgres.Client) outbox.ImportFunc {
+	return func(ctx context.Context) error {
+		articles, err := mdb.FindPendingArticleEvents(ctx, importBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, article := range articles {
+			keys := make([]string, 0, len(article.PendingEvents))
+			for _, e := range article.PendingEvents {
+				payload := pgmodels.ArticleEvent{ArticleID: article.ID.Hex(), Revision: e.Revision}
+				if err := pgdb.ImportEvent(ctx, e.Key, e.Type, payload, e.CreatedAt); err != nil {
+					return err
+				}
+				keys = append(keys, e.Key)
+			}
+
+			if err := mdb.ClearArticleEvents(ctx, &article.ID, keys); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// recountEngagement stores the engagement of an article counted again from its comments and likes.
+func recountEngagement(ctx context.Context, pgdb *postgres.Client, mdb *mongo.Client, articleID string) error {
+	engagement, countedAt, err := pgdb.CountEngagement(ctx, articleID)
+	if err != nil {
+		return err
+	}
+
+	return mdb.SetEngagement(ctx, articleID, engagement, countedAt)
+}
+
+// findEventArticle loads the article an event is about, nil once it was deleted.
+func findEventArticle(ctx context.Context, mdb *mongo.Client, articleID string) (*mongomodels.ArticleDB, error) {
+	exists, err := mdb.ArticleExists(ctx, articleID)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	articleOID, err := primitive.ObjectIDFromHex(articleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mdb.FindArticleByID(ctx, &articleOID)
+}
+
+// publish sends payload to the other services, with the key of the outbox event as its ID.
+func publish(ctx context.Context, publisher events.Publisher, event *pgmodels.OutboxEvent, payload events.Payload) error {
+	published, err := events.New(event.Key, event.CreatedAt, payload)
+	if err != nil {
+		return err
+	}
+
+	return publisher.Publish(ctx, published)
+}
+
+// onComment decodes the payload of comment events for fn.
+func onComment(fn func(ctx context.Context, e *pgmodels.CommentEvent) error) outbox.HandlerFunc {
+	return func(ctx context.Context, event *pgmodels.OutboxEvent) error {
+		var e pgmodels.CommentEvent
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", event.Type, err)
+		}
+
+		return fn(ctx, &e)
+	}
+}
+
+// onArticle decodes the payload of article events for fn.
+func onArticle(fn func(ctx context.Context, event *pgmodels.OutboxEvent, e *pgmodels.ArticleEvent) error) outbox.HandlerFunc {
+	return func(ctx context.Context, event *pgmodels.OutboxEvent) error {
+		var e pgmodels.ArticleEvent
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", event.Type, err)
+		}
+
+		return fn(ctx, event, &e)
+	}
+}
+
+// onLike decodes the payload of like events for fn.
+func onLike(fn func(ctx context.Context, e *pgmodels.LikeEvent) error) outbox.HandlerFunc {
+	return func(ctx context.Context, event *pgmodels.OutboxEvent) error {
+		var e pgmodels.LikeEvent
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", event.Type, err)
+		}
+
+		return fn(ctx, &e)
+	}
+}
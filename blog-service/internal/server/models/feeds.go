@@ -0,0 +1,130 @@
+package models
+
+import (
+	"blog-service/internal/db/mongo"
+	mongomodels "blog-service/internal/db/mongo/models"
+	"blog-service/internal/feed"
+	"blog-service/internal/render"
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const feedSize = 20
+
+// FeedRequest describes which feed to build and where it is served from.
+type FeedRequest struct {
+	Filter   mongo.FeedFilter
+	Title    string
+	SiteURL  string
+	SelfLink string
+}
+
+// tagURI builds a permanent, globally unique id (RFC 4151) for something published on the site.
+func tagURI(siteURL string, date time.Time, specific string) string {
+	host := siteURL
+	if u, err := url.Parse(siteURL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+
+	return fmt.Sprintf("tag:%s,%d:%s", host, date.Year(), specific)
+}
+
+// lastModified is the newest of the creation, publication and edit time of an article.
+func lastModified(article *mongomodels.ArticleDB) time.Time {
+	modified := article.CreatedAt
+	for _, t := range []time.Time{article.PublishAt, article.UpdatedAt} {
+		if t.After(modified) {
+			modified = t
+		}
+	}
+
+	return modified
+}
+
+// GetFeedState returns the state used for the ETag and Last-Modified headers of a feed.
+func GetFeedState(ctx context.Context, db *mongo.Client, filter mongo.FeedFilter) (*mongo.FeedState, error) {
+	return db.GetFeedState(ctx, filter, feedSize)
+}
+
+// BuildFeed loads the newest articles of a feed and describes them independently of the output format.
+func BuildFeed(ctx context.Context, db *mongo.Client, req *FeedRequest) (*feed.Feed, error) {
+	articles, err := db.FindFeedArticles(ctx, req.Filter, feedSize)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &feed.Feed{
+		ID:       tagURI(req.SiteURL, time.Unix(0, 0), "feed"+req.SelfLink),
+		Title:    req.Title,
+		Subtitle: req.Title,
+		Link:     req.SiteURL,
+		SelfLink: req.SiteURL + req.SelfLink,
+		Entries:  make([]feed.Entry, 0, len(articles)),
+	}
+
+	// Name author feeds after the author rather than their id
+	if req.Filter.PublisherID != 0 && len(articles) > 0 {
+		f.Title = "Articles by " + articles[0].PublisherName
+		f.Subtitle = f.Title
+	}
+
+	for i := range articles {
+		article := &articles[i]
+
+		// Cached HTML from an older renderer is rendered again, it is refreshed on the next article read
+		if article.RenderVersion != render.Version {
+			if err := renderArticle(article); err != nil {
+				return nil, err
+			}
+		}
+
+		published := article.PublishAt
+		if published.IsZero() {
+			published = article.CreatedAt
+		}
+
+		updated := lastModified(article)
+		if updated.After(f.Updated) {
+			f.Updated = updated
+		}
+
+		f.Entries = append(f.Entries, feed.Entry{
+			Published: published,
+			Updated:   updated,
+			ID:        tagURI(req.SiteURL, article.CreatedAt, "article/"+article.ID.Hex()),
+			Title:     article.Title,
+			Link:      req.SiteURL + articlePath(article),
+			Summary:   article.Excerpt,
+			Content:   article.ContentHTML,
+			Author:    article.PublisherName,
+			Category:  article.Category,
+		})
+	}
+
+	return f, nil
+}
+
+// AuthorFeedRequest describes the feed of a single author.
+func AuthorFeedRequest(siteURL string, publisherID int) *FeedRequest {
+	id := strconv.Itoa(publisherID)
+
+	return &FeedRequest{
+		Filter:   mongo.FeedFilter{PublisherID: publisherID},
+		Title:    "Articles by author " + id,
+		SiteURL:  siteURL,
+		SelfLink: "/authors/" + id + "/feed.atom",
+	}
+}
+
+// CategoryFeedRequest describes the feed of a single category.
+func CategoryFeedRequest(siteURL, category string) *FeedRequest {
+	return &FeedRequest{
+		Filter:   mongo.FeedFilter{Category: category},
+		Title:    "Articles in " + category,
+		SiteURL:  siteURL,
+		SelfLink: "/categories/" + url.PathEscape(category) + "/feed.atom",
+	}
+}
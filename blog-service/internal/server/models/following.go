@@ -0,0 +1,227 @@
+package models
+
+import (
+	"blog-service/internal/db/mongo"
+	mongomodels "blog-service/internal/db/mongo/models"
+	"blog-service/internal/db/postgres"
+	pgmodels "blog-service/internal/db/postgres/models"
+	"blog-service/internal/notify"
+	"context"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DefaultFeedLimit = 10
+	MaxFeedLimit     = 50
+
+	maxCategoryLength = 100
+)
+
+type FollowingDTO struct {
+	Authors    []int    `json:"authors"`
+	Categories []string `json:"categories"`
+}
+
+type ProfileDTO struct {
+	pgmodels.FollowCounts
+	ID int `json:"id"`
+	// FollowedByMe is only sent to authenticated callers
+	FollowedByMe *bool `json:"followedByMe,omitempty"`
+}
+
+/*
+FeedPage is one page of the personalised feed.
+NextCursor is passed back as ?cursor= to get the following page, it is empty on the last one.
+*/
+type FeedPage struct {
+	Articles   []mongomodels.ArticleDB `json:"articles"`
+	NextCursor string                  `json:"nextCursor,omitempty"`
+}
+
+/*
+FollowAuthor makes the caller follow (or unfollow) an author.
+Following is idempotent, repeating it changes nothing and does not notify the author again.
+*/
+func FollowAuthor(ctx context.Context, db *postgres.Client, notifier *notify.Dispatcher, authorID int, follow bool) error {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return &UnauthorizedError{}
+	}
+
+	if authorID <= 0 || authorID == userClaims.ID {
+		return &ParamError{}
+	}
+
+	if !follow {
+		_, err := db.UnfollowAuthor(ctx, userClaims.ID, authorID)
+		return err
+	}
+
+	created, err := db.FollowAuthor(ctx, userClaims.ID, authorID)
+	if err != nil {
+		return err
+	}
+
+	if created {
+		notifier.Publish(&pgmodels.Notification{
+			Type:        pgmodels.NotificationFollow,
+			RecipientID: authorID,
+			ActorID:     userClaims.ID,
+			ActorName:   userClaims.Username,
+		})
+	}
+
+	return nil
+}
+
+// FollowCategory makes the caller follow (or unfollow) a category.
+func FollowCategory(ctx context.Context, db *postgres.Client, category string, follow bool) error {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return &UnauthorizedError{}
+	}
+
+	category = strings.TrimSpace(category)
+	if category == "" || utf8.RuneCountInString(category) > maxCategoryLength {
+		return &ParamError{}
+	}
+
+	var err error
+	if follow {
+		_, err = db.FollowCategory(ctx, userClaims.ID, category)
+	} else {
+		_, err = db.UnfollowCategory(ctx, userClaims.ID, category)
+	}
+
+	return err
+}
+
+// GetFollowing lists the authors and categories the caller follows.
+func GetFollowing(ctx context.Context, db *postgres.Client) (*FollowingDTO, error) {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return nil, &UnauthorizedError{}
+	}
+
+	authors, err := db.GetFollowedAuthors(ctx, userClaims.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := db.GetFollowedCategories(ctx, userClaims.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FollowingDTO{Authors: authors, Categories: categories}, nil
+}
+
+// GetProfile returns the follower and following counts of a user.
+func GetProfile(ctx context.Context, db *postgres.Client, userID int) (*ProfileDTO, error) {
+	if userID <= 0 {
+		return nil, &ParamError{}
+	}
+
+	counts, err := db.GetFollowCounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &ProfileDTO{FollowCounts: *counts, ID: userID}
+
+	if userClaims := GetClaimsFromContext(ctx); userClaims != nil && userClaims.ID != userID {
+		following, err := db.IsFollowingAuthor(ctx, userClaims.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		profile.FollowedByMe = &following
+	}
+
+	return profile, nil
+}
+
+/*
+GetFollowingFeed returns a page of articles from the authors and categories the caller follows.
+The follow lists are read once from Postgres and handed to a single Mongo query,
+so a page costs two queries no matter how many follows the caller has.
+*/
+func GetFollowingFeed(ctx context.Context, pgdb *postgres.Client, mdb *mongo.Client, cursor string, limit int) (*FeedPage, error) {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return nil, &UnauthorizedError{}
+	}
+
+	if limit <= 0 {
+		limit = DefaultFeedLimit
+	}
+	limit = min(limit, MaxFeedLimit)
+
+	var after *mongo.FollowingCursor
+	if cursor != "" {
+		var err error
+		if after, err = decodeFeedCursor(cursor); err != nil {
+			return nil, &ParamError{}
+		}
+	}
+
+	authors, err := pgdb.GetFollowedAuthors(ctx, userClaims.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := pgdb.GetFollowedCategories(ctx, userClaims.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// One extra article tells whether another page exists
+	articles, err := mdb.FindFollowingArticles(ctx, authors, categories, after, int64(limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	page := &FeedPage{Articles: articles}
+	if len(articles) > limit {
+		page.Articles = articles[:limit]
+		last := page.Articles[limit-1]
+		page.NextCursor = encodeFeedCursor(&mongo.FollowingCursor{PublishAt: last.PublishAt, ID: last.ID})
+	}
+
+	return page, nil
+}
+
+// encodeFeedCursor packs a position into an opaque string, clients should not rely on its format.
+func encodeFeedCursor(cursor *mongo.FollowingCursor) string {
+	raw := strconv.FormatInt(cursor.PublishAt.UnixMilli(), 10) + ":" + cursor.ID.Hex()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeFeedCursor(s string) (*mongo.FollowingCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	millis, hex, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, &ParamError{}
+	}
+
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongo.FollowingCursor{PublishAt: time.UnixMilli(ms).UTC(), ID: id}, nil
+}
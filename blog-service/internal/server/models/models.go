@@ -1,24 +1,39 @@
 package models
 
 import (
+	"blog-service/internal/contentfilter"
 	"blog-service/internal/db/mongo"
 	mongomodels "blog-service/internal/db/mongo/models"
 	"blog-service/internal/db/postgres"
 	pgmodels "blog-service/internal/db/postgres/models"
+	"blog-service/internal/live"
+	"blog-service/internal/render"
 
 	"context"
 	"fmt"
+	"log/slog"
 	"math"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+/*
+ArticleUpdateDTO holds the fields to change, empty fields are left as they are.
+CoverImageID and AssetIDs are only changed when present, an empty CoverImageID removes the cover.
+*/
 type ArticleUpdateDTO struct {
-	Title       string `json:"title,omitempty"`
-	Content     string `json:"content,omitempty"`
-	Category    string `json:"category,omitempty"`
-	PublisherID int    `json:"publisherId,omitempty"`
+	CoverImageID  *string  `json:"coverImageId,omitempty"`
+	Title         string   `json:"title,omitempty"`
+	Content       string   `json:"content,omitempty"`
+	ContentFormat string   `json:"contentFormat,omitempty"`
+	Category      string   `json:"category,omitempty"`
+	Summary       string   `json:"summary,omitempty"`
+	AssetIDs      []string `json:"assetIds,omitempty"`
+}
+
+type ArticleUpdateResponse struct {
+	Revision int `json:"revision"`
 }
 
 /*
@@ -26,24 +41,45 @@ type ArticleUpdateDTO struct {
 		   from the token instead of the request
 */
 type ArticleCreateDTO struct {
-	Title    string `json:"title"`
-	Content  string `json:"content"`
-	Category string `json:"category"`
+	PublishAt     *time.Time `json:"publishAt,omitempty"`
+	Title         string     `json:"title"`
+	Content       string     `json:"content"`
+	ContentFormat string     `json:"contentFormat,omitempty"`
+	Category      string     `json:"category"`
+	Status        string     `json:"status,omitempty"`
+	CoverImageID  string     `json:"coverImageId,omitempty"`
+	AssetIDs      []string   `json:"assetIds,omitempty"`
 }
 
 type ArticleGetDTO struct {
-	CreatedAt     time.Time        `bson:"created_at" json:"createdAt"`
-	Title         string           `bson:"title" json:"title"`
-	Content       string           `bson:"content" json:"content"`
-	PublisherName string           `bson:"publisher_name" json:"publisherName"`
-	Category      string           `bson:"category" json:"category"`
-	ID            string           `json:"id"`
-	Comments      []CommentsGetDTO `json:"comments"`
-	PublisherID   int              `bson:"publisher_id" json:"publisherId"`
+	CreatedAt     time.Time               `bson:"createdAt" json:"createdAt"`
+	PublishAt     time.Time               `bson:"publishAt" json:"publishAt"`
+	Title         string                  `bson:"title" json:"title"`
+	Slug          string                  `bson:"slug" json:"slug"`
+	Content       string                  `bson:"content" json:"content"`
+	ContentFormat string                  `bson:"contentFormat" json:"contentFormat"`
+	Excerpt       string                  `bson:"excerpt" json:"excerpt"`
+	PublisherName string                  `bson:"publisherName" json:"publisherName"`
+	Category      string                  `bson:"category" json:"category"`
+	Status        string                  `bson:"status" json:"status"`
+	CoverImage    *mongomodels.CoverImage `bson:"coverImage" json:"coverImage,omitempty"`
+	AssetIDs      []string                `bson:"assetIds" json:"assetIds,omitempty"`
+	ID            string                  `json:"id"`
+	Comments      []CommentsGetDTO        `json:"comments"`
+	PublisherID   int                     `bson:"publisherId" json:"publisherId"`
+	// Bookmarked is true when the authenticated caller saved the article
+	Bookmarked bool `json:"bookmarked"`
 }
 
 type ArticleCreateResponse struct {
-	ID string `json:"id"`
+	ID   string `json:"id"`
+	Slug string `json:"slug"`
+}
+
+// CommentCreateResponse tells whether the new comment was published or held for review.
+type CommentCreateResponse struct {
+	ID   int  `json:"id"`
+	Held bool `json:"held"`
 }
 
 type CommentsGetDTO struct {
@@ -60,9 +96,18 @@ type CommentCreateDTO struct {
 	ArticleID string `json:"articleId"`
 }
 
+/*
+GetCommentsByArticleID returns a page of the comments of an article.
+Held comments are included for their author and for moderators.
+*/
 func GetCommentsByArticleID(ctx context.Context, db *postgres.Client, id string, limit, page int) ([]CommentsGetDTO, error) {
+	viewerID, allHeld := 0, false
+	if userClaims := GetClaimsFromContext(ctx); userClaims != nil {
+		viewerID, allHeld = userClaims.ID, userClaims.CanModerate()
+	}
+
 	// Get comments count
-	commCount, err := db.GetCommentsCount(ctx, id)
+	commCount, err := db.GetCommentsCountForViewer(ctx, id, viewerID, allHeld)
 	if err != nil {
 		return []CommentsGetDTO{}, err
 	}
@@ -74,7 +119,7 @@ func GetCommentsByArticleID(ctx context.Context, db *postgres.Client, id string,
 		page = int(ceil)
 	}
 
-	comments, err := db.GetComments(ctx, id, limit, page)
+	comments, err := db.GetCommentsForViewer(ctx, id, viewerID, allHeld, limit, page)
 	if err != nil {
 		return []CommentsGetDTO{}, err
 	}
@@ -95,7 +140,39 @@ func GetCommentsByArticleID(ctx context.Context, db *postgres.Client, id string,
 	return commentsRes, nil
 }
 
-func GetArticleByID(ctx context.Context, db *mongo.Client, id string) (*ArticleGetDTO, error) {
+/*
+renderArticle fills in the cached HTML and excerpt of an article
+from its raw content and source format.
+*/
+func renderArticle(article *mongomodels.ArticleDB) error {
+	contentHTML, err := render.ToHTML(article.ContentFormat, article.Content)
+	if err != nil {
+		return err
+	}
+
+	article.ContentHTML = contentHTML
+	article.Excerpt = render.Excerpt(contentHTML)
+	article.RenderVersion = render.Version
+
+	return nil
+}
+
+/*
+GetArticleByID returns the article with the given id.
+output selects the representation of the content: render.OutputRaw returns
+the body as written, render.OutputHTML returns it as sanitised HTML.
+Articles whose cached HTML is missing or was produced by an older renderer
+are rendered again and the cache is refreshed.
+*/
+func GetArticleByID(ctx context.Context, db *mongo.Client, id, output string) (*ArticleGetDTO, error) {
+	if output == "" {
+		output = render.OutputRaw
+	}
+
+	if output != render.OutputRaw && output != render.OutputHTML {
+		return nil, &ParamError{}
+	}
+
 	articleOID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
@@ -106,67 +183,266 @@ func GetArticleByID(ctx context.Context, db *mongo.Client, id string) (*ArticleG
 		return nil, err
 	}
 
+	if !canView(ctx, article) {
+		return nil, &InvalidArticleError{}
+	}
+
+	if article.RenderVersion != render.Version {
+		if err := renderArticle(article); err != nil {
+			return nil, err
+		}
+
+		// A failed cache refresh only costs a re-render on the next read
+		err = db.UpdateArticleRender(ctx, &articleOID, article.ContentHTML, article.Excerpt, article.RenderVersion)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to cache rendered article", "article", id, "error", err)
+		}
+	}
+
+	content := article.Content
+	if output == render.OutputHTML {
+		content = article.ContentHTML
+	}
+
+	contentFormat := article.ContentFormat
+	if contentFormat == "" {
+		contentFormat = render.FormatPlain
+	}
+
+	status := article.Status
+	if status == "" {
+		status = mongomodels.StatusPublished
+	}
+
 	return &ArticleGetDTO{
 		CreatedAt:     article.CreatedAt,
+		PublishAt:     article.PublishAt,
 		Title:         article.Title,
-		Content:       article.Content,
+		Slug:          article.Slug,
+		Content:       content,
+		ContentFormat: contentFormat,
+		Excerpt:       article.Excerpt,
 		PublisherName: article.PublisherName,
 		Category:      article.Category,
+		Status:        status,
+		CoverImage:    article.CoverImage,
+		AssetIDs:      article.AssetIDs,
 		ID:            id,
 		PublisherID:   article.PublisherID,
 	}, nil
 }
 
-func CreateArticle(ctx context.Context, db *mongo.Client, article *ArticleCreateDTO) (string, error) {
+func CreateArticle(ctx context.Context, db *mongo.Client, article *ArticleCreateDTO) (*ArticleCreateResponse, error) {
 	userClaims := GetClaimsFromContext(ctx)
 	if userClaims == nil {
-		return "", &UnauthorizedError{}
+		return nil, &UnauthorizedError{}
 	}
 
 	if article.Title == "" || article.Content == "" || article.Category == "" {
-		return "", &ParamError{}
+		return nil, &ParamError{}
+	}
+
+	// New articles are written in markdown unless stated otherwise
+	if article.ContentFormat == "" {
+		article.ContentFormat = render.FormatMarkdown
+	}
+
+	if !render.IsValidFormat(article.ContentFormat) {
+		return nil, &ParamError{}
+	}
+
+	now := time.Now()
+
+	// Archiving only makes sense for articles that already exist
+	if article.Status == mongomodels.StatusArchived {
+		return nil, &ParamError{}
+	}
+
+	status, publishAt, err := resolveStatus(article.Status, article.PublishAt, now)
+	if err != nil {
+		return nil, err
+	}
+
+	coverImage, assetIDs, err := resolveAssets(ctx, db, userClaims, article.AssetIDs, article.CoverImageID)
+	if err != nil {
+		return nil, err
 	}
 
 	articleToInsert := mongomodels.ArticleDB{
-		CreatedAt:     time.Now(),
+		CreatedAt:     now,
+		PublishAt:     publishAt,
+		Status:        status,
+		CoverImage:    coverImage,
+		AssetIDs:      assetIDs,
 		Title:         article.Title,
 		Content:       article.Content,
+		ContentFormat: article.ContentFormat,
 		Category:      article.Category,
 		PublisherName: userClaims.Username,
 		PublisherID:   userClaims.ID,
+		ID:            primitive.NewObjectID(),
+	}
+
+	if err := renderArticle(&articleToInsert); err != nil {
+		return nil, err
+	}
+
+	if articleToInsert.IsPublished() {
+		articleToInsert.PendingEvents = []mongomodels.ArticleEventDB{mongomodels.NewArticleEvent(pgmodels.EventArticlePublished, 0)}
+	}
+
+	if err := assignSlug(ctx, db, &articleToInsert); err != nil {
+		return nil, err
 	}
 
 	res, err := db.InsertArticle(ctx, &articleToInsert)
 	if err != nil {
-		return "", err
+		if delErr := db.DeleteSlugs(ctx, &articleToInsert.ID); delErr != nil {
+			slog.ErrorContext(ctx, "Failed to release slug", "slug", articleToInsert.Slug, "error", delErr)
+		}
+		return nil, err
 	}
 
 	oid, ok := res.InsertedID.(primitive.ObjectID)
 	if !ok {
-		return "", fmt.Errorf("failed to convert returned result to objectid")
+		return nil, fmt.Errorf("failed to convert returned result to objectid")
+	}
+
+	articleToInsert.ID = oid
+	revision := snapshot(&articleToInsert, userClaims.ID, userClaims.Username, initialRevisionSummary, now)
+
+	// The article exists already, a missing first revision is seeded again on the first edit
+	if _, err := db.InsertRevision(ctx, revision); err != nil {
+		slog.ErrorContext(ctx, "Failed to store initial revision of article", "article", oid.Hex(), "error", err)
 	}
 
-	return oid.Hex(), nil
+	return &ArticleCreateResponse{ID: oid.Hex(), Slug: articleToInsert.Slug}, nil
 }
 
-func CreateComment(ctx context.Context, pgdb *postgres.Client, mdb *mongo.Client, comment *CommentCreateDTO) error {
+/*
+UpdateArticle edits an article and records the new state as a revision.
+Fields left empty in the request keep their current value.
+Only the publisher and admins may edit an article, readers are told about edits of published ones.
+@returns
+int - the number of the revision created by the edit.
+error - for checking the successful execution of the function.
+*/
+func UpdateArticle(ctx context.Context, db *mongo.Client, id string, update *ArticleUpdateDTO) (int, error) {
+	article, err := findManagedArticle(ctx, db, id)
+	if err != nil {
+		return 0, err
+	}
+
+	if update.ContentFormat != "" && !render.IsValidFormat(update.ContentFormat) {
+		return 0, &ParamError{}
+	}
+
+	changed := false
+	apply := func(field *string, value string) {
+		if value != "" && value != *field {
+			*field = value
+			changed = true
+		}
+	}
+
+	// The state before the edit, recorded as the first revision of articles without history
+	original := *article
+
+	apply(&article.Title, update.Title)
+	apply(&article.Content, update.Content)
+	apply(&article.ContentFormat, update.ContentFormat)
+	apply(&article.Category, update.Category)
+
+	if update.CoverImageID != nil || update.AssetIDs != nil {
+		assetIDs := update.AssetIDs
+		if assetIDs == nil {
+			assetIDs = article.AssetIDs
+		}
+
+		coverImageID := ""
+		if article.CoverImage != nil {
+			coverImageID = article.CoverImage.AssetID
+		}
+		if update.CoverImageID != nil {
+			coverImageID = *update.CoverImageID
+		}
+
+		coverImage, ids, err := resolveAssets(ctx, db, GetClaimsFromContext(ctx), assetIDs, coverImageID)
+		if err != nil {
+			return 0, err
+		}
+
+		article.CoverImage = coverImage
+		article.AssetIDs = ids
+		changed = true
+	}
+
+	if !changed {
+		return 0, &ParamError{}
+	}
+
+	if err := seedRevision(ctx, db, &original); err != nil {
+		return 0, err
+	}
+
+	return saveRevision(ctx, db, article, update.Summary, 0)
+}
+
+/*
+deleteArticle removes an article with its history and its slugs. The article-deleted event
+must already be recorded, its handlers remove the comments once the article is gone.
+*/
+func deleteArticle(ctx context.Context, db *mongo.Client, articleID *primitive.ObjectID) error {
+	if err := db.DeleteArticle(ctx, articleID); err != nil {
+		return err
+	}
+	if err := db.DeleteRevisions(ctx, articleID); err != nil {
+		return err
+	}
+
+	return db.DeleteSlugs(ctx, articleID)
+}
+
+/*
+CreateComment runs a comment through the content filter and adds it to a published article.
+Accepted comments are pushed to readers streaming it, the publisher is notified by the comment.created event.
+Held comments wait in the moderation queue until a moderator restores them.
+*/
+func CreateComment(ctx context.Context, pgdb *postgres.Client, mdb *mongo.Client, bus *live.Bus,
+	filter contentfilter.ContentFilter, comment *CommentCreateDTO) (*CommentCreateResponse, error) {
 	userClaims := GetClaimsFromContext(ctx)
 	if userClaims == nil {
-		return &UnauthorizedError{}
+		return nil, &UnauthorizedError{}
 	}
 
 	if comment.ArticleID == "" || comment.Content == "" {
-		return &ParamError{}
+		return nil, &ParamError{}
 	}
 
 	articleOID, err := primitive.ObjectIDFromHex(comment.ArticleID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = mdb.FindArticleByID(ctx, &articleOID)
-	if err != nil {
-		return &InvalidArticleError{}
+	article, err := mdb.FindArticleByID(ctx, &articleOID)
+	if err != nil || !article.IsPublished() {
+		return nil, &InvalidArticleError{}
+	}
+
+	verdict := contentfilter.Result{Verdict: contentfilter.Accept}
+	if filter != nil {
+		verdict, err = filter.Check(ctx, &contentfilter.Comment{
+			Content:   comment.Content,
+			ArticleID: comment.ArticleID,
+			UserID:    userClaims.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if verdict.Verdict == contentfilter.Reject {
+		return nil, &RejectedContentError{Reason: verdict.Reason}
 	}
 
 	commentToInsert := pgmodels.Comment{
@@ -174,9 +450,28 @@ func CreateComment(ctx context.Context, pgdb *postgres.Client, mdb *mongo.Client
 		Content:   comment.Content,
 		ArticleID: comment.ArticleID,
 		UserID:    userClaims.ID,
+		Held:      verdict.Verdict == contentfilter.Hold,
+	}
+
+	commentID, err := pgdb.CreateComment(ctx, commentToInsert, pgmodels.CommentEvent{
+		AuthorName:  userClaims.Username,
+		PublisherID: article.PublisherID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	commentToInsert.ID = commentID
+
+	if commentToInsert.Held {
+		if err := holdComment(ctx, pgdb, &commentToInsert, verdict.Reason); err != nil {
+			return nil, err
+		}
+
+		return &CommentCreateResponse{ID: commentID, Held: true}, nil
 	}
 
-	_, err = pgdb.CreateComment(ctx, commentToInsert)
+	publishCommentEvent(ctx, bus, live.CommentCreated, CommentsGetDTO{commentToInsert, 0})
 
-	return err
+	return &CommentCreateResponse{ID: commentID}, nil
 }
@@ -0,0 +1,418 @@
+package models
+
+import (
+	"blog-service/internal/db/mongo"
+	mongomodels "blog-service/internal/db/mongo/models"
+	"blog-service/internal/db/postgres"
+	pgmodels "blog-service/internal/db/postgres/models"
+	"blog-service/internal/live"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Reasons a report can give.
+const (
+	ReasonSpam           = "spam"
+	ReasonHarassment     = "harassment"
+	ReasonHateSpeech     = "hate_speech"
+	ReasonViolence       = "violence"
+	ReasonSexualContent  = "sexual_content"
+	ReasonMisinformation = "misinformation"
+	ReasonCopyright      = "copyright"
+	ReasonOther          = "other"
+)
+
+// ReportReasons lists every reason a report can give.
+var ReportReasons = []string{
+	ReasonSpam,
+	ReasonHarassment,
+	ReasonHateSpeech,
+	ReasonViolence,
+	ReasonSexualContent,
+	ReasonMisinformation,
+	ReasonCopyright,
+	ReasonOther,
+}
+
+// Actions a moderator can take.
+const (
+	ActionHide    = "hide"
+	ActionRestore = "restore"
+	ActionDelete  = "delete"
+	ActionWarn    = "warn"
+	ActionDismiss = "dismiss"
+	// ActionHold is recorded when the content filter holds a comment for review
+	ActionHold = "hold"
+)
+
+const (
+	DefaultAutoHideThreshold = 5
+	moderationPageSize       = 20
+	maxReportDetailsLength   = 1000
+)
+
+/*
+ReportCreateDTO reports an article or a comment.
+TargetID is the article id or the comment id, Details are required for ReasonOther.
+*/
+type ReportCreateDTO struct {
+	TargetType string `json:"targetType"`
+	TargetID   string `json:"targetId"`
+	Reason     string `json:"reason"`
+	Details    string `json:"details,omitempty"`
+}
+
+type ModerationActionDTO struct {
+	Action string `json:"action"`
+	Note   string `json:"note,omitempty"`
+}
+
+// moderationTarget is the reported content as far as moderation needs it.
+type moderationTarget struct {
+	article   *mongomodels.ArticleDB
+	comment   *pgmodels.Comment
+	articleID primitive.ObjectID
+	authorID  int
+}
+
+// findModerationTarget looks up reported content, ParamError when the type or id is invalid.
+func findModerationTarget(ctx context.Context, pgdb *postgres.Client, mdb *mongo.Client, targetType, targetID string) (*moderationTarget, error) {
+	switch targetType {
+	case pgmodels.TargetArticle:
+		articleOID, err := primitive.ObjectIDFromHex(targetID)
+		if err != nil {
+			return nil, &ParamError{}
+		}
+
+		article, err := mdb.FindArticleByID(ctx, &articleOID)
+		if err != nil {
+			return nil, &InvalidArticleError{}
+		}
+
+		return &moderationTarget{article: article, articleID: articleOID, authorID: article.PublisherID}, nil
+
+	case pgmodels.TargetComment:
+		commentID, err := strconv.Atoi(targetID)
+		if err != nil {
+			return nil, &ParamError{}
+		}
+
+		comment, err := findComment(ctx, pgdb, commentID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &moderationTarget{comment: comment, authorID: comment.UserID}, nil
+
+	default:
+		return nil, &ParamError{}
+	}
+}
+
+/*
+ReportContent records a report of the caller.
+Every user counts once per item. When autoHideThreshold users reported an item
+since it was last reviewed, the item is hidden until a moderator looks at it.
+*/
+func ReportContent(ctx context.Context, pgdb *postgres.Client, mdb *mongo.Client, bus *live.Bus,
+	dto *ReportCreateDTO, autoHideThreshold int) error {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return &UnauthorizedError{}
+	}
+
+	if !slices.Contains(ReportReasons, dto.Reason) || len(dto.Details) > maxReportDetailsLength ||
+		(dto.Reason == ReasonOther && dto.Details == "") {
+		return &ParamError{}
+	}
+
+	target, err := findModerationTarget(ctx, pgdb, mdb, dto.TargetType, dto.TargetID)
+	if err != nil {
+		return err
+	}
+
+	if target.article != nil && !canView(ctx, target.article) {
+		return &InvalidArticleError{}
+	}
+	if target.comment != nil {
+		if err := checkCommentOpen(userClaims, target.comment); err != nil {
+			return err
+		}
+	}
+
+	if target.authorID == userClaims.ID {
+		return &ParamError{}
+	}
+
+	report := &pgmodels.Report{
+		TargetType: dto.TargetType,
+		TargetID:   dto.TargetID,
+		ReporterID: userClaims.ID,
+		Reason:     dto.Reason,
+		Details:    dto.Details,
+	}
+
+	item, created, err := pgdb.AddReport(ctx, report, target.authorID)
+	if err != nil {
+		return err
+	}
+
+	if !created || item.Status != pgmodels.ModerationOpen || item.ReportCount-item.ReviewedCount < autoHideThreshold {
+		return nil
+	}
+
+	note := fmt.Sprintf("hidden automatically after %d reports", item.ReportCount-item.ReviewedCount)
+
+	return applyModeration(ctx, pgdb, mdb, bus, 0, item, target, &ModerationActionDTO{Action: ActionHide, Note: note})
+}
+
+// GetModerationQueue lists reported content for moderators.
+func GetModerationQueue(ctx context.Context, db *postgres.Client, filter pgmodels.ModerationFilter, page int) ([]pgmodels.ModerationItem, error) {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return nil, &UnauthorizedError{}
+	}
+
+	if !userClaims.CanModerate() {
+		return nil, &ForbiddenError{}
+	}
+
+	if filter.Reason != "" && !slices.Contains(ReportReasons, filter.Reason) {
+		return nil, &ParamError{}
+	}
+
+	return db.GetModerationQueue(ctx, filter, moderationPageSize, max(page, 1))
+}
+
+// GetModerationActions returns the audit trail of one item, or of all content when targetType is empty.
+func GetModerationActions(ctx context.Context, db *postgres.Client, targetType, targetID string, page int) ([]pgmodels.ModerationAction, error) {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return nil, &UnauthorizedError{}
+	}
+
+	if !userClaims.CanModerate() {
+		return nil, &ForbiddenError{}
+	}
+
+	return db.GetModerationActions(ctx, targetType, targetID, moderationPageSize, max(page, 1))
+}
+
+// ModerateContent applies a moderator decision to an article or a comment.
+func ModerateContent(ctx context.Context, pgdb *postgres.Client, mdb *mongo.Client, bus *live.Bus,
+	targetType, targetID string, dto *ModerationActionDTO) error {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return &UnauthorizedError{}
+	}
+
+	if !userClaims.CanModerate() {
+		return &ForbiddenError{}
+	}
+
+	if len(dto.Note) > maxReportDetailsLength {
+		return &ParamError{}
+	}
+
+	target, err := findModerationTarget(ctx, pgdb, mdb, targetType, targetID)
+	if err != nil {
+		return err
+	}
+
+	item, err := pgdb.GetModerationItem(ctx, targetType, targetID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		item = &pgmodels.ModerationItem{TargetType: targetType, TargetID: targetID, Status: pgmodels.ModerationResolved}
+	} else if err != nil {
+		return err
+	}
+
+	return applyModeration(ctx, pgdb, mdb, bus, userClaims.ID, item, target, dto)
+}
+
+/*
+applyModeration changes the content and records the action in the audit trail, in one transaction.
+The decision only applies while the queue item still has the status it was read with, actions
+taken automatically are dropped otherwise. actorID is 0 for actions taken automatically.
+*/
+func applyModeration(ctx context.Context, pgdb *postgres.Client, mdb *mongo.Client, bus *live.Bus,
+	actorID int, item *pgmodels.ModerationItem, target *moderationTarget, dto *ModerationActionDTO) error {
+	change := &postgres.ModerationChange{
+		Action: &pgmodels.ModerationAction{
+			TargetType: item.TargetType,
+			TargetID:   item.TargetID,
+			ActorID:    actorID,
+			Action:     dto.Action,
+			Note:       dto.Note,
+		},
+		From:     item.Status,
+		To:       item.Status,
+		AuthorID: target.authorID,
+	}
+	if target.comment != nil {
+		change.CommentID = target.comment.ID
+	}
+
+	// Readers of the comments only hear about the change once it is committed
+	announce := func() {}
+
+	switch dto.Action {
+	case ActionHide:
+		if item.Status == pgmodels.ModerationHidden {
+			return &ParamError{}
+		}
+		change.To = pgmodels.ModerationHidden
+
+		if target.article != nil {
+			change.PreviousStatus = target.article.Status
+			if change.PreviousStatus == "" {
+				change.PreviousStatus = mongomodels.StatusPublished
+			}
+			change.Apply = func(ctx context.Context) error {
+				_, err := mdb.UpdateArticleStatus(ctx, &target.articleID, mongomodels.StatusHidden, target.article.PublishAt)
+				return err
+			}
+		} else {
+			change.CommentStatus = pgmodels.CommentHidden
+			// Readers never saw held comments
+			if target.comment.Status == pgmodels.CommentVisible {
+				announce = func() {
+					publishCommentEvent(ctx, bus, live.CommentDeleted, CommentsGetDTO{Comment: *target.comment})
+				}
+			}
+		}
+
+	case ActionRestore:
+		if item.Status != pgmodels.ModerationHidden {
+			return &ParamError{}
+		}
+		change.To = pgmodels.ModerationResolved
+
+		if target.article != nil {
+			restored := item.PreviousStatus
+			if restored == "" {
+				restored = mongomodels.StatusPublished
+			}
+			change.Apply = func(ctx context.Context) error {
+				_, err := mdb.UpdateArticleStatus(ctx, &target.articleID, restored, target.article.PublishAt)
+				return err
+			}
+		} else {
+			change.CommentStatus = pgmodels.CommentVisible
+			announce = func() {
+				likes, err := pgdb.GetCommentLikeCount(ctx, target.comment.ID)
+				if err != nil {
+					slog.ErrorContext(ctx, "Failed to count likes of restored comment", "comment", target.comment.ID, "error", err)
+					return
+				}
+				target.comment.Status, target.comment.Held = pgmodels.CommentVisible, false
+				publishCommentEvent(ctx, bus, live.CommentCreated, CommentsGetDTO{*target.comment, likes})
+			}
+		}
+
+	case ActionDelete:
+		change.To = pgmodels.ModerationDeleted
+
+		if target.article != nil {
+			change.DeleteArticle = true
+			change.Apply = func(ctx context.Context) error {
+				return deleteArticle(ctx, mdb, &target.articleID)
+			}
+		} else {
+			change.DeleteComment = true
+			if target.comment.Status == pgmodels.CommentVisible {
+				announce = func() {
+					publishCommentEvent(ctx, bus, live.CommentDeleted, CommentsGetDTO{Comment: *target.comment})
+				}
+			}
+		}
+
+	case ActionWarn:
+		if dto.Note == "" {
+			return &ParamError{}
+		}
+
+		// Stored with the action and delivered by the relay, so a recorded warning always reaches the author
+		change.Warning = &pgmodels.Notification{
+			Type:        pgmodels.NotificationWarning,
+			RecipientID: target.authorID,
+			ActorID:     actorID,
+			Message:     dto.Note,
+		}
+		if target.article != nil {
+			change.Warning.ArticleID = target.articleID.Hex()
+		} else {
+			change.Warning.ArticleID = target.comment.ArticleID
+			change.Warning.CommentID = target.comment.ID
+		}
+
+	case ActionDismiss:
+		if item.Status != pgmodels.ModerationOpen {
+			return &ParamError{}
+		}
+		change.To = pgmodels.ModerationResolved
+
+	default:
+		return &ParamError{}
+	}
+
+	err := pgdb.Moderate(ctx, change)
+	if errors.Is(err, postgres.ErrModerationConflict) {
+		// Another decision got there first, an automatic hide has nothing left to do
+		if actorID == 0 {
+			return nil
+		}
+		return &ParamError{}
+	}
+	if err != nil {
+		return err
+	}
+
+	announce()
+
+	if target.comment != nil && actorID != 0 {
+		learnFromDecision(ctx, pgdb, target.comment, dto.Action)
+	}
+
+	return nil
+}
+
+/*
+learnFromDecision keeps a moderator decision on a comment as a training sample for the spam classifier.
+Hidden and deleted comments are examples of spam, restored and dismissed ones of legitimate comments.
+*/
+func learnFromDecision(ctx context.Context, db *postgres.Client, comment *pgmodels.Comment, action string) {
+	var isSpam bool
+
+	switch action {
+	case ActionHide, ActionDelete:
+		isSpam = true
+	case ActionRestore, ActionDismiss:
+		isSpam = false
+	default:
+		return
+	}
+
+	// The decision itself is recorded, a missing sample only makes the classifier learn less
+	if err := db.AddFilterSample(ctx, pgmodels.FilterSample{Content: comment.Content, Spam: isSpam}); err != nil {
+		slog.ErrorContext(ctx, "Failed to store filter sample", "comment", comment.ID, "error", err)
+	}
+}
+
+// holdComment puts a comment the content filter held into the moderation queue.
+func holdComment(ctx context.Context, db *postgres.Client, comment *pgmodels.Comment, reason string) error {
+	action := &pgmodels.ModerationAction{
+		TargetType: pgmodels.TargetComment,
+		TargetID:   strconv.Itoa(comment.ID),
+		Action:     ActionHold,
+		Note:       reason,
+	}
+
+	return db.RecordModerationAction(ctx, action, comment.UserID, pgmodels.ModerationHidden, "")
+}
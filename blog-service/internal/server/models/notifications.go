@@ -0,0 +1,140 @@
+package models
+
+import (
+	"blog-service/internal/db/postgres"
+	pgmodels "blog-service/internal/db/postgres/models"
+	"blog-service/internal/live"
+	"context"
+	"errors"
+)
+
+const (
+	DefaultNotificationLimit = 20
+	MaxNotificationLimit     = 100
+)
+
+/*
+NotificationsDTO is a page of notifications.
+NextBefore is passed back as ?before= to get older notifications, it is 0 on the last page.
+*/
+type NotificationsDTO struct {
+	Notifications []pgmodels.Notification `json:"notifications"`
+	Unread        int                     `json:"unread"`
+	NextBefore    int                     `json:"nextBefore,omitempty"`
+}
+
+/*
+NotificationsReadDTO selects the notifications to mark as read.
+Leaving IDs empty marks all of them.
+*/
+type NotificationsReadDTO struct {
+	IDs []int `json:"ids,omitempty"`
+}
+
+// GetNotifications lists the notifications of the caller, newest first.
+func GetNotifications(ctx context.Context, db *postgres.Client, beforeID, limit int, unreadOnly bool) (*NotificationsDTO, error) {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return nil, &UnauthorizedError{}
+	}
+
+	if beforeID < 0 {
+		return nil, &ParamError{}
+	}
+
+	if limit <= 0 {
+		limit = DefaultNotificationLimit
+	}
+	limit = min(limit, MaxNotificationLimit)
+
+	notifications, err := db.GetNotifications(ctx, userClaims.ID, beforeID, limit, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := db.CountUnreadNotifications(ctx, userClaims.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &NotificationsDTO{Notifications: notifications, Unread: unread}
+	if len(notifications) == limit {
+		res.NextBefore = notifications[len(notifications)-1].ID
+	}
+
+	return res, nil
+}
+
+// MarkNotificationsRead marks notifications of the caller as read, ids of other users are ignored.
+func MarkNotificationsRead(ctx context.Context, db *postgres.Client, dto *NotificationsReadDTO) error {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return &UnauthorizedError{}
+	}
+
+	ids := dto.IDs
+	if len(ids) == 0 {
+		ids = nil
+	}
+
+	_, err := db.MarkNotificationsRead(ctx, userClaims.ID, ids)
+
+	return err
+}
+
+/*
+LikeComment adds (or removes) the like of the caller on a comment.
+Liking twice keeps a single like, the author is only notified the first time, by the like.added event.
+Readers streaming the article receive the new like count of visible comments.
+Hidden comments can only be liked by moderators.
+*/
+func LikeComment(ctx context.Context, db *postgres.Client, bus *live.Bus, commentID int, like bool) error {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return &UnauthorizedError{}
+	}
+
+	comment, err := findComment(ctx, db, commentID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkCommentOpen(userClaims, comment); err != nil {
+		return err
+	}
+
+	if !like {
+		tag, err := db.RemoveLike(ctx, commentID, userClaims.ID)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+
+		return publishLikeCount(ctx, db, bus, comment)
+	}
+
+	newLike := pgmodels.Like{CommentID: commentID, UserID: userClaims.ID}
+	_, err = db.AddLike(ctx, newLike, pgmodels.LikeEvent{UserName: userClaims.Username})
+	if errors.Is(err, postgres.ErrAlreadyLiked) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return publishLikeCount(ctx, db, bus, comment)
+}
+
+func publishLikeCount(ctx context.Context, db *postgres.Client, bus *live.Bus, comment *pgmodels.Comment) error {
+	if comment.Status != pgmodels.CommentVisible {
+		return nil
+	}
+
+	likes, err := db.GetCommentLikeCount(ctx, comment.ID)
+	if err != nil {
+		return err
+	}
+
+	publishCommentEvent(ctx, bus, live.CommentLikes, CommentsGetDTO{*comment, likes})
+
+	return nil
+}
@@ -0,0 +1,222 @@
+package models
+
+import (
+	"blog-service/internal/db/mongo"
+	mongomodels "blog-service/internal/db/mongo/models"
+	pgmodels "blog-service/internal/db/postgres/models"
+	"blog-service/internal/diff"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const initialRevisionSummary = "Initial version"
+
+type RevisionDiffDTO struct {
+	Title    []diff.Line `json:"title"`
+	Category []diff.Line `json:"category"`
+	Content  []diff.Line `json:"content"`
+	From     int         `json:"from"`
+	To       int         `json:"to"`
+}
+
+type RevisionRestoreResponse struct {
+	Number int `json:"number"`
+}
+
+func snapshot(article *mongomodels.ArticleDB, authorID int, authorName, summary string, createdAt time.Time) *mongomodels.RevisionDB {
+	return &mongomodels.RevisionDB{
+		CreatedAt:     createdAt,
+		Title:         article.Title,
+		Content:       article.Content,
+		ContentFormat: article.ContentFormat,
+		Category:      article.Category,
+		AuthorName:    authorName,
+		Summary:       summary,
+		ArticleID:     article.ID,
+		AuthorID:      authorID,
+	}
+}
+
+/*
+seedRevision records the current state of an article as its first revision.
+Articles created before revisions existed have no history, so the state they
+are in before their first edit would otherwise be lost.
+*/
+func seedRevision(ctx context.Context, db *mongo.Client, article *mongomodels.ArticleDB) error {
+	latest, err := db.LatestRevisionNumber(ctx, &article.ID)
+	if err != nil || latest > 0 {
+		return err
+	}
+
+	createdAt := article.UpdatedAt
+	if createdAt.IsZero() {
+		createdAt = article.CreatedAt
+	}
+
+	_, err = db.InsertRevision(ctx, snapshot(article, article.PublisherID, article.PublisherName, initialRevisionSummary, createdAt))
+
+	return err
+}
+
+/*
+saveRevision stores the new state of an article as a revision and then writes it to the article.
+The revision is removed again if the article could not be updated,
+so the newest revision always matches the article. Edits of published articles record article.updated.
+@returns
+int - the number of the new revision.
+error - for checking the successful execution of the function.
+*/
+func saveRevision(ctx context.Context, db *mongo.Client, article *mongomodels.ArticleDB, summary string, restoredFrom int) (int, error) {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return 0, &UnauthorizedError{}
+	}
+
+	now := time.Now()
+	article.UpdatedAt = now
+
+	if err := renderArticle(article); err != nil {
+		return 0, err
+	}
+
+	// A new title gets a new slug, the old one keeps redirecting
+	if err := assignSlug(ctx, db, article); err != nil {
+		return 0, err
+	}
+
+	revision := snapshot(article, userClaims.ID, userClaims.Username, summary, now)
+	revision.RestoredFrom = restoredFrom
+
+	number, err := db.InsertRevision(ctx, revision)
+	if err != nil {
+		return 0, err
+	}
+
+	var events []mongomodels.ArticleEventDB
+	if article.IsPublished() {
+		events = append(events, mongomodels.NewArticleEvent(pgmodels.EventArticleUpdated, number))
+	}
+
+	if _, err := db.UpdateArticle(ctx, &article.ID, article, events...); err != nil {
+		if delErr := db.DeleteRevision(ctx, &revision.ID); delErr != nil {
+			slog.ErrorContext(ctx, "Failed to roll back revision", "revision", number, "article", article.ID.Hex(), "error", delErr)
+		}
+		return 0, err
+	}
+
+	return number, nil
+}
+
+// findManagedArticle loads an article the caller is allowed to edit and see the history of.
+func findManagedArticle(ctx context.Context, db *mongo.Client, id string) (*mongomodels.ArticleDB, error) {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return nil, &UnauthorizedError{}
+	}
+
+	articleOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, &InvalidArticleError{}
+	}
+
+	article, err := db.FindArticleByID(ctx, &articleOID)
+	if err != nil {
+		return nil, &InvalidArticleError{}
+	}
+
+	if !userClaims.CanManage(article.PublisherID) {
+		return nil, &ForbiddenError{}
+	}
+
+	return article, nil
+}
+
+func findRevision(ctx context.Context, db *mongo.Client, articleID *primitive.ObjectID, number int) (*mongomodels.RevisionDB, error) {
+	revision, err := db.FindRevision(ctx, articleID, number)
+	if errors.Is(err, mongo.ErrRevisionNotFound) {
+		return nil, &InvalidRevisionError{}
+	}
+
+	return revision, err
+}
+
+// GetRevisions lists the revisions of an article without their content.
+func GetRevisions(ctx context.Context, db *mongo.Client, id string) ([]mongomodels.RevisionDB, error) {
+	article, err := findManagedArticle(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.FindRevisions(ctx, &article.ID)
+}
+
+// GetRevision returns a single revision of an article, including its content.
+func GetRevision(ctx context.Context, db *mongo.Client, id string, number int) (*mongomodels.RevisionDB, error) {
+	article, err := findManagedArticle(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return findRevision(ctx, db, &article.ID, number)
+}
+
+// DiffRevisions computes a line diff of the title, category and content of two revisions.
+func DiffRevisions(ctx context.Context, db *mongo.Client, id string, from, to int) (*RevisionDiffDTO, error) {
+	article, err := findManagedArticle(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	fromRev, err := findRevision(ctx, db, &article.ID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	toRev, err := findRevision(ctx, db, &article.ID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RevisionDiffDTO{
+		Title:    diff.Lines(fromRev.Title, toRev.Title),
+		Category: diff.Lines(fromRev.Category, toRev.Category),
+		Content:  diff.Lines(fromRev.Content, toRev.Content),
+		From:     from,
+		To:       to,
+	}, nil
+}
+
+/*
+RestoreRevision makes an old revision the current state of the article.
+History is never rewritten: the restored state is saved as a new revision.
+@returns
+int - the number of the new revision.
+error - for checking the successful execution of the function.
+*/
+func RestoreRevision(ctx context.Context, db *mongo.Client, id string, number int) (int, error) {
+	article, err := findManagedArticle(ctx, db, id)
+	if err != nil {
+		return 0, err
+	}
+
+	revision, err := findRevision(ctx, db, &article.ID, number)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := seedRevision(ctx, db, article); err != nil {
+		return 0, err
+	}
+
+	article.Title = revision.Title
+	article.Content = revision.Content
+	article.ContentFormat = revision.ContentFormat
+	article.Category = revision.Category
+
+	return saveRevision(ctx, db, article, fmt.Sprintf("Restored revision %d", number), number)
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"blog-service/internal/db/mongo"
+	mongomodels "blog-service/internal/db/mongo/models"
+	"blog-service/internal/sitemap"
+	"context"
+	"io"
+	"strconv"
+)
+
+// SitemapPages returns how many sitemaps are needed to list every published article.
+func SitemapPages(ctx context.Context, db *mongo.Client) (int, error) {
+	count, err := db.CountPublishedArticles(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pages := int((count + sitemap.MaxURLs - 1) / sitemap.MaxURLs)
+
+	return max(pages, 1), nil
+}
+
+// SitemapPageURL is where blog-service, reachable at publicURL, serves the given page of a split sitemap.
+func SitemapPageURL(publicURL string, page int) string {
+	return publicURL + "/sitemaps/" + strconv.Itoa(page) + ".xml"
+}
+
+/*
+WriteSitemap streams one page (starting at 1) of the article sitemap to w.
+Articles are written as they are read from the cursor.
+*/
+func WriteSitemap(ctx context.Context, db *mongo.Client, w io.Writer, siteURL string, page int) error {
+	urlSet, err := sitemap.NewURLSetWriter(w)
+	if err != nil {
+		return err
+	}
+
+	skip := int64(page-1) * sitemap.MaxURLs
+
+	err = db.StreamPublishedArticles(ctx, skip, sitemap.MaxURLs, func(article *mongomodels.ArticleDB) error {
+		return urlSet.WriteURL(siteURL+articlePath(article), lastModified(article))
+	})
+	if err != nil {
+		return err
+	}
+
+	return urlSet.Close()
+}
+
+// WriteSitemapIndex writes the index listing every page of a split sitemap.
+func WriteSitemapIndex(w io.Writer, publicURL string, pages int) error {
+	entries := make([]sitemap.Entry, 0, pages)
+	for page := 1; page <= pages; page++ {
+		entries = append(entries, sitemap.Entry{Loc: SitemapPageURL(publicURL, page)})
+	}
+
+	return sitemap.WriteIndex(w, entries)
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	mongomodels "blog-service/internal/db/mongo/models"
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWriteSitemapIndexLinksToBlogService(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := WriteSitemapIndex(&buf, "https://api.example.com", 2); err != nil {
+		t.Fatalf("Failed to write index: %s", err)
+	}
+
+	for _, want := range []string{"https://api.example.com/sitemaps/1.xml", "https://api.example.com/sitemaps/2.xml"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Index does not list %s:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestArticlePath(t *testing.T) {
+	id := primitive.NewObjectID()
+
+	if got := articlePath(&mongomodels.ArticleDB{ID: id, Slug: "hello-world"}); got != "/article/by-slug/hello-world" {
+		t.Errorf("articlePath = %q, want the slug", got)
+	}
+	if got := articlePath(&mongomodels.ArticleDB{ID: id}); got != "/article/"+id.Hex() {
+		t.Errorf("articlePath = %q, want the id of an article without slug", got)
+	}
+}
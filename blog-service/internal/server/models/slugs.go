@@ -0,0 +1,90 @@
+package models
+
+import (
+	"blog-service/internal/db/mongo"
+	mongomodels "blog-service/internal/db/mongo/models"
+	"blog-service/internal/slug"
+	"context"
+	"errors"
+	"fmt"
+)
+
+type ArticleSlugDTO struct {
+	ID   string
+	Slug string
+	// Moved is set when the slug is an old one and the article now lives under Slug
+	Moved bool
+}
+
+/*
+slugMatches reports whether the current slug of the article was built from base.
+A numeric suffix only counts as the collision counter when the slug was allocated for base,
+"foo-2024" stays the slug of a "Foo 2024" renamed to "Foo" otherwise.
+*/
+func slugMatches(article *mongomodels.ArticleDB, base string) bool {
+	return article.Slug == base || article.SlugBase == base
+}
+
+// articlePath is the path linking to an article on the site, by its slug once it has one.
+func articlePath(article *mongomodels.ArticleDB) string {
+	if article.Slug == "" {
+		return "/article/" + article.ID.Hex()
+	}
+
+	return "/article/by-slug/" + article.Slug
+}
+
+/*
+assignSlug gives the article a unique slug built from its title.
+Nothing happens when the current slug already matches the title.
+The previous slug stays reserved for the article so old links can be redirected.
+*/
+func assignSlug(ctx context.Context, db *mongo.Client, article *mongomodels.ArticleDB) error {
+	base := slug.Make(article.Title)
+	if article.Slug != "" && slugMatches(article, base) {
+		return nil
+	}
+
+	for _, candidate := range slug.Candidates(base, article.ID.Hex()) {
+		ok, err := db.ReserveSlug(ctx, candidate, &article.ID)
+		if err != nil {
+			return err
+		}
+		if ok {
+			article.Slug, article.SlugBase = candidate, base
+			return nil
+		}
+	}
+
+	return fmt.Errorf("failed to find a free slug for %q", base)
+}
+
+/*
+ResolveSlug finds the article a slug points to.
+Old slugs resolve to the article too, with Moved set and Slug holding the current one,
+as long as the caller may view it.
+*/
+func ResolveSlug(ctx context.Context, db *mongo.Client, s string) (*ArticleSlugDTO, error) {
+	article, err := db.FindArticleBySlug(ctx, s)
+	if err == nil {
+		return &ArticleSlugDTO{ID: article.ID.Hex(), Slug: article.Slug}, nil
+	}
+	if !errors.Is(err, mongo.ErrSlugNotFound) {
+		return nil, err
+	}
+
+	owner, err := db.FindSlugOwner(ctx, s)
+	if errors.Is(err, mongo.ErrSlugNotFound) {
+		return nil, &InvalidArticleError{}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	article, err = db.FindArticleByID(ctx, &owner)
+	if err != nil || article.Slug == "" || !canView(ctx, article) {
+		return nil, &InvalidArticleError{}
+	}
+
+	return &ArticleSlugDTO{ID: article.ID.Hex(), Slug: article.Slug, Moved: true}, nil
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	mongomodels "blog-service/internal/db/mongo/models"
+	"testing"
+)
+
+func TestSlugMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		article mongomodels.ArticleDB
+		base    string
+		want    bool
+	}{
+		{"same slug", mongomodels.ArticleDB{Slug: "foo"}, "foo", true},
+		{"collision counter", mongomodels.ArticleDB{Slug: "foo-2", SlugBase: "foo"}, "foo", true},
+		{"number from the old title", mongomodels.ArticleDB{Slug: "foo-2024", SlugBase: "foo-2024"}, "foo", false},
+		{"number without a recorded base", mongomodels.ArticleDB{Slug: "foo-2024"}, "foo", false},
+		{"other title", mongomodels.ArticleDB{Slug: "foo", SlugBase: "foo"}, "bar", false},
+	}
+
+	for _, tt := range tests {
+		if got := slugMatches(&tt.article, tt.base); got != tt.want {
+			t.Errorf("%s: slugMatches(%q, %q) = %v, want %v", tt.name, tt.article.Slug, tt.base, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"blog-service/internal/db/mongo"
+	mongomodels "blog-service/internal/db/mongo/models"
+	pgmodels "blog-service/internal/db/postgres/models"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ArticleStatusUpdateDTO struct {
+	PublishAt *time.Time `json:"publishAt,omitempty"`
+	Status    string     `json:"status"`
+}
+
+/*
+resolveStatus validates a requested lifecycle state and works out the publish time.
+An empty status publishes immediately, a scheduled article needs a publish time in the future.
+@returns
+string - the status to store.
+time.Time - when the article was or will be published, zero for drafts and archived articles.
+error - ParamError when the combination is invalid.
+*/
+func resolveStatus(status string, publishAt *time.Time, now time.Time) (string, time.Time, error) {
+	switch status {
+	case "", mongomodels.StatusPublished:
+		return mongomodels.StatusPublished, now, nil
+	case mongomodels.StatusDraft, mongomodels.StatusArchived:
+		return status, time.Time{}, nil
+	case mongomodels.StatusScheduled:
+		if publishAt == nil || !publishAt.After(now) {
+			return "", time.Time{}, &ParamError{}
+		}
+		return status, publishAt.UTC(), nil
+	default:
+		return "", time.Time{}, &ParamError{}
+	}
+}
+
+/*
+planStatusChange applies the lifecycle rules to a requested change of the status of article.
+@returns
+string, time.Time - the status and publish time to store.
+[]mongomodels.ArticleEventDB - article.published when the article becomes visible.
+error - ForbiddenError for hidden articles, ParamError when the request is invalid.
+*/
+func planStatusChange(article *mongomodels.ArticleDB, dto *ArticleStatusUpdateDTO,
+	now time.Time) (string, time.Time, []mongomodels.ArticleEventDB, error) {
+	// Hidden articles are only restored through moderation, so the decision ends up in the audit trail
+	if article.Status == mongomodels.StatusHidden {
+		return "", time.Time{}, nil, &ForbiddenError{}
+	}
+
+	status, publishAt, err := resolveStatus(dto.Status, dto.PublishAt, now)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+
+	switch {
+	case status == mongomodels.StatusArchived:
+		// Archived articles remember when they were published
+		publishAt = article.PublishAt
+	case status == mongomodels.StatusPublished && article.IsPublished() && !article.PublishAt.IsZero():
+		// Keep the original publish date when an already published article is published again
+		publishAt = article.PublishAt
+	}
+
+	var events []mongomodels.ArticleEventDB
+	if status == mongomodels.StatusPublished && !article.IsPublished() {
+		events = append(events, mongomodels.NewArticleEvent(pgmodels.EventArticlePublished, 0))
+	}
+
+	return status, publishAt, events, nil
+}
+
+// canView reports whether the caller may read the article in its current state.
+func canView(ctx context.Context, article *mongomodels.ArticleDB) bool {
+	if article.IsPublished() {
+		return true
+	}
+
+	userClaims := GetClaimsFromContext(ctx)
+
+	return userClaims != nil && userClaims.CanManage(article.PublisherID)
+}
+
+/*
+UpdateArticleStatus moves an article through its lifecycle
+(draft, scheduled, published, archived). Only the publisher and admins may do so.
+Publishing an article that was not visible records article.published.
+*/
+func UpdateArticleStatus(ctx context.Context, db *mongo.Client, id string, dto *ArticleStatusUpdateDTO) error {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return &UnauthorizedError{}
+	}
+
+	articleOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &InvalidArticleError{}
+	}
+
+	article, err := db.FindArticleByID(ctx, &articleOID)
+	if err != nil {
+		return &InvalidArticleError{}
+	}
+
+	if !userClaims.CanManage(article.PublisherID) {
+		return &ForbiddenError{}
+	}
+
+	status, publishAt, events, err := planStatusChange(article, dto, time.Now())
+	if err != nil {
+		return err
+	}
+
+	_, err = db.UpdateArticleStatus(ctx, &articleOID, status, publishAt, events...)
+
+	return err
+}
+
+// GetDrafts returns the draft and scheduled articles of the authenticated user.
+func GetDrafts(ctx context.Context, db *mongo.Client) ([]*mongomodels.ArticleDB, error) {
+	userClaims := GetClaimsFromContext(ctx)
+	if userClaims == nil {
+		return nil, &UnauthorizedError{}
+	}
+
+	return db.FindArticlesByStatus(ctx, userClaims.ID,
+		[]string{mongomodels.StatusDraft, mongomodels.StatusScheduled})
+}
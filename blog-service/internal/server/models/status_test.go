@@ -0,0 +1,119 @@
+package models
+
+import (
+	mongomodels "blog-service/internal/db/mongo/models"
+	pgmodels "blog-service/internal/db/postgres/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanStatusChange(t *testing.T) {
+	now := time.Date(2025, 9, 19, 12, 0, 0, 0, time.UTC)
+	published := now.Add(-48 * time.Hour)
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		article       mongomodels.ArticleDB
+		dto           ArticleStatusUpdateDTO
+		wantStatus    string
+		wantPublishAt time.Time
+		wantEvent     bool
+		wantErr       error
+	}{
+		{
+			name:          "publishing a draft publishes it now",
+			article:       mongomodels.ArticleDB{Status: mongomodels.StatusDraft},
+			dto:           ArticleStatusUpdateDTO{Status: mongomodels.StatusPublished},
+			wantStatus:    mongomodels.StatusPublished,
+			wantPublishAt: now,
+			wantEvent:     true,
+		},
+		{
+			name:          "empty status publishes",
+			article:       mongomodels.ArticleDB{Status: mongomodels.StatusScheduled, PublishAt: later},
+			wantStatus:    mongomodels.StatusPublished,
+			wantPublishAt: now,
+			wantEvent:     true,
+		},
+		{
+			name:          "publishing again keeps the publish date",
+			article:       mongomodels.ArticleDB{Status: mongomodels.StatusPublished, PublishAt: published},
+			dto:           ArticleStatusUpdateDTO{Status: mongomodels.StatusPublished},
+			wantStatus:    mongomodels.StatusPublished,
+			wantPublishAt: published,
+		},
+		{
+			name:          "archiving keeps the publish date",
+			article:       mongomodels.ArticleDB{Status: mongomodels.StatusPublished, PublishAt: published},
+			dto:           ArticleStatusUpdateDTO{Status: mongomodels.StatusArchived},
+			wantStatus:    mongomodels.StatusArchived,
+			wantPublishAt: published,
+		},
+		{
+			name:          "unpublishing to a draft clears the publish date",
+			article:       mongomodels.ArticleDB{Status: mongomodels.StatusPublished, PublishAt: published},
+			dto:           ArticleStatusUpdateDTO{Status: mongomodels.StatusDraft},
+			wantStatus:    mongomodels.StatusDraft,
+			wantPublishAt: time.Time{},
+		},
+		{
+			name:          "scheduling in the future",
+			article:       mongomodels.ArticleDB{Status: mongomodels.StatusDraft},
+			dto:           ArticleStatusUpdateDTO{Status: mongomodels.StatusScheduled, PublishAt: &later},
+			wantStatus:    mongomodels.StatusScheduled,
+			wantPublishAt: later,
+		},
+		{
+			name:    "scheduling in the past",
+			article: mongomodels.ArticleDB{Status: mongomodels.StatusDraft},
+			dto:     ArticleStatusUpdateDTO{Status: mongomodels.StatusScheduled, PublishAt: &earlier},
+			wantErr: &ParamError{},
+		},
+		{
+			name:    "scheduling without a publish time",
+			article: mongomodels.ArticleDB{Status: mongomodels.StatusDraft},
+			dto:     ArticleStatusUpdateDTO{Status: mongomodels.StatusScheduled},
+			wantErr: &ParamError{},
+		},
+		{
+			name:    "unknown status",
+			article: mongomodels.ArticleDB{Status: mongomodels.StatusDraft},
+			dto:     ArticleStatusUpdateDTO{Status: "deleted"},
+			wantErr: &ParamError{},
+		},
+		{
+			name:    "hidden articles are only restored through moderation",
+			article: mongomodels.ArticleDB{Status: mongomodels.StatusHidden, PublishAt: published},
+			dto:     ArticleStatusUpdateDTO{Status: mongomodels.StatusPublished},
+			wantErr: &ForbiddenError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, publishAt, events, err := planStatusChange(&tt.article, &tt.dto, now)
+
+			if tt.wantErr != nil {
+				if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErr) {
+					t.Fatalf("err = %v, want %T", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if status != tt.wantStatus || !publishAt.Equal(tt.wantPublishAt) {
+				t.Errorf("Got %q at %v, want %q at %v", status, publishAt, tt.wantStatus, tt.wantPublishAt)
+			}
+
+			published := len(events) == 1 && events[0].Type == pgmodels.EventArticlePublished
+			if published != tt.wantEvent || len(events) > 1 {
+				t.Errorf("events = %+v, want article.published: %t", events, tt.wantEvent)
+			}
+		})
+	}
+}
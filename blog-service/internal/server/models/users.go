@@ -0,0 +1,89 @@
+package models
+
+import (
+	"blog-service/internal/blob"
+	"blog-service/internal/db/mongo"
+	"blog-service/internal/db/postgres"
+	pgmodels "blog-service/internal/db/postgres/models"
+	"blog-service/internal/userdeletion"
+	"context"
+	"fmt"
+)
+
+// Author the content of deleted users is credited to when it is anonymised.
+const (
+	DeletedUserID   = 0
+	DeletedUserName = "deleted user"
+)
+
+/*
+RegisterUserDeletionSteps adds to worker the steps removing the content of a deleted user.
+Likes go in both modes. Comments and articles, with their history and slugs, are deleted
+when purging, otherwise credited to DeletedUserName. Uploads follow the articles, purging
+removes their blobs from blobStore as well. Comments run before relations so the
+notifications they caused are kept anonymised, the rest of the user's relations goes last.
+*/
+func RegisterUserDeletionSteps(worker *userdeletion.Worker, pgdb *postgres.Client, mdb *mongo.Client, blobStore blob.BlobStore) {
+	worker.Step("likes", func(ctx context.Context, job *pgmodels.UserDeletionJob) (int64, error) {
+		return pgdb.DeleteUserLikes(ctx, job.UserID)
+	})
+	worker.Step("comments", func(ctx context.Context, job *pgmodels.UserDeletionJob) (int64, error) {
+		if job.Mode == pgmodels.DeletionPurge {
+			return pgdb.DeleteUserComments(ctx, job.UserID)
+		}
+		return pgdb.AnonymiseUserComments(ctx, job.UserID, DeletedUserID, DeletedUserName)
+	})
+	worker.Step("articles", func(ctx context.Context, job *pgmodels.UserDeletionJob) (int64, error) {
+		if job.Mode != pgmodels.DeletionPurge {
+			return mdb.AnonymisePublisher(ctx, job.UserID, DeletedUserID, DeletedUserName)
+		}
+
+		articleIDs, err := mdb.FindArticleIDsByPublisher(ctx, job.UserID)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, articleID := range articleIDs {
+			// Recorded first, its handlers remove the comments once the article is gone
+			if err := pgdb.RecordArticleEvent(ctx, pgmodels.EventArticleDeleted, pgmodels.ArticleEvent{ArticleID: articleID.Hex()}); err != nil {
+				return 0, err
+			}
+			if err := deleteArticle(ctx, mdb, &articleID); err != nil {
+				return 0, err
+			}
+		}
+
+		return int64(len(articleIDs)), nil
+	})
+	worker.Step("uploads", func(ctx context.Context, job *pgmodels.UserDeletionJob) (int64, error) {
+		// Anonymising already credited them to DeletedUserID with the articles
+		if job.Mode != pgmodels.DeletionPurge {
+			return 0, nil
+		}
+
+		assets, err := mdb.FindAssetsByOwner(ctx, job.UserID)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, asset := range assets {
+			// Blobs go first, an asset whose document is gone would never be found again
+			for _, key := range []string{asset.Key, asset.ThumbnailKey} {
+				if key == "" {
+					continue
+				}
+				if err := blobStore.Delete(ctx, key); err != nil {
+					return 0, fmt.Errorf("failed to delete blob %s: %w", key, err)
+				}
+			}
+			if err := mdb.DeleteAsset(ctx, &asset.ID); err != nil {
+				return 0, err
+			}
+		}
+
+		return int64(len(assets)), nil
+	})
+	worker.Step("relations", func(ctx context.Context, job *pgmodels.UserDeletionJob) (int64, error) {
+		return pgdb.DeleteUserRelations(ctx, job.UserID)
+	})
+}
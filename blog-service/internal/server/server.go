@@ -1,16 +1,27 @@
 package server
 
 import (
+	"blog-service/internal/blob"
+	"blog-service/internal/contentfilter"
 	"blog-service/internal/db/mongo"
 	pg "blog-service/internal/db/postgres"
 	pb "blog-service/internal/grpc/protobuf"
-	"log"
+	"blog-service/internal/health"
+	"blog-service/internal/live"
+	"blog-service/internal/logging"
+	"blog-service/internal/metrics"
+	"blog-service/internal/notify"
+	"blog-service/internal/ratelimit"
+	"context"
+	"log/slog"
+	"net"
 	"net/http"
 	"time"
 
 	"blog-service/internal/server/handlers"
 
 	"github.com/rs/cors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 const (
@@ -19,25 +30,79 @@ const (
 	idleTimeout  = 120 * time.Second
 )
 
+// Config holds the settings of the HTTP API.
+type Config struct {
+	AllowedOrigins []string
+	SiteURL        string
+	// PublicURL is where blog-service itself is reachable, for the links to the documents it serves
+	PublicURL         string
+	AutoHideThreshold int
+	// TrustProxy identifies clients by X-Forwarded-For for rate limiting, only when behind a proxy
+	TrustProxy bool
+	// RateLimits replaces the default limit of the routes, by rule name
+	RateLimits map[string]ratelimit.Limit
+}
+
 type Server struct {
+	config         Config
 	mux            *http.ServeMux
 	mongoClient    *mongo.Client
 	postgresClient *pg.Client
 	authClient     pb.AuthServiceClient
+	blobStore      blob.BlobStore
+	notifier       *notify.Dispatcher
+	bus            *live.Bus
+	filter         contentfilter.ContentFilter
+	limiter        *handlers.RateLimiter
+	checker        *health.Checker
+	httpServer     *http.Server
 }
 
-func NewServer(mongoClient *mongo.Client, postgresClient *pg.Client, authClient pb.AuthServiceClient) *Server {
+func NewServer(config Config, mongoClient *mongo.Client, postgresClient *pg.Client, authClient pb.AuthServiceClient, blobStore blob.BlobStore, notifier *notify.Dispatcher, bus *live.Bus, filter contentfilter.ContentFilter, rateLimitStore ratelimit.Store, checker *health.Checker) *Server {
 	mux := http.NewServeMux()
 
 	s := &Server{
+		config:         config,
 		mux:            mux,
 		mongoClient:    mongoClient,
 		postgresClient: postgresClient,
 		authClient:     authClient,
+		blobStore:      blobStore,
+		notifier:       notifier,
+		bus:            bus,
+		filter:         filter,
+		limiter: &handlers.RateLimiter{
+			Store:      rateLimitStore,
+			TrustProxy: config.TrustProxy,
+		},
+		checker: checker,
 	}
 
 	s.registerRoutes()
 
+	// --- CORS Configuration ---
+	c := cors.New(cors.Options{
+		AllowedOrigins: config.AllowedOrigins,
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", logging.RequestIDHeader},
+		ExposedHeaders: []string{logging.RequestIDHeader, "Retry-After", "RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
+	})
+
+	// Event streams end when shutdown begins, see handlers.WithStreamShutdown
+	draining := make(chan struct{})
+
+	s.httpServer = &http.Server{
+		// Wrap existing server handler CORS middleware, requests are logged with their ID around it
+		Handler:      logging.Middleware(c.Handler(s)),
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  idleTimeout,
+		ConnContext: func(ctx context.Context, _ net.Conn) context.Context {
+			return handlers.WithStreamShutdown(ctx, draining)
+		},
+	}
+	s.httpServer.RegisterOnShutdown(func() { close(draining) })
+
 	return s
 }
 
@@ -46,65 +111,174 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) registerRoutes() {
+	// Probes are neither authenticated nor rate limited
+	healthHandler := &handlers.HealthHandler{Checker: s.checker}
+	s.handle("/healthz", healthHandler)
+	s.handle("/readyz", healthHandler)
+
 	protectedArticleHandler := handlers.AuthMiddleware(
-		&handlers.ArticleHandler{
-			MongoDB:    s.mongoClient,
-			PostgresDB: s.postgresClient,
-		},
+		s.limiter.Middleware(
+			&handlers.ArticleHandler{
+				MongoDB:    s.mongoClient,
+				PostgresDB: s.postgresClient,
+				Bus:        s.bus,
+			},
+			s.rateLimitRule("article-write", ratelimit.Per(30, time.Hour), http.MethodPost, http.MethodPut),
+		),
 		s.authClient,
 	)
 
 	protectedCommentHandler := handlers.AuthMiddleware(
-		&handlers.CommentHandler{
-			MongoDB:    s.mongoClient,
-			PostgresDB: s.postgresClient,
-		},
+		s.limiter.Middleware(
+			&handlers.CommentHandler{
+				MongoDB:    s.mongoClient,
+				PostgresDB: s.postgresClient,
+				Bus:        s.bus,
+				Filter:     s.filter,
+			},
+			s.rateLimitRule("comment-create", ratelimit.Per(10, time.Minute), http.MethodPost),
+			s.rateLimitRule("comment-change", ratelimit.Per(60, time.Minute), http.MethodPut, http.MethodDelete),
+		),
 		s.authClient,
 	)
 
-	s.mux.Handle("/article/{id}", protectedArticleHandler)
-	s.mux.Handle("/article/{id}/", protectedArticleHandler)
+	s.handle("/article/by-slug/{slug}", protectedArticleHandler)
+	s.handle("/article/by-slug/{slug}/", protectedArticleHandler)
+
+	s.handle("/article/{id}", protectedArticleHandler)
+	s.handle("/article/{id}/", protectedArticleHandler)
+
+	s.handle("/article", protectedArticleHandler)
+	s.handle("/article/", protectedArticleHandler)
+
+	s.handle("/comment", protectedCommentHandler)
+	s.handle("/comment/", protectedCommentHandler)
 
-	s.mux.Handle("/article", protectedArticleHandler)
-	s.mux.Handle("/article/", protectedArticleHandler)
+	// Login is limited by address, every attempt costs an argon2 hash in auth-service
+	registerHandler := s.limiter.Middleware(&handlers.AuthHandler{AuthClient: s.authClient},
+		s.rateLimitRule("register", ratelimit.Per(5, time.Hour)))
+	s.handle("/auth/register", registerHandler)
+	s.handle("/auth/register/", registerHandler)
 
-	s.mux.Handle("/comment", protectedCommentHandler)
-	s.mux.Handle("/comment/", protectedCommentHandler)
+	loginHandler := s.limiter.Middleware(&handlers.AuthHandler{AuthClient: s.authClient},
+		s.rateLimitRule("login", ratelimit.Per(10, time.Minute)))
+	s.handle("/auth/login", loginHandler)
+	s.handle("/auth/login/", loginHandler)
 
-	s.mux.Handle("/auth/register", &handlers.AuthHandler{AuthClient: s.authClient})
-	s.mux.Handle("/auth/register/", &handlers.AuthHandler{AuthClient: s.authClient})
+	protectedAssetHandler := handlers.AuthMiddleware(
+		s.limiter.Middleware(
+			&handlers.AssetHandler{MongoDB: s.mongoClient, Store: s.blobStore},
+			s.rateLimitRule("upload", ratelimit.Per(60, time.Hour), http.MethodPost),
+		),
+		s.authClient,
+	)
+	s.handle("/upload", protectedAssetHandler)
+	s.handle("/upload/", protectedAssetHandler)
+	s.handle("/assets/", &handlers.AssetHandler{MongoDB: s.mongoClient, Store: s.blobStore})
 
-	s.mux.Handle("/auth/login", &handlers.AuthHandler{AuthClient: s.authClient})
-	s.mux.Handle("/auth/login/", &handlers.AuthHandler{AuthClient: s.authClient})
+	protectedMeHandler := handlers.AuthMiddleware(
+		&handlers.MeHandler{MongoDB: s.mongoClient},
+		s.authClient,
+	)
+	s.handle("/me/drafts", protectedMeHandler)
+	s.handle("/me/drafts/", protectedMeHandler)
+
+	protectedFollowHandler := handlers.AuthMiddleware(
+		&handlers.FollowHandler{MongoDB: s.mongoClient, PostgresDB: s.postgresClient, Notifier: s.notifier},
+		s.authClient,
+	)
+	s.handle("/follows/authors/{id}", protectedFollowHandler)
+	s.handle("/follows/categories/{name}", protectedFollowHandler)
+	s.handle("/me/following", protectedFollowHandler)
+	s.handle("/authors/{id}", protectedFollowHandler)
+	s.handle("/feed", protectedFollowHandler)
+	s.handle("/feed/", protectedFollowHandler)
+
+	protectedNotificationHandler := handlers.AuthMiddleware(
+		&handlers.NotificationHandler{PostgresDB: s.postgresClient, Notifier: s.notifier},
+		s.authClient,
+	)
+	s.handle("/notifications", protectedNotificationHandler)
+	s.handle("/notifications/", protectedNotificationHandler)
+
+	protectedBookmarkHandler := handlers.AuthMiddleware(
+		&handlers.BookmarkHandler{MongoDB: s.mongoClient, PostgresDB: s.postgresClient},
+		s.authClient,
+	)
+	s.handle("/bookmarks", protectedBookmarkHandler)
+	s.handle("/bookmarks/", protectedBookmarkHandler)
+
+	protectedModerationHandler := handlers.AuthMiddleware(
+		s.limiter.Middleware(
+			&handlers.ModerationHandler{
+				MongoDB:           s.mongoClient,
+				PostgresDB:        s.postgresClient,
+				Bus:               s.bus,
+				AutoHideThreshold: s.config.AutoHideThreshold,
+			},
+			s.rateLimitRule("report", ratelimit.Per(20, time.Hour), http.MethodPost),
+		),
+		s.authClient,
+	)
+	s.handle("/reports", protectedModerationHandler)
+	s.handle("/reports/", protectedModerationHandler)
+	s.handle("/moderation/", protectedModerationHandler)
+
+	sitemapHandler := &handlers.SitemapHandler{MongoDB: s.mongoClient, SiteURL: s.config.SiteURL, PublicURL: s.config.PublicURL}
+	s.handle("/sitemap.xml", sitemapHandler)
+	s.handle("/sitemaps/{page}", sitemapHandler)
+
+	feedHandler := &handlers.FeedHandler{MongoDB: s.mongoClient, SiteURL: s.config.SiteURL}
+	s.handle("/feed.rss", feedHandler)
+	s.handle("/feed.atom", feedHandler)
+	s.handle("/authors/{id}/feed.atom", feedHandler)
+	s.handle("/categories/{name}/feed.atom", feedHandler)
 
 	blogHandler := &handlers.BlogHandler{Mongo: s.mongoClient}
-	s.mux.Handle("/blog", blogHandler)
-	s.mux.Handle("/blog/by-publisher", blogHandler)
+	s.handle("/blog", blogHandler)
+	s.handle("/blog/by-publisher", blogHandler)
 }
 
-func (s *Server) Start(addr string) error {
-	// --- CORS Configuration ---
-	c := cors.New(cors.Options{
-		AllowedOrigins: []string{"http://localhost:5173"},
-		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{"Content-Type", "Authorization"},
-	})
+/*
+handle registers a route, instrumented with its pattern as the metrics label and span name.
+The span continues the trace context sent by the caller, if any.
+*/
+func (s *Server) handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, metrics.InstrumentHandler(pattern, otelhttp.NewHandler(handler, pattern)))
+}
 
-	// Wrap existing server handler CORS middleware
-	handlerWithCORS := c.Handler(s)
+/*
+rateLimitRule creates the rate limit of a route for the given methods, all methods when none are given.
+The configured limit of the rule, if any, replaces the default one.
+*/
+func (s *Server) rateLimitRule(name string, limit ratelimit.Limit, methods ...string) handlers.RateLimitRule {
+	if configured, ok := s.config.RateLimits[name]; ok {
+		limit = configured
+	}
 
-	httpServer := &http.Server{
-		Addr:         addr,
-		Handler:      handlerWithCORS,
-		ReadTimeout:  readTimeout,
-		WriteTimeout: writeTimeout,
-		IdleTimeout:  idleTimeout,
+	return handlers.RateLimitRule{Name: name, Methods: methods, Limit: limit}
+}
+
+/*
+Start serves HTTP on addr until Shutdown is called.
+@returns
+error - nil after a shutdown, otherwise why serving failed.
+*/
+func (s *Server) Start(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
 	}
-	log.Printf("Starting blog service on %s", httpServer.Addr)
+	slog.Info("Starting blog service", "addr", addr)
 
-	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if err := s.httpServer.Serve(lis); err != nil && err != http.ErrServerClosed {
 		return err
 	}
 
 	return nil
 }
+
+// Shutdown stops accepting connections and waits for in-flight requests until ctx expires.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
@@ -0,0 +1,116 @@
+package sitemap
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// MaxURLs is the number of URLs a single sitemap may hold according to the protocol.
+const MaxURLs = 50_000
+
+const (
+	ContentType = "application/xml; charset=utf-8"
+	namespace   = "http://www.sitemaps.org/schemas/sitemap/0.9"
+)
+
+/*
+URLSetWriter writes a <urlset> one URL at a time, so a sitemap can be streamed
+straight from a database cursor without holding every URL in memory.
+*/
+type URLSetWriter struct {
+	w     *bufio.Writer
+	count int
+}
+
+func NewURLSetWriter(w io.Writer) (*URLSetWriter, error) {
+	bw := bufio.NewWriter(w)
+
+	if _, err := fmt.Fprintf(bw, "%s<urlset xmlns=%q>\n", xml.Header, namespace); err != nil {
+		return nil, err
+	}
+
+	return &URLSetWriter{w: bw}, nil
+}
+
+// WriteURL adds a URL, lastmod is omitted when zero.
+func (s *URLSetWriter) WriteURL(loc string, lastmod time.Time) error {
+	if s.count >= MaxURLs {
+		return fmt.Errorf("sitemap already holds %d urls", MaxURLs)
+	}
+	s.count++
+
+	if _, err := s.w.WriteString("  <url><loc>"); err != nil {
+		return err
+	}
+
+	if err := xml.EscapeText(s.w, []byte(loc)); err != nil {
+		return err
+	}
+
+	if _, err := s.w.WriteString("</loc>"); err != nil {
+		return err
+	}
+
+	if !lastmod.IsZero() {
+		if _, err := fmt.Fprintf(s.w, "<lastmod>%s</lastmod>", lastmod.UTC().Format(time.RFC3339)); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.w.WriteString("</url>\n")
+
+	return err
+}
+
+// Close finishes the document and flushes it to the underlying writer.
+func (s *URLSetWriter) Close() error {
+	if _, err := s.w.WriteString("</urlset>\n"); err != nil {
+		return err
+	}
+
+	return s.w.Flush()
+}
+
+// Entry is a sitemap listed in a sitemap index.
+type Entry struct {
+	LastMod time.Time
+	Loc     string
+}
+
+// WriteIndex writes a <sitemapindex> pointing at the given sitemaps.
+func WriteIndex(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+
+	if _, err := fmt.Fprintf(bw, "%s<sitemapindex xmlns=%q>\n", xml.Header, namespace); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if _, err := bw.WriteString("  <sitemap><loc>"); err != nil {
+			return err
+		}
+		if err := xml.EscapeText(bw, []byte(e.Loc)); err != nil {
+			return err
+		}
+		if _, err := bw.WriteString("</loc>"); err != nil {
+			return err
+		}
+		if !e.LastMod.IsZero() {
+			if _, err := fmt.Fprintf(bw, "<lastmod>%s</lastmod>", e.LastMod.UTC().Format(time.RFC3339)); err != nil {
+				return err
+			}
+		}
+		if _, err := bw.WriteString("</sitemap>\n"); err != nil {
+			return err
+		}
+	}
+
+	if _, err := bw.WriteString("</sitemapindex>\n"); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,66 @@
+package sitemap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func TestURLSetWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewURLSetWriter(&buf)
+	if err != nil {
+		t.Fatalf("Failed to start sitemap: %s", err)
+	}
+
+	lastmod := time.Date(2025, 9, 19, 12, 0, 0, 0, time.UTC)
+	if err := w.WriteURL("https://blog.example.com/article/1?a=1&b=2", lastmod); err != nil {
+		t.Fatalf("Failed to write url: %s", err)
+	}
+	if err := w.WriteURL("https://blog.example.com/article/2", time.Time{}); err != nil {
+		t.Fatalf("Failed to write url: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close sitemap: %s", err)
+	}
+
+	var doc struct {
+		URLs []struct {
+			Loc     string `xml:"loc"`
+			LastMod string `xml:"lastmod"`
+		} `xml:"url"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Sitemap is not valid XML: %s\n%s", err, buf.String())
+	}
+
+	if len(doc.URLs) != 2 || doc.URLs[0].Loc != "https://blog.example.com/article/1?a=1&b=2" ||
+		doc.URLs[0].LastMod != "2025-09-19T12:00:00Z" || doc.URLs[1].LastMod != "" {
+		t.Errorf("Sitemap content is corrupted: %+v", doc.URLs)
+	}
+}
+
+func TestWriteIndex(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := WriteIndex(&buf, []Entry{{Loc: "https://blog.example.com/sitemaps/1.xml"}})
+	if err != nil {
+		t.Fatalf("Failed to write index: %s", err)
+	}
+
+	var doc struct {
+		XMLName  xml.Name `xml:"sitemapindex"`
+		Sitemaps []struct {
+			Loc string `xml:"loc"`
+		} `xml:"sitemap"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Sitemap index is not valid XML: %s", err)
+	}
+
+	if len(doc.Sitemaps) != 1 || doc.Sitemaps[0].Loc != "https://blog.example.com/sitemaps/1.xml" {
+		t.Errorf("Sitemap index content is corrupted: %+v", doc.Sitemaps)
+	}
+}
@@ -0,0 +1,83 @@
+package slug
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	maxLength = 80
+	fallback  = "article"
+	// Number of numbered candidates (slug, slug-2, ...) tried before the unique one.
+	maxSuffix = 20
+)
+
+// Letters that do not decompose into a base letter and a combining mark.
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ł': "l", 'þ': "th", 'ð': "d", 'ı': "i",
+}
+
+/*
+Make builds a URL safe slug from a title.
+Diacritics are transliterated to their base letter ("Știri din Brașov" -> "stiri-din-brasov"),
+everything that is not a letter or digit becomes a single dash, and long titles
+are cut at a word boundary.
+*/
+func Make(title string) string {
+	var b strings.Builder
+	dash := false
+
+	for _, r := range norm.NFD.String(strings.ToLower(title)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining marks left over from the decomposition (ș -> s + comma below)
+			continue
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			dash = false
+		case transliterations[r] != "":
+			b.WriteString(transliterations[r])
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+
+	s := strings.TrimSuffix(b.String(), "-")
+
+	if len(s) > maxLength {
+		s = s[:maxLength]
+		if i := strings.LastIndexByte(s, '-'); i > 0 {
+			s = s[:i]
+		}
+	}
+
+	if s == "" {
+		return fallback
+	}
+
+	return s
+}
+
+// WithSuffix returns the n-th candidate for a slug that is already taken: slug, slug-2, slug-3, ...
+func WithSuffix(s string, n int) string {
+	if n <= 1 {
+		return s
+	}
+
+	return s + "-" + strconv.Itoa(n)
+}
+
+// Candidates lists the slugs tried for an article in order: s, s-2, ... and finally s-unique.
+func Candidates(s, unique string) []string {
+	candidates := make([]string, 0, maxSuffix+1)
+	for n := 1; n <= maxSuffix; n++ {
+		candidates = append(candidates, WithSuffix(s, n))
+	}
+
+	return append(candidates, s+"-"+unique)
+}
@@ -0,0 +1,48 @@
+package slug
+
+import "testing"
+
+func TestMake(t *testing.T) {
+	tests := map[string]string{
+		"Hello, World!":                   "hello-world",
+		"Știri din Brașov și Țara Bârsei": "stiri-din-brasov-si-tara-barsei",
+		"Ştiri cu sedilă: ţară":           "stiri-cu-sedila-tara",
+		"  --Go 1.24 -- what's new?--  ":  "go-1-24-what-s-new",
+		"Straße & Smørrebrød":             "strasse-smorrebrod",
+		"!!!":                             "article",
+		"日本語":                             "article",
+	}
+
+	for title, want := range tests {
+		if got := Make(title); got != want {
+			t.Errorf("Make(%q): got %q, wanted %q", title, got, want)
+		}
+	}
+}
+
+func TestMakeTruncatesAtWordBoundary(t *testing.T) {
+	title := "a very long title that keeps going and going well beyond what anyone would put in a url bar"
+
+	got := Make(title)
+	if len(got) > maxLength || got[len(got)-1] == '-' {
+		t.Errorf("Slug was not cut cleanly: %q", got)
+	}
+}
+
+func TestWithSuffix(t *testing.T) {
+	if got := WithSuffix("title", 1); got != "title" {
+		t.Errorf("got %q, wanted %q", got, "title")
+	}
+
+	if got := WithSuffix("title", 3); got != "title-3" {
+		t.Errorf("got %q, wanted %q", got, "title-3")
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	got := Candidates("title", "64b0c0ffee")
+
+	if len(got) != maxSuffix+1 || got[0] != "title" || got[1] != "title-2" || got[len(got)-1] != "title-64b0c0ffee" {
+		t.Errorf("Unexpected candidates: %q", got)
+	}
+}
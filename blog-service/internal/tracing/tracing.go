@@ -0,0 +1,66 @@
+// Kept identical in blog-service and auth-service, change both copies together.
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterNone   = "none"
+)
+
+/*
+Setup installs the global tracer provider and the W3C trace context propagator.
+The exporter selects where spans go: "otlp" sends them over gRPC to
+OTEL_EXPORTER_OTLP_ENDPOINT, "stdout" prints them and "none" drops them.
+The propagator is installed in every case, so trace context still flows between the services.
+@returns
+func - flushes the pending spans and stops the provider, to be called on shutdown.
+*/
+func Setup(ctx context.Context, exporterName string) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch exporterName {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracegrpc.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown traces exporter %q, must be otlp, stdout or none", exporterName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", exporterName, err)
+	}
+
+	provider := NewProvider(sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+/*
+NewProvider creates a tracer provider describing this service.
+Tests pass sdktrace.WithSyncer(tracetest.NewInMemoryExporter()) to inspect the recorded spans.
+*/
+func NewProvider(opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	res := resource.NewSchemaless(semconv.ServiceName(ServiceName))
+
+	return sdktrace.NewTracerProvider(append([]sdktrace.TracerProviderOption{sdktrace.WithResource(res)}, opts...)...)
+}
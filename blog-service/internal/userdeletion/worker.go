@@ -0,0 +1,188 @@
+package userdeletion
+
+import (
+	"blog-service/internal/db/postgres/models"
+	pb "blog-service/internal/grpc/protobuf"
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	defaultInterval  = 30 * time.Second
+	defaultBatchSize = 10
+	// defaultLease covers a full run, a longer one may be claimed again by another replica meanwhile
+	defaultLease = 10 * time.Minute
+	callTimeout  = 10 * time.Second
+)
+
+// Store keeps the progress of the jobs, *postgres.Client implements it.
+type Store interface {
+	StartUserDeletionJob(ctx context.Context, job *models.UserDeletionJob) error
+	RecordUserDeletionStep(ctx context.Context, id int64, step string, count int64) error
+	CompleteUserDeletionJob(ctx context.Context, job *models.UserDeletionJob) error
+}
+
+/*
+StepFunc removes or anonymises one kind of content of the user of job.
+It must be idempotent: a step interrupted by a crash runs again.
+@returns
+int64 - how many rows or documents it changed, reported as the outcome of the step.
+*/
+type StepFunc func(ctx context.Context, job *models.UserDeletionJob) (int64, error)
+
+type step struct {
+	name string
+	fn   StepFunc
+}
+
+/*
+Worker carries out the user deletions recorded by auth-service: it claims them, runs every step
+in order and reports the outcome back. Finished steps are recorded, so a deletion claimed again
+after a crash or a failure resumes with the first unfinished step.
+*/
+type Worker struct {
+	auth      pb.AuthServiceClient
+	token     string
+	store     Store
+	steps     []step
+	interval  time.Duration
+	batchSize int
+	lease     time.Duration
+}
+
+// NewWorker creates a worker calling auth-service with the service token it shares with it.
+func NewWorker(auth pb.AuthServiceClient, token string, store Store) *Worker {
+	return &Worker{
+		auth:      auth,
+		token:     token,
+		store:     store,
+		interval:  defaultInterval,
+		batchSize: defaultBatchSize,
+		lease:     defaultLease,
+	}
+}
+
+/*
+Step adds fn to the steps run for every deletion, in the order they are added.
+The name is recorded once fn succeeded, it must stay the same across releases.
+*/
+func (w *Worker) Step(name string, fn StepFunc) {
+	w.steps = append(w.steps, step{name: name, fn: fn})
+}
+
+// Run carries out the pending deletions immediately and then on every tick until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.runBatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runBatch claims the deletions that are due and carries them out.
+func (w *Worker) runBatch(ctx context.Context) {
+	callCtx, cancel := context.WithTimeout(w.withToken(ctx), callTimeout)
+	defer cancel()
+
+	resp, err := w.auth.ClaimUserDeletions(callCtx, &pb.ClaimUserDeletionsRequest{
+		Limit:        int32(w.batchSize),
+		LeaseSeconds: int32(w.lease.Seconds()),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to claim user deletions", "error", err)
+		return
+	}
+
+	for _, deletion := range resp.GetDeletions() {
+		w.process(ctx, deletion)
+	}
+}
+
+func (w *Worker) process(ctx context.Context, deletion *pb.UserDeletion) {
+	job := &models.UserDeletionJob{
+		Mode:   models.DeletionAnonymise,
+		ID:     deletion.GetId(),
+		UserID: int(deletion.GetUserId()),
+	}
+	if deletion.GetMode() == pb.DeletionMode_DELETION_MODE_PURGE {
+		job.Mode = models.DeletionPurge
+	}
+
+	if err := w.store.StartUserDeletionJob(ctx, job); err != nil {
+		slog.ErrorContext(ctx, "Failed to start user deletion", "deletion", job.ID, "error", err)
+		return
+	}
+
+	// A job completed before its report got lost is only reported again
+	if job.CompletedAt == nil {
+		if name, err := w.runSteps(ctx, job); err != nil {
+			slog.WarnContext(ctx, "User deletion step failed", "deletion", job.ID, "user", job.UserID,
+				"step", name, "error", err)
+			w.report(ctx, job, name+": "+err.Error())
+			return
+		}
+
+		if err := w.store.CompleteUserDeletionJob(ctx, job); err != nil {
+			slog.ErrorContext(ctx, "Failed to complete user deletion", "deletion", job.ID, "error", err)
+			return
+		}
+		slog.InfoContext(ctx, "Deleted the content of user", "deletion", job.ID, "user", job.UserID,
+			"mode", job.Mode, "outcome", job.Outcome)
+	}
+
+	w.report(ctx, job, "")
+}
+
+// runSteps runs the steps that did not finish yet, it returns the name of the one that failed.
+func (w *Worker) runSteps(ctx context.Context, job *models.UserDeletionJob) (string, error) {
+	if job.Outcome == nil {
+		job.Outcome = map[string]int64{}
+	}
+
+	for _, s := range w.steps {
+		if _, done := job.Outcome[s.name]; done {
+			continue
+		}
+
+		count, err := s.fn(ctx, job)
+		if err != nil {
+			return s.name, err
+		}
+
+		if err := w.store.RecordUserDeletionStep(ctx, job.ID, s.name, count); err != nil {
+			return s.name, err
+		}
+		job.Outcome[s.name] = count
+	}
+
+	return "", nil
+}
+
+// report sends the outcome to auth-service, the deletion is claimed again after its lease when it fails.
+func (w *Worker) report(ctx context.Context, job *models.UserDeletionJob, cause string) {
+	callCtx, cancel := context.WithTimeout(w.withToken(ctx), callTimeout)
+	defer cancel()
+
+	_, err := w.auth.ReportUserDeletion(callCtx, &pb.ReportUserDeletionRequest{
+		Id:      job.ID,
+		Outcome: job.Outcome,
+		Error:   cause,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to report user deletion", "deletion", job.ID, "error", err)
+	}
+}
+
+func (w *Worker) withToken(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+w.token)
+}
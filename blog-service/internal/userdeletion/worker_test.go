@@ -0,0 +1,149 @@
+package userdeletion
+
+import (
+	"blog-service/internal/db/postgres/models"
+	pb "blog-service/internal/grpc/protobuf"
+	"context"
+	"errors"
+	"maps"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type memoryStore struct {
+	jobs      map[int64]*models.UserDeletionJob
+	completed int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{jobs: map[int64]*models.UserDeletionJob{}}
+}
+
+func (s *memoryStore) StartUserDeletionJob(_ context.Context, job *models.UserDeletionJob) error {
+	stored, ok := s.jobs[job.ID]
+	if !ok {
+		stored = &models.UserDeletionJob{ID: job.ID, UserID: job.UserID, Mode: job.Mode, Outcome: map[string]int64{}}
+		s.jobs[job.ID] = stored
+	}
+
+	job.Outcome = maps.Clone(stored.Outcome)
+	job.CompletedAt = stored.CompletedAt
+	return nil
+}
+
+func (s *memoryStore) RecordUserDeletionStep(_ context.Context, id int64, step string, count int64) error {
+	s.jobs[id].Outcome[step] = count
+	return nil
+}
+
+func (s *memoryStore) CompleteUserDeletionJob(_ context.Context, job *models.UserDeletionJob) error {
+	stored := s.jobs[job.ID]
+	if stored.CompletedAt == nil {
+		now := time.Now()
+		stored.CompletedAt = &now
+		s.completed++
+	}
+	return nil
+}
+
+// fakeAuth hands out its pending deletions on every claim and keeps the reports.
+type fakeAuth struct {
+	pb.AuthServiceClient
+	pending []*pb.UserDeletion
+	reports []*pb.ReportUserDeletionRequest
+	token   string
+}
+
+func (a *fakeAuth) ClaimUserDeletions(ctx context.Context, _ *pb.ClaimUserDeletionsRequest, _ ...grpc.CallOption) (*pb.ClaimUserDeletionsResponse, error) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		a.token = values[0]
+	}
+
+	return &pb.ClaimUserDeletionsResponse{Deletions: a.pending}, nil
+}
+
+func (a *fakeAuth) ReportUserDeletion(_ context.Context, in *pb.ReportUserDeletionRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	a.reports = append(a.reports, in)
+	if in.GetError() == "" {
+		a.pending = nil
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func TestWorkerResumesAfterAFailedStep(t *testing.T) {
+	auth := &fakeAuth{pending: []*pb.UserDeletion{{Id: 7, UserId: 3, Mode: pb.DeletionMode_DELETION_MODE_PURGE}}}
+	store := newMemoryStore()
+	worker := NewWorker(auth, "secret", store)
+
+	likes, comments := 0, 0
+	var mode string
+	worker.Step("likes", func(_ context.Context, job *models.UserDeletionJob) (int64, error) {
+		likes++
+		mode = job.Mode
+		return 2, nil
+	})
+	worker.Step("comments", func(context.Context, *models.UserDeletionJob) (int64, error) {
+		comments++
+		if comments == 1 {
+			return 0, errors.New("database unavailable")
+		}
+		return 5, nil
+	})
+
+	ctx := context.Background()
+	worker.runBatch(ctx)
+
+	if len(auth.reports) != 1 || auth.reports[0].GetError() == "" {
+		t.Fatalf("Failed step should be reported as an error, reports = %v", auth.reports)
+	}
+	if got := auth.reports[0].GetOutcome(); got["likes"] != 2 || len(got) != 1 {
+		t.Errorf("Partial outcome = %v, want only the finished step", got)
+	}
+
+	worker.runBatch(ctx)
+
+	if len(auth.reports) != 2 || auth.reports[1].GetError() != "" {
+		t.Fatalf("Resumed deletion should be reported as done, reports = %v", auth.reports)
+	}
+	if want := map[string]int64{"likes": 2, "comments": 5}; !maps.Equal(auth.reports[1].GetOutcome(), want) {
+		t.Errorf("Outcome = %v, want %v", auth.reports[1].GetOutcome(), want)
+	}
+	if likes != 1 || comments != 2 {
+		t.Errorf("Steps ran %d and %d times, want the finished one once and the failing one twice", likes, comments)
+	}
+	if mode != models.DeletionPurge || store.completed != 1 {
+		t.Errorf("mode = %q, completed %d times, want purge completed once", mode, store.completed)
+	}
+	if auth.token != "Bearer secret" {
+		t.Errorf("authorization = %q, want the service token", auth.token)
+	}
+}
+
+func TestWorkerOnlyReportsCompletedJobs(t *testing.T) {
+	auth := &fakeAuth{pending: []*pb.UserDeletion{{Id: 1, UserId: 3}}}
+	store := newMemoryStore()
+	completedAt := time.Now()
+	store.jobs[1] = &models.UserDeletionJob{ID: 1, UserID: 3, Outcome: map[string]int64{"likes": 4}, CompletedAt: &completedAt}
+	worker := NewWorker(auth, "secret", store)
+
+	runs := 0
+	worker.Step("likes", func(context.Context, *models.UserDeletionJob) (int64, error) {
+		runs++
+		return 0, nil
+	})
+
+	worker.runBatch(context.Background())
+
+	if runs != 0 || store.completed != 0 {
+		t.Errorf("Completed job ran %d steps and completed %d times, want it only reported", runs, store.completed)
+	}
+	if len(auth.reports) != 1 || auth.reports[0].GetOutcome()["likes"] != 4 {
+		t.Errorf("reports = %v, want the stored outcome reported again", auth.reports)
+	}
+}